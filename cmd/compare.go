@@ -0,0 +1,198 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/compare"
+	"github.com/klmitch/overcover/modules"
+	"github.com/klmitch/overcover/report"
+)
+
+// Patch points for top-level functions called by functions in this
+// file.
+var (
+	readFile = os.ReadFile
+)
+
+// Variables used to store the values of flags.
+var (
+	compareBase      string
+	compareHead      string
+	failOnRegression bool
+	tolerance        float64
+)
+
+// compareCmd describes the compare subcommand to cobra.
+var compareCmd = &cobra.Command{
+	Use:   "compare --base FILE --head FILE",
+	Short: "Compare the coverage of two coverage runs",
+	Long:  `Compare the coverage of two coverage runs, such as those of a base branch and of a pull request.  Each run is read from a coverage profile, as generated by passing a filename to the "-coverprofile" option of "go test", or from a JSON report previously written by overcover.  As for the overcover command, the ignore directives in the source and the exclusions in the configuration file are applied to a coverage profile.  The changes in the coverage of each package and of each changed file are reported, along with the files that were added or removed and the files whose coverage dropped.  With --fail-on-regression, the command fails if the coverage of any file dropped by more than the --tolerance.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if compareBase == "" || compareHead == "" {
+			fmt.Fprintf(stderr, "Both --base and --head must be specified.\n")
+			_ = cmd.Usage()
+			exit(2)
+		}
+
+		// Load the runs and compare them
+		mods := workspaceModules()
+		cmp := compare.Compare(loadRun(compareBase, mods), loadRun(compareHead, mods))
+		writeComparison(stdout, cmp)
+
+		// Check for regressions, if requested
+		if !failOnRegression {
+			return
+		}
+		regressions := cmp.Regressions(tolerance)
+		if len(regressions) > 0 {
+			fmt.Fprintf(stderr, "\nCoverage dropped by more than the tolerance of %.1f%% for files:\n", tolerance)
+			for _, d := range regressions {
+				fmt.Fprintf(stderr, "  %s: %+.1f%%\n", d.Handle(), d.Change())
+			}
+			exit(1)
+		}
+	},
+}
+
+// loadRun loads the coverage data of a run from the named file, which
+// may be either a coverage profile or a JSON report.  The ignore
+// directives and the exclusions are applied to the data from a
+// coverage profile, as they are by the overcover command, so that it
+// covers the same statements as a JSON report written by that command.
+func loadRun(fname string, mods []modules.Module) common.DataSet {
+	data, err := readFile(fname)
+	if err == nil && bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var rep *report.Report
+		if rep, err = report.ReadJSON(bytes.NewReader(data)); err == nil {
+			return rep.DataSet()
+		}
+	}
+
+	var ds common.DataSet
+	if err == nil {
+		ds, _, err = loadCoverage([]string{fname}, []string{}, []string{})
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Unable to load coverage data from %s: %s\n", fname, err)
+		exit(3)
+	}
+
+	ds = sourceDirectives(ds, mods)
+	ds, _ = excludeData(stdout, ds, mods)
+	return ds
+}
+
+// coverageCell formats the coverage of one side of a delta for a
+// table, with "-" if the file or package is absent from that run.
+func coverageCell(fd *common.FileData) string {
+	if fd == nil {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.1f%%", fd.Coverage()*100.0)
+}
+
+// changeCell formats the change of a delta for a table.
+func changeCell(d compare.Delta) string {
+	switch {
+	case d.Added():
+		return "added"
+
+	case d.Removed():
+		return "removed"
+	}
+
+	return fmt.Sprintf("%+.1f%%", d.Change())
+}
+
+// writeDeltas writes a table of deltas to the specified stream.
+func writeDeltas(w io.Writer, title, column string, ds []compare.Delta) {
+	fmt.Fprintln(w, title)
+	tab := tabwriter.NewWriter(w, 2, 8, 2, ' ', 0)
+	fmt.Fprintf(tab, " %s\tBase\tHead\tChange\n %s\t----\t----\t------\n", column, strings.Repeat("-", len(column)))
+	for _, d := range ds {
+		fmt.Fprintf(tab, " %s\t%s\t%s\t%s\n", d.Handle(), coverageCell(d.Base), coverageCell(d.Head), changeCell(d))
+	}
+	tab.Flush()
+	fmt.Fprintln(w, "")
+}
+
+// writeComparison writes the changes in coverage to the specified
+// stream.  Every package is listed, but only the files which were
+// added, removed, or whose statement counts changed; the files whose
+// coverage dropped are listed separately.
+func writeComparison(w io.Writer, cmp *compare.Comparison) {
+	writeDeltas(w, "Per package changes:", "Package", cmp.Packages)
+
+	// Select the changed and the regressed files
+	var changed, regressed []compare.Delta
+	for _, d := range cmp.Files {
+		if d.Changed() {
+			changed = append(changed, d)
+		}
+		if d.Regressed(0.0) {
+			regressed = append(regressed, d)
+		}
+	}
+	if len(changed) > 0 {
+		writeDeltas(w, "Per file changes:", "File", changed)
+	}
+	if len(regressed) > 0 {
+		fmt.Fprintln(w, "Regressed files:")
+		for _, d := range regressed {
+			fmt.Fprintf(w, "  %s: %s -> %s (%+.1f%%)\n", d.Handle(), coverageCell(d.Base), coverageCell(d.Head), d.Change())
+		}
+		fmt.Fprintln(w, "")
+	}
+
+	fmt.Fprintf(w, "Overall coverage: %s -> %s (%+.1f%%)\n", coverageCell(cmp.Overall.Base), coverageCell(cmp.Overall.Head), cmp.Overall.Change())
+}
+
+// getToleranceDefault is a helper that retrieves the default
+// tolerance from the environment.
+func getToleranceDefault() float64 {
+	if value, err := strconv.ParseFloat(os.Getenv("OVERCOVER_TOLERANCE"), 64); err == nil {
+		return value
+	}
+
+	return 0.0
+}
+
+// init initializes the flags for the compare subcommand.
+func init() {
+	compareCmd.Flags().StringVar(&compareBase, "base", os.Getenv("OVERCOVER_COMPARE_BASE"), "Specify the coverage profile or JSON report of the base run.")
+	compareCmd.Flags().StringVar(&compareHead, "head", os.Getenv("OVERCOVER_COMPARE_HEAD"), "Specify the coverage profile or JSON report of the head run.")
+	_, failOnRegressionDefault := os.LookupEnv("OVERCOVER_FAIL_ON_REGRESSION")
+	compareCmd.Flags().StringVarP(&config, "config", "c", os.Getenv("OVERCOVER_CONFIG"), "Configuration file to read the exclusions from.")
+	compareCmd.Flags().StringArrayVar(&excludes, "exclude", getExcludeDefault(), "Exclude the files and packages matching a pattern from a coverage profile.  Patterns are as for rules, and are matched against both the package and the file.  May be given multiple times, and are added to those in the configuration file.")
+	_, workspaceDefault := os.LookupEnv("OVERCOVER_WORKSPACE")
+	compareCmd.Flags().BoolVarP(&workspace, "workspace", "w", workspaceDefault, "Used to request that the ignore directives in the source of each file in a coverage profile be read from the module of the go.work workspace that owns it.")
+	compareCmd.Flags().BoolVar(&failOnRegression, "fail-on-regression", failOnRegressionDefault, "Used to request that the command fail if the coverage of any file dropped by more than the tolerance.")
+	compareCmd.Flags().Float64Var(&tolerance, "tolerance", getToleranceDefault(), "Set the tolerance, in percentage points, by which the coverage of a file may drop without failing with --fail-on-regression.")
+
+	rootCmd.AddCommand(compareCmd)
+}
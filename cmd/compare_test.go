@@ -0,0 +1,350 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/klmitch/patcher"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/compare"
+)
+
+var testCompareBase = common.DataSet{
+	{Package: "pkg/a", Name: "a1.go", Count: 10, Exec: 5},
+	{Package: "pkg/a", Name: "old.go", Count: 4, Exec: 4},
+	{Package: "pkg/b", Name: "b.go", Count: 10, Exec: 8},
+	{Package: "pkg/b", Name: "same.go", Count: 2, Exec: 2},
+}
+
+var testCompareHead = common.DataSet{
+	{Package: "pkg/a", Name: "a1.go", Count: 10, Exec: 7},
+	{Package: "pkg/b", Name: "b.go", Count: 10, Exec: 6},
+	{Package: "pkg/b", Name: "same.go", Count: 2, Exec: 2},
+	{Package: "pkg/c", Name: "new.go", Count: 2, Exec: 1},
+}
+
+const testCompareReport = `{
+  "version": 1,
+  "files": [
+    {"package": "pkg/a", "file": "a1.go", "statements": 10, "executed": 5},
+    {"package": "pkg/a", "file": "old.go", "statements": 4, "executed": 4},
+    {"package": "pkg/b", "file": "b.go", "statements": 10, "executed": 8},
+    {"package": "pkg/b", "file": "same.go", "statements": 2, "executed": 2}
+  ]
+}
+`
+
+const testCompareOutput = `Per package changes:
+ Package  Base   Head   Change
+ -------  ----   ----   ------
+ pkg/a/   64.3%  70.0%  +5.7%
+ pkg/b/   83.3%  66.7%  -16.7%
+ pkg/c/   -      50.0%  added
+
+Per file changes:
+ File          Base    Head   Change
+ ----          ----    ----   ------
+ pkg/a/a1.go   50.0%   70.0%  +20.0%
+ pkg/a/old.go  100.0%  -      removed
+ pkg/b/b.go    80.0%   60.0%  -20.0%
+ pkg/c/new.go  -       50.0%  added
+
+Regressed files:
+  pkg/b/b.go: 80.0% -> 60.0% (-20.0%)
+
+Overall coverage: 73.1% -> 66.7% (-6.4%)
+`
+
+func patchCompare(t *testing.T) patcher.Patcher {
+	return patcher.NewPatchMaster(
+		patcher.SetVar(&findModule, func(dir string) (string, string, error) {
+			return "/src/mod", "example.com/mod", nil
+		}),
+		patcher.SetVar(&applyDirectives, func(ds common.DataSet, _, _ string) common.DataSet {
+			return ds
+		}),
+		patcher.SetVar(&unmarshalKey, func(key string, rawVal interface{}, _ ...viper.DecoderConfigOption) error {
+			assert.Equal(t, "exclude", key)
+			return nil
+		}),
+		patcher.SetVar(&getBool, func(name string) bool {
+			assert.Equal(t, "exclude_generated", name)
+			return false
+		}),
+		patcher.SetVar(&workspace, false),
+		patcher.SetVar(&readFile, func(fname string) ([]byte, error) {
+			if fname == "base.json" {
+				return []byte(testCompareReport), nil
+			}
+			return []byte("mode: set\n"), nil
+		}),
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
+			switch filenames[0] {
+			case "base.out":
+				return testCompareBase, nil, nil
+			case "head.out":
+				return testCompareHead, nil, nil
+			}
+			return nil, nil, assert.AnError
+		}),
+	)
+}
+
+func TestCompareCmdBase(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patchCompare(t),
+		patcher.SetVar(&compareBase, "base.out"),
+		patcher.SetVar(&compareHead, "head.out"),
+		patcher.SetVar(&failOnRegression, false),
+	).Install().Restore()
+
+	compareCmd.Run(compareCmd, []string{})
+
+	assert.Equal(t, testCompareOutput, outStream.String())
+	assert.Equal(t, "", errStream.String())
+}
+
+func TestCompareCmdReport(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patchCompare(t),
+		patcher.SetVar(&compareBase, "base.json"),
+		patcher.SetVar(&compareHead, "head.out"),
+		patcher.SetVar(&failOnRegression, false),
+	).Install().Restore()
+
+	compareCmd.Run(compareCmd, []string{})
+
+	assert.Equal(t, testCompareOutput, outStream.String())
+	assert.Equal(t, "", errStream.String())
+}
+
+func TestCompareCmdWithinTolerance(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&tolerance, 20.0),
+		patchCompare(t),
+		patcher.SetVar(&compareBase, "base.out"),
+		patcher.SetVar(&compareHead, "head.out"),
+		patcher.SetVar(&failOnRegression, true),
+	).Install().Restore()
+
+	compareCmd.Run(compareCmd, []string{})
+
+	assert.Equal(t, testCompareOutput, outStream.String())
+	assert.Equal(t, "", errStream.String())
+}
+
+func TestCompareCmdRegression(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&tolerance, 10.0),
+		patchCompare(t),
+		patcher.SetVar(&compareBase, "base.out"),
+		patcher.SetVar(&compareHead, "head.out"),
+		patcher.SetVar(&failOnRegression, true),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(1)", func() { compareCmd.Run(compareCmd, []string{}) })
+	assert.Equal(t, testCompareOutput, outStream.String())
+	assert.Equal(t, "\nCoverage dropped by more than the tolerance of 10.0% for files:\n  pkg/b/b.go: -20.0%\n", errStream.String())
+}
+
+func TestCompareCmdMissingFlags(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patchCompare(t),
+		patcher.SetVar(&compareBase, "base.out"),
+		patcher.SetVar(&compareHead, ""),
+	).Install().Restore()
+	compareCmd.SetOut(outStream)
+	defer compareCmd.SetOut(nil)
+
+	assert.PanicsWithValue(t, "os.Exit(2)", func() { compareCmd.Run(compareCmd, []string{}) })
+	assert.Contains(t, errStream.String(), "Both --base and --head must be specified.\n")
+}
+
+func TestLoadRunProfile(t *testing.T) {
+	defer patchCompare(t).Install().Restore()
+
+	result := loadRun("base.out", nil)
+
+	assert.Equal(t, testCompareBase, result)
+}
+
+func TestLoadRunDirectives(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patchCompare(t),
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&applyDirectives, func(ds common.DataSet, modRoot, modPath string) common.DataSet {
+			assert.Equal(t, "/src/mod", modRoot)
+			assert.Equal(t, "example.com/mod", modPath)
+			return ds[1:]
+		}),
+		patcher.SetVar(&excludes, []string{"pkg/b"}),
+	).Install().Restore()
+
+	result := loadRun("base.out", nil)
+
+	assert.Equal(t, common.DataSet{
+		{Package: "pkg/a", Name: "old.go", Count: 4, Exec: 4},
+	}, result)
+	assert.Equal(t, "Excluded 12 statements (10 executed) in 2 files matching exclude patterns\n", outStream.String())
+}
+
+func TestLoadRunReport(t *testing.T) {
+	defer patchCompare(t).Install().Restore()
+
+	result := loadRun("base.json", nil)
+
+	assert.Equal(t, common.DataSet{
+		{Package: "pkg/a", Name: "a1.go", Count: 10, Exec: 5},
+		{Package: "pkg/a", Name: "old.go", Count: 4, Exec: 4},
+		{Package: "pkg/b", Name: "b.go", Count: 10, Exec: 8},
+		{Package: "pkg/b", Name: "same.go", Count: 2, Exec: 2},
+	}, result)
+}
+
+func TestLoadRunBadReport(t *testing.T) {
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&readFile, func(fname string) ([]byte, error) {
+			return []byte(`{"version": 99}`), nil
+		}),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(3)", func() { loadRun("base.json", nil) })
+	assert.Equal(t, "Unable to load coverage data from base.json: unsupported report version 99\n", errStream.String())
+}
+
+func TestLoadRunReadFails(t *testing.T) {
+	errStream := &bytes.Buffer{}
+	loadCoverageCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&readFile, func(fname string) ([]byte, error) {
+			return nil, assert.AnError
+		}),
+		patcher.SetVar(&loadCoverage, func(_, _, _ []string) (common.DataSet, common.DataSet, error) {
+			loadCoverageCalled = true
+			return nil, nil, nil
+		}),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(3)", func() { loadRun("base.out", nil) })
+	assert.Equal(t, "Unable to load coverage data from base.out: "+assert.AnError.Error()+"\n", errStream.String())
+	assert.False(t, loadCoverageCalled)
+}
+
+func TestLoadRunLoadFails(t *testing.T) {
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patchCompare(t),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(3)", func() { loadRun("other.out", nil) })
+	assert.Equal(t, "Unable to load coverage data from other.out: "+assert.AnError.Error()+"\n", errStream.String())
+}
+
+func TestWriteComparisonUnchanged(t *testing.T) {
+	outStream := &bytes.Buffer{}
+
+	writeComparison(outStream, compare.Compare(testCompareHead, testCompareHead))
+
+	assert.Equal(t, `Per package changes:
+ Package  Base   Head   Change
+ -------  ----   ----   ------
+ pkg/a/   70.0%  70.0%  +0.0%
+ pkg/b/   66.7%  66.7%  +0.0%
+ pkg/c/   50.0%  50.0%  +0.0%
+
+Overall coverage: 66.7% -> 66.7% (+0.0%)
+`, outStream.String())
+}
+
+func TestGetToleranceDefaultUnset(t *testing.T) {
+	defer patcher.UnsetEnv("OVERCOVER_TOLERANCE").Install().Restore()
+
+	result := getToleranceDefault()
+
+	assert.Equal(t, 0.0, result)
+}
+
+func TestGetToleranceDefaultSet(t *testing.T) {
+	defer patcher.SetEnv("OVERCOVER_TOLERANCE", "2.5").Install().Restore()
+
+	result := getToleranceDefault()
+
+	assert.Equal(t, 2.5, result)
+}
+
+func TestGetToleranceDefaultInvalid(t *testing.T) {
+	defer patcher.SetEnv("OVERCOVER_TOLERANCE", "lots").Install().Restore()
+
+	result := getToleranceDefault()
+
+	assert.Equal(t, 0.0, result)
+}
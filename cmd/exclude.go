@@ -0,0 +1,99 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/coverage"
+	"github.com/klmitch/overcover/modules"
+	"github.com/klmitch/overcover/report"
+	"github.com/klmitch/overcover/rules"
+)
+
+// Patch points for top-level functions called by functions in this
+// file.
+var markGenerated = coverage.MarkGenerated
+
+// splitGenerated partitions a data set into the files that do not
+// contain generated code and those that do.
+func splitGenerated(ds common.DataSet) (common.DataSet, common.DataSet) {
+	var kept, generated common.DataSet
+	for _, fd := range ds {
+		if fd.Generated {
+			generated = append(generated, fd)
+		} else {
+			kept = append(kept, fd)
+		}
+	}
+
+	return kept, generated
+}
+
+// excludeData removes the files matching the exclude patterns, given
+// by the --exclude option and the configuration file, from the data
+// set, along with any files containing generated code if the
+// exclude_generated option is set; in a workspace, each file is
+// checked against the source of the module that owns it.  So that the
+// denominator of the coverage cannot be silently reduced, the number
+// of statements excluded is reported, and is also returned for the
+// report; nil is returned if nothing is to be excluded.
+func excludeData(w io.Writer, ds common.DataSet, mods []modules.Module) (common.DataSet, *report.Coverage) {
+	// Read the exclude patterns from the configuration
+	var patterns []string
+	if err := unmarshalKey("exclude", &patterns); err != nil {
+		fmt.Fprintf(stderr, "Unable to read exclude patterns from configuration: %s\n", err)
+		exit(2)
+	}
+	patterns = append(append([]string{}, excludes...), patterns...)
+	excludeGenerated := getBool("exclude_generated")
+	if len(patterns) == 0 && !excludeGenerated {
+		return ds, nil
+	}
+
+	// Exclude the generated files
+	var excluded common.DataSet
+	if excludeGenerated {
+		var marked, generated common.DataSet
+		unowned := forModules(ds, mods, func(owned common.DataSet, modRoot, modPath string) {
+			marked = append(marked, markGenerated(owned, modRoot, modPath)...)
+		})
+		ds, generated = splitGenerated(append(unowned, marked...))
+		sum := generated.Sum()
+		fmt.Fprintf(w, "Excluded %d statements (%d executed) in %d generated files\n", sum.Count, sum.Exec, len(generated))
+		excluded = append(excluded, generated...)
+	}
+
+	// Exclude the files matching the patterns; relative patterns
+	// are relative to the module containing the current directory
+	if len(patterns) > 0 {
+		_, modPath, _ := findModule(".")
+		var matched common.DataSet
+		var err error
+		ds, matched, err = rules.Exclude(patterns, modPath, ds)
+		if err != nil {
+			fmt.Fprintf(stderr, "Invalid exclude pattern: %s\n", err)
+			exit(2)
+		}
+		sum := matched.Sum()
+		fmt.Fprintf(w, "Excluded %d statements (%d executed) in %d files matching exclude patterns\n", sum.Count, sum.Exec, len(matched))
+		excluded = append(excluded, matched...)
+	}
+
+	result := report.NewCoverage(excluded.Sum())
+	return ds, &result
+}
@@ -0,0 +1,203 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/klmitch/patcher"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/report"
+)
+
+var testExcludeData = common.DataSet{
+	{Package: "example.com/mod/api", Name: "a.go", Count: 10, Exec: 8},
+	{Package: "example.com/mod/api", Name: "a_mock.go", Count: 4, Exec: 1},
+	{Package: "example.com/mod/mocks", Name: "a.go", Count: 6, Exec: 0},
+}
+
+func patchExclude(t *testing.T, flags, patterns []string, err error, generated bool) patcher.Patcher {
+	return patcher.NewPatchMaster(
+		patcher.SetVar(&excludes, flags),
+		patcher.SetVar(&getBool, func(key string) bool {
+			assert.Equal(t, "exclude_generated", key)
+			return generated
+		}),
+		patcher.SetVar(&markGenerated, func(ds common.DataSet, modRoot, modPath string) common.DataSet {
+			assert.Equal(t, "/src/mod", modRoot)
+			assert.Equal(t, "example.com/mod", modPath)
+			result := append(common.DataSet{}, ds...)
+			result[0].Generated = true
+			return result
+		}),
+		patcher.SetVar(&unmarshalKey, func(key string, rawVal interface{}, _ ...viper.DecoderConfigOption) error {
+			assert.Equal(t, "exclude", key)
+			*(rawVal.(*[]string)) = patterns
+			return err
+		}),
+		patcher.SetVar(&findModule, func(dir string) (string, string, error) {
+			assert.Equal(t, ".", dir)
+			return "/src/mod", "example.com/mod", nil
+		}),
+	)
+}
+
+func TestSplitGenerated(t *testing.T) {
+	ds := common.DataSet{
+		{Package: "pkg", Name: "a.go", Count: 2},
+		{Package: "pkg", Name: "b.go", Count: 2, Generated: true},
+	}
+
+	kept, generated := splitGenerated(ds)
+
+	assert.Equal(t, common.DataSet{
+		{Package: "pkg", Name: "a.go", Count: 2},
+	}, kept)
+	assert.Equal(t, common.DataSet{
+		{Package: "pkg", Name: "b.go", Count: 2, Generated: true},
+	}, generated)
+}
+
+func TestExcludeDataNoPatterns(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	defer patchExclude(t, []string{}, nil, nil, false).Install().Restore()
+
+	ds, excluded := excludeData(outStream, testExcludeData, nil)
+
+	assert.Equal(t, testExcludeData, ds)
+	assert.Nil(t, excluded)
+	assert.Equal(t, "", outStream.String())
+}
+
+func TestExcludeDataBase(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	defer patchExclude(t, []string{"**/*_mock.go"}, []string{"./mocks/..."}, nil, false).Install().Restore()
+
+	ds, excluded := excludeData(outStream, testExcludeData, nil)
+
+	assert.Equal(t, common.DataSet{
+		{Package: "example.com/mod/api", Name: "a.go", Count: 10, Exec: 8},
+	}, ds)
+	assert.Equal(t, &report.Coverage{Statements: 10, Executed: 1, Coverage: 10.0}, excluded)
+	assert.Equal(t, "Excluded 10 statements (1 executed) in 2 files matching exclude patterns\n", outStream.String())
+}
+
+func TestExcludeDataNoneMatched(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	defer patchExclude(t, []string{"example.com/other/..."}, nil, nil, false).Install().Restore()
+
+	ds, excluded := excludeData(outStream, testExcludeData, nil)
+
+	assert.Equal(t, testExcludeData, ds)
+	assert.Equal(t, &report.Coverage{Coverage: 100.0}, excluded)
+	assert.Equal(t, "Excluded 0 statements (0 executed) in 0 files matching exclude patterns\n", outStream.String())
+}
+
+func TestExcludeDataConfigError(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patchExclude(t, []string{}, nil, assert.AnError, false),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(2)", func() {
+		excludeData(outStream, testExcludeData, nil)
+	})
+
+	assert.Equal(t, "", outStream.String())
+	assert.Equal(t, fmt.Sprintf("Unable to read exclude patterns from configuration: %s\n", assert.AnError), errStream.String())
+}
+
+func TestExcludeDataBadPattern(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patchExclude(t, []string{""}, nil, nil, false),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(2)", func() {
+		excludeData(outStream, testExcludeData, nil)
+	})
+
+	assert.Equal(t, "", outStream.String())
+	assert.Equal(t, "Invalid exclude pattern: exclude pattern \"\": empty pattern\n", errStream.String())
+}
+
+func TestExcludeDataGenerated(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	defer patchExclude(t, []string{}, nil, nil, true).Install().Restore()
+
+	ds, excluded := excludeData(outStream, testExcludeData, nil)
+
+	assert.Equal(t, common.DataSet{
+		{Package: "example.com/mod/api", Name: "a_mock.go", Count: 4, Exec: 1},
+		{Package: "example.com/mod/mocks", Name: "a.go", Count: 6, Exec: 0},
+	}, ds)
+	assert.Equal(t, &report.Coverage{Statements: 10, Executed: 8, Coverage: 80.0}, excluded)
+	assert.Equal(t, "Excluded 10 statements (8 executed) in 1 generated files\n", outStream.String())
+}
+
+func TestExcludeDataGeneratedAndPatterns(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	defer patchExclude(t, []string{"./mocks/..."}, nil, nil, true).Install().Restore()
+
+	ds, excluded := excludeData(outStream, testExcludeData, nil)
+
+	assert.Equal(t, common.DataSet{
+		{Package: "example.com/mod/api", Name: "a_mock.go", Count: 4, Exec: 1},
+	}, ds)
+	assert.Equal(t, &report.Coverage{Statements: 16, Executed: 8, Coverage: 50.0}, excluded)
+	assert.Equal(t, "Excluded 10 statements (8 executed) in 1 generated files\nExcluded 6 statements (0 executed) in 1 files matching exclude patterns\n", outStream.String())
+}
+
+func TestExcludeDataGeneratedWorkspace(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	roots := map[string]string{}
+	defer patcher.NewPatchMaster(
+		patchExclude(t, []string{}, nil, nil, true),
+		patcher.SetVar(&markGenerated, func(ds common.DataSet, modRoot, modPath string) common.DataSet {
+			roots[modPath] = modRoot
+			result := append(common.DataSet{}, ds...)
+			result[0].Generated = true
+			return result
+		}),
+	).Install().Restore()
+
+	ds, excluded := excludeData(outStream, testModulesData, testModules)
+
+	assert.Equal(t, common.DataSet{
+		{Package: "example.com/unknown", Name: "a.go", Count: 10, Exec: 0},
+	}, ds)
+	assert.Equal(t, &report.Coverage{Statements: 30, Executed: 21, Coverage: 70.0}, excluded)
+	assert.Equal(t, map[string]string{
+		"example.com/mod":       "/src/mod",
+		"example.com/mod/tools": "/src/mod/tools",
+		"example.com/other":     "/src/other",
+	}, roots)
+	assert.Equal(t, "Excluded 30 statements (21 executed) in 3 generated files\n", outStream.String())
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/modules"
+	"github.com/klmitch/overcover/report"
+)
+
+// sourceFunctions locates the functions in the files of the data set
+// that were not read from the source of the packages specified on the
+// command line, by loading the source of their packages.  A failure
+// to load the source is reported, and leaves the functions of the
+// affected files unknown.
+func sourceFunctions(ds common.DataSet, mods []modules.Module) common.DataSet {
+	// Determine the packages to load
+	var pkgs []string
+	seen := map[string]bool{}
+	for _, fd := range ds {
+		if fd.Funcs == nil && len(fd.Blocks) > 0 && !seen[fd.Package] {
+			seen[fd.Package] = true
+			pkgs = append(pkgs, fd.Package)
+		}
+	}
+	if len(pkgs) == 0 {
+		return ds
+	}
+
+	// Load their source
+	direct, err := loadSource(mods, pkgs)
+	if err != nil {
+		fmt.Fprintf(stderr, "WARNING: unable to read source: %s\n", err)
+		return ds
+	}
+	funcs := map[string][]common.Extent{}
+	for _, fd := range direct {
+		funcs[fd.Handle()] = fd.Funcs
+	}
+
+	// Add the functions to the files
+	result := make(common.DataSet, 0, len(ds))
+	for _, fd := range ds {
+		if fd.Funcs == nil {
+			fd.Funcs = funcs[fd.Handle()]
+		}
+		result = append(result, fd)
+	}
+
+	return result
+}
+
+// loadFunctions computes the coverage of each function in the data
+// set.  The coverage blocks of each file are mapped onto the functions
+// in its source; files whose source cannot be read are reported.
+func loadFunctions(ds common.DataSet, mods []modules.Module) common.FuncSet {
+	// Map the blocks onto the functions
+	fs, missing := findFunctions(sourceFunctions(ds, mods))
+	if len(missing) > 0 {
+		fmt.Fprintf(stderr, "WARNING: unable to read source; functions not reported for files:\n")
+		for _, fd := range missing {
+			fmt.Fprintf(stderr, "  %s\n", fd.Handle())
+		}
+	}
+
+	return fs
+}
+
+// writeFunctions writes a table of the coverage of each function, if
+// requested with --functions, to the specified stream, in order of
+// increasing coverage.  The function coverage is obtained by calling
+// funcs, which is only done if the table was requested.  The coverage
+// of the functions is returned for the report; nil is returned if it
+// was not requested.
+func writeFunctions(w io.Writer, funcs func() common.FuncSet) []report.Function {
+	if !functions {
+		return nil
+	}
+
+	// Emit the table
+	result := report.NewFunctions(funcs())
+	fmt.Fprintln(w, "Per function details:")
+	tab := tabwriter.NewWriter(w, 2, 8, 2, ' ', 0)
+	fmt.Fprintf(tab, " Function\tFile\tExecuted\tTotal\tCoverage\n --------\t----\t--------\t-----\t--------\n")
+	for _, fn := range result {
+		fmt.Fprintf(tab, " %s\t%s/%s:%d\t%d\t%d\t%.1f%%\n", fn.Function, fn.Package, fn.File, fn.Line, fn.Executed, fn.Statements, fn.Coverage.Coverage)
+	}
+	tab.Flush()
+	fmt.Fprintln(w, "")
+
+	return result
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/klmitch/patcher"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/report"
+)
+
+var testFunctionsData = common.DataSet{
+	{
+		Package: "example.com/mod/api",
+		Name:    "a.go",
+		Count:   4,
+		Exec:    1,
+		Blocks:  []common.Block{{StartLine: 4, StartCol: 2, EndLine: 6, EndCol: 1, NumStmt: 4, Count: 1}},
+		Funcs:   []common.Extent{{Name: "New", Line: 3, StartLine: 3, StartCol: 20, EndLine: 6, EndCol: 1}},
+	},
+	{
+		Package: "example.com/mod/api",
+		Name:    "b.go",
+		Count:   2,
+		Blocks:  []common.Block{{StartLine: 4, StartCol: 2, EndLine: 5, EndCol: 1, NumStmt: 2}},
+	},
+	{
+		Package: "example.com/mod/tools",
+		Name:    "c.go",
+		Count:   1,
+		Blocks:  []common.Block{{StartLine: 4, StartCol: 2, EndLine: 5, EndCol: 1, NumStmt: 1}},
+	},
+	{
+		Package: "example.com/mod/api",
+		Name:    "doc.go",
+	},
+}
+
+func TestSourceFunctionsNone(t *testing.T) {
+	loadStatementsCalled := false
+	defer patcher.SetVar(&loadStatements, func(_ string, _, _ []string) (common.DataSet, error) {
+		loadStatementsCalled = true
+		return nil, nil
+	}).Install().Restore()
+
+	result := sourceFunctions(testFunctionsData[:1], nil)
+
+	assert.Equal(t, testFunctionsData[:1], result)
+	assert.False(t, loadStatementsCalled)
+}
+
+func TestSourceFunctionsLoad(t *testing.T) {
+	funcs := []common.Extent{{Name: "Get", Line: 3, StartLine: 3, StartCol: 20, EndLine: 5, EndCol: 1}}
+	defer patcher.SetVar(&loadStatements, func(dir string, _, args []string) (common.DataSet, error) {
+		assert.Equal(t, "", dir)
+		assert.Equal(t, []string{"example.com/mod/api", "example.com/mod/tools"}, args)
+		return common.DataSet{
+			{Package: "example.com/mod/api", Name: "a.go", Funcs: []common.Extent{}},
+			{Package: "example.com/mod/api", Name: "b.go", Funcs: funcs},
+			{Package: "example.com/mod/api", Name: "doc.go", Funcs: []common.Extent{}},
+		}, nil
+	}).Install().Restore()
+
+	result := sourceFunctions(testFunctionsData, nil)
+
+	assert.Equal(t, testFunctionsData[0], result[0])
+	assert.Equal(t, funcs, result[1].Funcs)
+	assert.Nil(t, result[2].Funcs)
+	assert.Equal(t, []common.Extent{}, result[3].Funcs)
+	assert.Nil(t, testFunctionsData[1].Funcs)
+}
+
+func TestSourceFunctionsLoadFails(t *testing.T) {
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&loadStatements, func(_ string, _, _ []string) (common.DataSet, error) {
+			return nil, assert.AnError
+		}),
+	).Install().Restore()
+
+	result := sourceFunctions(testFunctionsData, nil)
+
+	assert.Equal(t, testFunctionsData, result)
+	assert.Equal(t, "WARNING: unable to read source: "+assert.AnError.Error()+"\n", errStream.String())
+}
+
+func TestLoadFunctions(t *testing.T) {
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&loadStatements, func(_ string, _, _ []string) (common.DataSet, error) {
+			return nil, nil
+		}),
+		patcher.SetVar(&findFunctions, func(ds common.DataSet) (common.FuncSet, common.DataSet) {
+			assert.Equal(t, testFunctionsData, ds)
+			return common.FuncSet{
+				{Package: "example.com/mod/api", File: "a.go", Name: "New", Line: 3, Count: 4, Exec: 1},
+			}, ds[1:3]
+		}),
+	).Install().Restore()
+
+	result := loadFunctions(testFunctionsData, nil)
+
+	assert.Equal(t, common.FuncSet{
+		{Package: "example.com/mod/api", File: "a.go", Name: "New", Line: 3, Count: 4, Exec: 1},
+	}, result)
+	assert.Equal(t, "WARNING: unable to read source; functions not reported for files:\n  example.com/mod/api/b.go\n  example.com/mod/tools/c.go\n", errStream.String())
+}
+
+func TestWriteFunctionsDisabled(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	funcsCalled := false
+
+	result := writeFunctions(outStream, func() common.FuncSet {
+		funcsCalled = true
+		return nil
+	})
+
+	assert.Nil(t, result)
+	assert.Equal(t, "", outStream.String())
+	assert.False(t, funcsCalled)
+}
+
+func TestWriteFunctionsEnabled(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	defer patcher.SetVar(&functions, true).Install().Restore()
+
+	result := writeFunctions(outStream, func() common.FuncSet {
+		return common.FuncSet{
+			{Package: "example.com/mod/api", File: "a.go", Name: "(*T).Get", Line: 12, Count: 10, Exec: 8},
+			{Package: "example.com/mod/api", File: "a.go", Name: "New", Line: 3, Count: 4, Exec: 1},
+		}
+	})
+
+	assert.Equal(t, []report.Function{
+		{Function: "New", Line: 3, Coverage: report.Coverage{Package: "example.com/mod/api", File: "a.go", Statements: 4, Executed: 1, Coverage: 25.0}},
+		{Function: "(*T).Get", Line: 12, Coverage: report.Coverage{Package: "example.com/mod/api", File: "a.go", Statements: 10, Executed: 8, Coverage: 80.0}},
+	}, result)
+	assert.Equal(t, `Per function details:
+ Function  File                         Executed  Total  Coverage
+ --------  ----                         --------  -----  --------
+ New       example.com/mod/api/a.go:3   1         4      25.0%
+ (*T).Get  example.com/mod/api/a.go:12  8         10     80.0%
+
+`, outStream.String())
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/diff"
+	"github.com/klmitch/overcover/history"
+)
+
+// Patch points for top-level functions called by functions in this
+// file.
+var (
+	now     = time.Now
+	gitHead = diff.Head
+)
+
+// Variables used to store the values of flags.
+var (
+	historyFile   string
+	historyLimit  int
+	historyMovers int
+	historyWidth  int
+)
+
+// historyCmd describes the history subcommand to cobra.
+var historyCmd = &cobra.Command{
+	Use:   "history --history FILE",
+	Short: "Report the trends in coverage over past runs",
+	Long:  `Report the trends in coverage over the past runs recorded in a history file.  Each run of overcover given the --history option appends the time, the git commit, and the overall and per-package coverage of the run to the history file.  The change in the overall coverage and in the coverage of each package from the earliest run to the latest is reported, with a sparkline showing the trend, along with the packages whose coverage improved or dropped the most.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if historyFile == "" {
+			fmt.Fprintf(stderr, "A history file must be specified with --history.\n")
+			_ = cmd.Usage()
+			exit(2)
+		}
+
+		// Read the history, keeping only the latest runs if requested
+		entries := readHistory()
+		if historyLimit > 0 && len(entries) > historyLimit {
+			entries = entries[len(entries)-historyLimit:]
+		}
+		if len(entries) == 0 {
+			fmt.Fprintf(stdout, "No runs recorded in %s\n", historyFile)
+			return
+		}
+
+		writeHistory(stdout, entries)
+	},
+}
+
+// recordHistory appends the coverage of the run to the history file
+// selected by the --history option, if any.  The commit is recorded
+// if the current directory is in a git repository.
+func recordHistory(ds common.DataSet) {
+	if historyFile == "" {
+		return
+	}
+
+	commit, _ := gitHead()
+	buf := &bytes.Buffer{}
+	_ = history.NewEntry(now(), commit, ds).WriteJSON(buf) // writes to a buffer cannot fail
+	if err := appendFile(historyFile, buf.Bytes()); err != nil {
+		fmt.Fprintf(stderr, "Unable to write history to %s: %s\n", historyFile, err)
+		exit(3)
+	}
+}
+
+// readHistory reads the entries of the history file selected by the
+// --history option.
+func readHistory() []history.Entry {
+	data, err := readFile(historyFile)
+	var entries []history.Entry
+	if err == nil {
+		entries, err = history.Read(bytes.NewReader(data))
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Unable to read history from %s: %s\n", historyFile, err)
+		exit(3)
+	}
+
+	return entries
+}
+
+// writeMovers writes a list of the packages whose coverage changed
+// the most to the specified stream.
+func writeMovers(w io.Writer, title string, trends []history.Trend) {
+	if len(trends) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, title)
+	for _, trend := range trends {
+		fmt.Fprintf(w, "  %s: %+.1f%%\n", trend.Package, trend.Change())
+	}
+	fmt.Fprintln(w, "")
+}
+
+// writeHistory writes the trends in the coverage over the entries to
+// the specified stream.
+func writeHistory(w io.Writer, entries []history.Entry) {
+	overall, pkgs := history.Trends(entries)
+	fmt.Fprintf(w, "Coverage history of %d runs from %s to %s\n\n", len(entries), entries[0].Time.Format(time.DateOnly), entries[len(entries)-1].Time.Format(time.DateOnly))
+
+	// Emit the table of packages
+	fmt.Fprintln(w, "Per package trends:")
+	tab := tabwriter.NewWriter(w, 2, 8, 2, ' ', 0)
+	fmt.Fprintf(tab, " Package\tFirst\tLast\tChange\tTrend\n -------\t-----\t----\t------\t-----\n")
+	for _, trend := range pkgs {
+		fmt.Fprintf(tab, " %s\t%.1f%%\t%.1f%%\t%+.1f%%\t%s\n", trend.Package, trend.First(), trend.Last(), trend.Change(), history.Sparkline(trend.Values, historyWidth))
+	}
+	tab.Flush()
+	fmt.Fprintln(w, "")
+
+	// Emit the packages that moved the most
+	best, worst := history.Movers(pkgs, historyMovers)
+	writeMovers(w, "Most improved packages:", best)
+	writeMovers(w, "Most declined packages:", worst)
+
+	fmt.Fprintf(w, "Overall coverage: %.1f%% -> %.1f%% (%+.1f%%) %s\n", overall.First(), overall.Last(), overall.Change(), history.Sparkline(overall.Values, historyWidth))
+}
+
+// init initializes the flags for the history subcommand.
+func init() {
+	historyCmd.Flags().StringVar(&historyFile, "history", os.Getenv("OVERCOVER_HISTORY"), "Specify the history file to read.")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 0, "Set the number of the latest runs to report on.  If zero, all are included.")
+	historyCmd.Flags().IntVar(&historyMovers, "movers", 5, "Set the number of packages to list as the most improved and the most declined.")
+	historyCmd.Flags().IntVar(&historyWidth, "width", 40, "Set the maximum width of the sparklines.  Longer histories are averaged to fit.  If zero, the sparklines are not limited.")
+
+	rootCmd.AddCommand(historyCmd)
+}
@@ -0,0 +1,274 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/klmitch/patcher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/history"
+	"github.com/klmitch/overcover/report"
+)
+
+var testHistory = `{"time":"2020-01-02T03:04:05Z","commit":"0123","overall":{"statements":20,"executed":9,"coverage":45},"packages":[{"package":"pkg/a","statements":10,"executed":4,"coverage":40},{"package":"pkg/b","statements":10,"executed":5,"coverage":50}]}
+{"time":"2020-02-02T03:04:05Z","commit":"4567","overall":{"statements":20,"executed":10,"coverage":50},"packages":[{"package":"pkg/a","statements":10,"executed":6,"coverage":60},{"package":"pkg/b","statements":10,"executed":4,"coverage":40}]}
+{"time":"2020-03-02T03:04:05Z","commit":"89ab","overall":{"statements":20,"executed":11,"coverage":55},"packages":[{"package":"pkg/a","statements":10,"executed":7,"coverage":70},{"package":"pkg/b","statements":10,"executed":4,"coverage":40}]}
+`
+
+func TestHistoryCmdBase(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&readFile, func(fname string) ([]byte, error) {
+			assert.Equal(t, "history.jsonl", fname)
+			return []byte(testHistory), nil
+		}),
+		patcher.SetVar(&historyFile, "history.jsonl"),
+		patcher.SetVar(&historyLimit, 0),
+		patcher.SetVar(&historyMovers, 5),
+		patcher.SetVar(&historyWidth, 40),
+	).Install().Restore()
+
+	historyCmd.Run(historyCmd, []string{})
+
+	assert.Equal(t, `Coverage history of 3 runs from 2020-01-02 to 2020-03-02
+
+Per package trends:
+ Package  First  Last   Change  Trend
+ -------  -----  ----   ------  -----
+ pkg/a    40.0%  70.0%  +30.0%  .*@
+ pkg/b    50.0%  40.0%  -10.0%  @..
+
+Most improved packages:
+  pkg/a: +30.0%
+
+Most declined packages:
+  pkg/b: -10.0%
+
+Overall coverage: 45.0% -> 55.0% (+10.0%) .+@
+`, outStream.String())
+	assert.Equal(t, "", errStream.String())
+}
+
+func TestHistoryCmdLimit(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&readFile, func(fname string) ([]byte, error) {
+			return []byte(testHistory), nil
+		}),
+		patcher.SetVar(&historyFile, "history.jsonl"),
+		patcher.SetVar(&historyLimit, 2),
+		patcher.SetVar(&historyMovers, 5),
+		patcher.SetVar(&historyWidth, 40),
+	).Install().Restore()
+
+	historyCmd.Run(historyCmd, []string{})
+
+	assert.Equal(t, `Coverage history of 2 runs from 2020-02-02 to 2020-03-02
+
+Per package trends:
+ Package  First  Last   Change  Trend
+ -------  -----  ----   ------  -----
+ pkg/a    60.0%  70.0%  +10.0%  .@
+ pkg/b    40.0%  40.0%  +0.0%   ..
+
+Most improved packages:
+  pkg/a: +10.0%
+
+Overall coverage: 50.0% -> 55.0% (+5.0%) .@
+`, outStream.String())
+	assert.Equal(t, "", errStream.String())
+}
+
+func TestHistoryCmdEmpty(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&readFile, func(fname string) ([]byte, error) {
+			return []byte{}, nil
+		}),
+		patcher.SetVar(&historyFile, "history.jsonl"),
+		patcher.SetVar(&historyLimit, 0),
+	).Install().Restore()
+
+	historyCmd.Run(historyCmd, []string{})
+
+	assert.Equal(t, "No runs recorded in history.jsonl\n", outStream.String())
+	assert.Equal(t, "", errStream.String())
+}
+
+func TestHistoryCmdNoFile(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&historyFile, ""),
+	).Install().Restore()
+	historyCmd.SetOut(outStream)
+	defer historyCmd.SetOut(nil)
+
+	assert.PanicsWithValue(t, "os.Exit(2)", func() { historyCmd.Run(historyCmd, []string{}) })
+	assert.Contains(t, errStream.String(), "A history file must be specified with --history.\n")
+}
+
+func TestRecordHistoryDisabled(t *testing.T) {
+	gitHeadCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&gitHead, func() (string, error) {
+			gitHeadCalled = true
+			return "", nil
+		}),
+		patcher.SetVar(&historyFile, ""),
+	).Install().Restore()
+
+	recordHistory(testModulesData)
+
+	assert.False(t, gitHeadCalled)
+}
+
+func TestRecordHistoryBase(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "history.jsonl")
+	require.NoError(t, os.WriteFile(fname, []byte(testHistory), 0o666))
+	ds := common.DataSet{
+		{Package: "pkg/a", Name: "a.go", Count: 10, Exec: 8},
+		{Package: "pkg/b", Name: "b.go", Count: 10, Exec: 5},
+	}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&now, func() time.Time {
+			return time.Date(2020, 4, 2, 3, 4, 5, 0, time.UTC)
+		}),
+		patcher.SetVar(&gitHead, func() (string, error) {
+			return "cdef", nil
+		}),
+		patcher.SetVar(&historyFile, fname),
+	).Install().Restore()
+
+	recordHistory(ds)
+
+	data, err := os.ReadFile(fname)
+	require.NoError(t, err)
+	entries, err := history.Read(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Len(t, entries, 4)
+	assert.Equal(t, history.Entry{
+		Time:    time.Date(2020, 4, 2, 3, 4, 5, 0, time.UTC),
+		Commit:  "cdef",
+		Overall: report.Coverage{Statements: 20, Executed: 13, Coverage: 65.0},
+		Packages: []report.Coverage{
+			{Package: "pkg/a", Statements: 10, Executed: 8, Coverage: 80.0},
+			{Package: "pkg/b", Statements: 10, Executed: 5, Coverage: 50.0},
+		},
+	}, entries[3])
+}
+
+func TestRecordHistoryNoCommit(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "history.jsonl")
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&now, func() time.Time {
+			return time.Date(2020, 4, 2, 3, 4, 5, 0, time.UTC)
+		}),
+		patcher.SetVar(&gitHead, func() (string, error) {
+			return "", assert.AnError
+		}),
+		patcher.SetVar(&historyFile, fname),
+	).Install().Restore()
+
+	recordHistory(common.DataSet{})
+
+	data, err := os.ReadFile(fname)
+	require.NoError(t, err)
+	assert.Equal(t, `{"time":"2020-04-02T03:04:05Z","overall":{"statements":0,"executed":0,"coverage":100},"packages":[]}`+"\n", string(data))
+}
+
+func TestRecordHistoryFails(t *testing.T) {
+	errStream := &bytes.Buffer{}
+	fname := filepath.Join(t.TempDir(), "missing", "history.jsonl")
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&gitHead, func() (string, error) {
+			return "cdef", nil
+		}),
+		patcher.SetVar(&historyFile, fname),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(3)", func() { recordHistory(common.DataSet{}) })
+	assert.Contains(t, errStream.String(), "Unable to write history to "+fname+": ")
+}
+
+func TestReadHistoryReadFails(t *testing.T) {
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&readFile, func(fname string) ([]byte, error) {
+			return nil, assert.AnError
+		}),
+		patcher.SetVar(&historyFile, "history.jsonl"),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(3)", func() { readHistory() })
+	assert.Equal(t, "Unable to read history from history.jsonl: "+assert.AnError.Error()+"\n", errStream.String())
+}
+
+func TestReadHistoryBadEntry(t *testing.T) {
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&readFile, func(fname string) ([]byte, error) {
+			return []byte("{bad\n"), nil
+		}),
+		patcher.SetVar(&historyFile, "history.jsonl"),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(3)", func() { readHistory() })
+	assert.Contains(t, errStream.String(), "Unable to read history from history.jsonl: entry 1: ")
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/diff"
+	"github.com/klmitch/overcover/modules"
+	"github.com/klmitch/overcover/report"
+)
+
+// checkPatch computes the coverage of the lines changed relative to
+// the base revision specified with --diff-base, if any, and reports
+// it to the specified stream.  In a workspace, each file is mapped to
+// the changes using the module that owns it.  It returns nil if no
+// base revision was specified.
+func checkPatch(ds common.DataSet, mods []modules.Module, w io.Writer) *report.Patch {
+	if diffBase == "" {
+		return nil
+	}
+
+	// Determine the changed lines
+	changes, err := loadChanges(diffBase)
+	if err != nil {
+		fmt.Fprintf(stderr, "Unable to determine changes relative to %s: %s\n", diffBase, err)
+		exit(3)
+	}
+
+	// Compute the coverage of the changed blocks
+	var changed common.DataSet
+	unowned := forModules(ds, mods, func(owned common.DataSet, modRoot, modPath string) {
+		changed = append(changed, diff.Coverage(changes, owned, modRoot, modPath)...)
+	})
+	patch := append(changed, diff.Coverage(changes, unowned, "", "")...).Sum()
+	coverage := patch.Coverage() * 100.0
+	fmt.Fprintf(w, "%d statements in changed blocks out of %d covered; patch coverage: %.1f%%\n", patch.Exec, patch.Count, coverage)
+
+	// Verify that we met the patch threshold
+	result := &report.Patch{
+		Base:      diffBase,
+		Coverage:  report.NewCoverage(patch),
+		Threshold: report.NewThreshold(coverage, getFloat64("patch_threshold")),
+	}
+	if !result.Threshold.Passed {
+		fmt.Fprintf(stderr, "\nFailed to meet patch coverage threshold of %.1f%%\n", result.Threshold.Threshold)
+	}
+
+	return result
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/klmitch/patcher"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/diff"
+	"github.com/klmitch/overcover/modules"
+	"github.com/klmitch/overcover/report"
+)
+
+var testPatchData = common.DataSet{
+	{
+		Package: "example.com/mod/pkg",
+		Name:    "file.go",
+		Count:   9,
+		Exec:    6,
+		Blocks: []common.Block{
+			{StartLine: 1, EndLine: 3, NumStmt: 2, Count: 1},
+			{StartLine: 4, EndLine: 6, NumStmt: 3, Count: 0},
+			{StartLine: 7, EndLine: 9, NumStmt: 4, Count: 1},
+		},
+	},
+}
+
+func patchPatch(t *testing.T, threshold float64, changesErr error) patcher.Patcher {
+	return patcher.NewPatchMaster(
+		patcher.SetVar(&diffBase, "main"),
+		patcher.SetVar(&loadChanges, func(base string) (diff.Changes, error) {
+			assert.Equal(t, "main", base)
+			if changesErr != nil {
+				return nil, changesErr
+			}
+			return diff.Changes{
+				filepath.FromSlash("/src/mod/pkg/file.go"): {{Start: 2, End: 5}},
+			}, nil
+		}),
+		patcher.SetVar(&findModule, func(dir string) (string, string, error) {
+			assert.Equal(t, ".", dir)
+			return filepath.FromSlash("/src/mod"), "example.com/mod", nil
+		}),
+		patcher.SetVar(&getFloat64, func(name string) float64 {
+			assert.Equal(t, "patch_threshold", name)
+			return threshold
+		}),
+	)
+}
+
+func TestCheckPatchNoBase(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	loadChangesCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&diffBase, ""),
+		patcher.SetVar(&loadChanges, func(_ string) (diff.Changes, error) {
+			loadChangesCalled = true
+			return nil, nil
+		}),
+	).Install().Restore()
+
+	result := checkPatch(testPatchData, nil, outStream)
+
+	assert.Nil(t, result)
+	assert.Equal(t, "", outStream.String())
+	assert.Equal(t, "", errStream.String())
+	assert.False(t, loadChangesCalled)
+}
+
+func TestCheckPatchPass(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patchPatch(t, 40.0, nil),
+	).Install().Restore()
+
+	result := checkPatch(testPatchData, nil, outStream)
+
+	assert.Equal(t, &report.Patch{
+		Base: "main",
+		Coverage: report.Coverage{
+			Statements: 5,
+			Executed:   2,
+			Coverage:   40.0,
+		},
+		Threshold: report.Threshold{
+			Threshold: 40.0,
+			Passed:    true,
+		},
+	}, result)
+	assert.Equal(t, "2 statements in changed blocks out of 5 covered; patch coverage: 40.0%\n", outStream.String())
+	assert.Equal(t, "", errStream.String())
+}
+
+func TestCheckPatchFail(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patchPatch(t, 80.0, nil),
+	).Install().Restore()
+
+	result := checkPatch(testPatchData, nil, outStream)
+
+	assert.Equal(t, report.Threshold{
+		Threshold: 80.0,
+		Passed:    false,
+	}, result.Threshold)
+	assert.Equal(t, "2 statements in changed blocks out of 5 covered; patch coverage: 40.0%\n", outStream.String())
+	assert.Equal(t, "\nFailed to meet patch coverage threshold of 80.0%\n", errStream.String())
+}
+
+func TestCheckPatchWorkspace(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patchPatch(t, 40.0, nil),
+		patcher.SetVar(&loadChanges, func(_ string) (diff.Changes, error) {
+			return diff.Changes{
+				filepath.FromSlash("/src/other/pkg/file.go"): {{Start: 2, End: 5}},
+				filepath.FromSlash("/abs/file.go"):           {{Start: 1, End: 1}},
+			}, nil
+		}),
+	).Install().Restore()
+	ds := common.DataSet{
+		{Package: "example.com/mod/pkg", Name: "file.go", Blocks: testPatchData[0].Blocks},
+		{Package: "example.com/other/pkg", Name: "file.go", Blocks: testPatchData[0].Blocks},
+		{Package: filepath.FromSlash("/abs"), Name: "file.go", Blocks: testPatchData[0].Blocks},
+	}
+
+	result := checkPatch(ds, []modules.Module{
+		{Root: filepath.FromSlash("/src/mod"), Path: "example.com/mod"},
+		{Root: filepath.FromSlash("/src/other"), Path: "example.com/other"},
+	}, outStream)
+
+	assert.Equal(t, report.Coverage{
+		Statements: 7,
+		Executed:   4,
+		Coverage:   57.14285714285714,
+	}, result.Coverage)
+	assert.Equal(t, "4 statements in changed blocks out of 7 covered; patch coverage: 57.1%\n", outStream.String())
+	assert.Equal(t, "", errStream.String())
+}
+
+func TestCheckPatchLoadChangesFails(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patchPatch(t, 80.0, assert.AnError),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(3)", func() { checkPatch(testPatchData, nil, outStream) })
+	assert.Equal(t, "", outStream.String())
+	assert.Equal(t, "Unable to determine changes relative to main: "+assert.AnError.Error()+"\n", errStream.String())
+}
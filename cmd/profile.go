@@ -0,0 +1,82 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/coverage"
+)
+
+// Variables used to store the values of flags.
+var (
+	complete      bool
+	profileOutput string
+)
+
+// profileCmd describes the profile subcommand to cobra.
+var profileCmd = &cobra.Command{
+	Use:   "profile [flags] [PACKAGE ...]",
+	Short: "Write the merged coverage data as a coverage profile",
+	Long:  `Write the merged coverage data as a coverage profile, in the same format written by the "-coverprofile" option of "go test".  The coverage profiles, binary coverage data directories, and LCOV tracefiles are read and merged as for the overcover command.  With --complete, the source of the specified packages (by default, "./...") is also read, and files with no coverage data, such as those of packages without tests, are included with blocks that were never executed, so that other tools processing the profile see them too.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Packages are only read to complete the profile
+		if len(args) > 0 && !complete {
+			fmt.Fprintf(stderr, "Packages may only be specified with --complete.\n")
+			_ = cmd.Usage()
+			exit(2)
+		}
+		if complete && len(args) == 0 {
+			args = []string{"./..."}
+		}
+
+		// Load the coverage data and write the profile
+		ds, _ := loadData(cmd, workspaceModules(), args)
+		writeProfile(ds)
+	},
+}
+
+// writeProfile writes the coverage data as a coverage profile to
+// standard output or to the file selected by the --output option of
+// the profile subcommand.
+func writeProfile(ds common.DataSet) {
+	if profileOutput == "" {
+		_ = coverage.WriteProfile(stdout, ds)
+		return
+	}
+
+	writeReport("coverage profile", profileOutput, func(w io.Writer) error {
+		return coverage.WriteProfile(w, ds)
+	})
+}
+
+// init initializes the flags for the profile subcommand.
+func init() {
+	profileCmd.Flags().StringArrayVarP(&coverprofile, "coverprofile", "p", getCoverProfileDefault(), "Specify a coverage profile file to read.  May be a glob pattern, and may be given multiple times; the profiles are merged.")
+	profileCmd.Flags().StringArrayVar(&coverdir, "coverdir", getCoverDirDefault(), "Specify a directory of binary coverage data files, as written to GOCOVERDIR by programs built with \"go build -cover\".  May be given multiple times; the data is merged with any coverage profiles.")
+	profileCmd.Flags().StringArrayVar(&tracefiles, "lcov", getLCOVDefault(), "Specify an LCOV tracefile to read, such as a coverage.dat file generated by Bazel.  May be a glob pattern, and may be given multiple times; the data is merged with any coverage profiles.")
+	profileCmd.Flags().StringArrayVarP(&buildArgs, "build-arg", "b", getBuildArgDefault(), "Add a build argument.  Build arguments are used to select source files for later coverage checking.")
+	_, workspaceDefault := os.LookupEnv("OVERCOVER_WORKSPACE")
+	profileCmd.Flags().BoolVarP(&workspace, "workspace", "w", workspaceDefault, "Used to request that the source of the specified packages be read from every module of the go.work workspace containing the current directory.")
+	profileCmd.Flags().BoolVar(&complete, "complete", false, "Read the source of the specified packages, and include the files with no coverage data in the profile.")
+	profileCmd.Flags().StringVarP(&profileOutput, "output", "o", os.Getenv("OVERCOVER_PROFILE_OUTPUT"), "Write the profile to the specified file instead of to standard output.")
+
+	rootCmd.AddCommand(profileCmd)
+}
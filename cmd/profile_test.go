@@ -0,0 +1,241 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/klmitch/patcher"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/klmitch/overcover/common"
+)
+
+var testProfileCoverage = common.DataSet{
+	{
+		Package: "some/package",
+		Name:    "file1.go",
+		Count:   2,
+		Exec:    1,
+		Blocks: []common.Block{
+			{StartLine: 3, StartCol: 19, EndLine: 5, EndCol: 3, NumStmt: 1, Count: 1},
+			{StartLine: 6, StartCol: 2, EndLine: 6, EndCol: 10, NumStmt: 1},
+		},
+	},
+}
+
+var testProfileStatements = common.DataSet{
+	{
+		Package: "some/package",
+		Name:    "file1.go",
+		Count:   2,
+		Blocks: []common.Block{
+			{StartLine: 3, StartCol: 19, EndLine: 5, EndCol: 3, NumStmt: 1},
+			{StartLine: 6, StartCol: 2, EndLine: 6, EndCol: 10, NumStmt: 1},
+		},
+		Synthetic: true,
+	},
+	{
+		Package: "other/package",
+		Name:    "file2.go",
+		Count:   1,
+		Blocks: []common.Block{
+			{StartLine: 4, StartCol: 2, EndLine: 4, EndCol: 12, NumStmt: 1},
+		},
+		Synthetic: true,
+	},
+}
+
+func TestProfileCmdBase(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	loadStatementsCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			return testProfileCoverage, nil, nil
+		}),
+		patcher.SetVar(&loadStatements, func(_ string, _, _ []string) (common.DataSet, error) {
+			loadStatementsCalled = true
+			return testProfileStatements, nil
+		}),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
+		patcher.SetVar(&complete, false),
+		patcher.SetVar(&profileOutput, ""),
+	).Install().Restore()
+
+	profileCmd.Run(profileCmd, []string{})
+
+	assert.Equal(t, `mode: set
+some/package/file1.go:3.19,5.3 1 1
+some/package/file1.go:6.2,6.10 1 0
+`, outStream.String())
+	assert.Equal(t, "", errStream.String())
+	assert.False(t, loadStatementsCalled)
+}
+
+func TestProfileCmdComplete(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&loadCoverage, func(_, _, _ []string) (common.DataSet, common.DataSet, error) {
+			return testProfileCoverage, nil, nil
+		}),
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
+			assert.Equal(t, []string{}, ba)
+			assert.Equal(t, []string{"./..."}, args)
+			return testProfileStatements, nil
+		}),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
+		patcher.SetVar(&buildArgs, []string{}),
+		patcher.SetVar(&complete, true),
+		patcher.SetVar(&profileOutput, ""),
+	).Install().Restore()
+
+	profileCmd.Run(profileCmd, []string{})
+
+	assert.Equal(t, `mode: set
+other/package/file2.go:4.2,4.12 1 0
+some/package/file1.go:3.19,5.3 1 1
+some/package/file1.go:6.2,6.10 1 0
+`, outStream.String())
+	assert.Equal(t, "", errStream.String())
+}
+
+func TestProfileCmdCompletePackages(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&loadCoverage, func(_, _, _ []string) (common.DataSet, common.DataSet, error) {
+			return nil, nil, nil
+		}),
+		patcher.SetVar(&loadStatements, func(_ string, _, args []string) (common.DataSet, error) {
+			assert.Equal(t, []string{"./other/..."}, args)
+			return testProfileStatements[1:], nil
+		}),
+		patcher.SetVar(&coverprofile, []string{}),
+		patcher.SetVar(&buildArgs, []string{}),
+		patcher.SetVar(&complete, true),
+		patcher.SetVar(&profileOutput, ""),
+	).Install().Restore()
+
+	profileCmd.Run(profileCmd, []string{"./other/..."})
+
+	assert.Equal(t, `mode: set
+other/package/file2.go:4.2,4.12 1 0
+`, outStream.String())
+	assert.Equal(t, "", errStream.String())
+}
+
+func TestProfileCmdPackagesIncomplete(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	loadCoverageCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&loadCoverage, func(_, _, _ []string) (common.DataSet, common.DataSet, error) {
+			loadCoverageCalled = true
+			return testProfileCoverage, nil, nil
+		}),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
+		patcher.SetVar(&complete, false),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(2)", func() { profileCmd.Run(profileCmd, []string{"./..."}) })
+	assert.Equal(t, "", outStream.String())
+	assert.Equal(t, "Packages may only be specified with --complete.\n", errStream.String())
+	assert.False(t, loadCoverageCalled)
+}
+
+func TestProfileCmdOutputFile(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	var written []byte
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&loadCoverage, func(_, _, _ []string) (common.DataSet, common.DataSet, error) {
+			return testProfileCoverage, nil, nil
+		}),
+		patcher.SetVar(&writeFile, func(name string, data []byte, _ os.FileMode) error {
+			assert.Equal(t, "full.out", name)
+			written = data
+			return nil
+		}),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
+		patcher.SetVar(&complete, false),
+		patcher.SetVar(&profileOutput, "full.out"),
+	).Install().Restore()
+
+	profileCmd.Run(profileCmd, []string{})
+
+	assert.Equal(t, "", outStream.String())
+	assert.Equal(t, "", errStream.String())
+	assert.Equal(t, `mode: set
+some/package/file1.go:3.19,5.3 1 1
+some/package/file1.go:6.2,6.10 1 0
+`, string(written))
+}
+
+func TestProfileCmdOutputFileFails(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&loadCoverage, func(_, _, _ []string) (common.DataSet, common.DataSet, error) {
+			return testProfileCoverage, nil, nil
+		}),
+		patcher.SetVar(&writeFile, func(_ string, _ []byte, _ os.FileMode) error {
+			return assert.AnError
+		}),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
+		patcher.SetVar(&complete, false),
+		patcher.SetVar(&profileOutput, "full.out"),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(3)", func() { profileCmd.Run(profileCmd, []string{}) })
+	assert.Equal(t, "", outStream.String())
+	assert.Equal(t, fmt.Sprintf("Unable to write coverage profile report to full.out: %s\n", assert.AnError), errStream.String())
+}
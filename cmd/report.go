@@ -0,0 +1,222 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/modules"
+	"github.com/klmitch/overcover/report"
+)
+
+// Report formats.
+const (
+	formatText     = "text"     // Human-readable text report
+	formatJSON     = "json"     // Machine-readable JSON report
+	formatMarkdown = "markdown" // Markdown report for pull requests
+)
+
+// Patch points for top-level functions called by functions in this
+// file.
+var (
+	writeFile = os.WriteFile
+	openFile  = os.OpenFile
+)
+
+// output describes where the report is written.
+type output struct {
+	text io.Writer     // Stream for the text report
+	buf  *bytes.Buffer // Buffer for a text report written to a file
+}
+
+// newOutput sets up the output for the report, as selected by the
+// --format and --output options.
+func newOutput() *output {
+	switch format {
+	case formatText:
+		if outputFile == "" {
+			return &output{text: stdout}
+		}
+		buf := &bytes.Buffer{}
+		return &output{text: buf, buf: buf}
+
+	case formatJSON, formatMarkdown:
+		return &output{text: io.Discard}
+	}
+
+	fmt.Fprintf(stderr, "Unknown report format %q; use \"text\", \"json\", or \"markdown\"\n", format)
+	exit(2)
+	return nil
+}
+
+// emit writes the report to standard output or to the file selected
+// by the --output option.  A text report written to standard output
+// has already been emitted.
+func (o *output) emit(rep *report.Report) {
+	var data []byte
+	switch {
+	case o.buf != nil:
+		data = o.buf.Bytes()
+
+	case format == formatJSON:
+		buf := &bytes.Buffer{}
+		_ = rep.WriteJSON(buf) // writes to a buffer cannot fail
+		data = buf.Bytes()
+
+	case format == formatMarkdown:
+		buf := &bytes.Buffer{}
+		_ = rep.WriteMarkdown(buf, worst) // writes to a buffer cannot fail
+		data = buf.Bytes()
+		appendStepSummary(data)
+
+	default:
+		return
+	}
+
+	// Write the report
+	if outputFile == "" {
+		_, _ = stdout.Write(data)
+		return
+	}
+	writeReport(format, outputFile, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// appendFile appends data to a file, creating it if necessary.
+func appendFile(fname string, data []byte) error {
+	f, err := openFile(fname, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o666)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// appendStepSummary appends a Markdown report to the GitHub Actions
+// step summary file named by the GITHUB_STEP_SUMMARY environment
+// variable, if it is set.  Failure to do so is not fatal.
+func appendStepSummary(data []byte) {
+	fname := os.Getenv("GITHUB_STEP_SUMMARY")
+	if fname == "" {
+		return
+	}
+
+	if err := appendFile(fname, data); err != nil {
+		fmt.Fprintf(stderr, "WARNING: unable to write step summary to %s: %s\n", fname, err)
+	}
+}
+
+// writeReport writes a report, generated by the specified function,
+// to a file.  The kind describes the report for error messages.
+func writeReport(kind, fname string, gen func(io.Writer) error) {
+	buf := &bytes.Buffer{}
+	err := gen(buf)
+	if err == nil {
+		err = writeFile(fname, buf.Bytes(), 0o666)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Unable to write %s report to %s: %s\n", kind, fname, err)
+		exit(3)
+	}
+}
+
+// writeCobertura writes the coverage data as a Cobertura XML report
+// to the file selected by the --cobertura option, if any.
+func writeCobertura(ds common.DataSet, mods []modules.Module) {
+	if cobertura == "" {
+		return
+	}
+
+	srcMods := sourceModules(mods)
+	writeReport("Cobertura", cobertura, func(w io.Writer) error {
+		return report.WriteCobertura(w, ds, srcMods)
+	})
+}
+
+// writeLCOV writes the coverage data as an LCOV tracefile to the
+// file selected by the --lcov-output option, if any.
+func writeLCOV(ds common.DataSet, mods []modules.Module) {
+	if lcovOutput == "" {
+		return
+	}
+
+	srcMods := sourceModules(mods)
+	writeReport("LCOV", lcovOutput, func(w io.Writer) error {
+		return report.WriteLCOV(w, ds, srcMods)
+	})
+}
+
+// writeHTML writes the coverage data as an HTML report to the file
+// selected by the --html option, if any.
+func writeHTML(ds common.DataSet, mods []modules.Module, threshold report.Threshold) {
+	if htmlOutput == "" {
+		return
+	}
+
+	srcMods := sourceModules(mods)
+	writeReport("HTML", htmlOutput, func(w io.Writer) error {
+		return report.WriteHTML(w, ds, threshold, srcMods)
+	})
+}
+
+// writeBadge writes a badge displaying the overall coverage to the
+// files selected by the --badge and --badge-endpoint options, if any.
+// The color of the badge is selected by comparing the coverage to the
+// threshold.
+func writeBadge(coverage, threshold float64) {
+	if badge == "" && badgeJSON == "" {
+		return
+	}
+
+	b := report.NewBadge(coverage, threshold, getFloat64("badge_margin"))
+	if badge != "" {
+		writeReport("badge", badge, b.WriteSVG)
+	}
+	if badgeJSON != "" {
+		writeReport("badge endpoint", badgeJSON, b.WriteEndpoint)
+	}
+}
+
+// writeJUnit writes the results of the threshold checks as a JUnit
+// XML report to the file selected by the --junit option, if any.
+func writeJUnit(rep *report.Report) {
+	if junit == "" {
+		return
+	}
+
+	writeReport("JUnit", junit, rep.WriteJUnit)
+}
+
+// infoStream returns the stream to which informational messages
+// should be written.  This is standard output for a text report, but
+// standard error for any other format, so that a machine-readable
+// report written to standard output is not corrupted.
+func infoStream() io.Writer {
+	if format == formatText {
+		return stdout
+	}
+
+	return stderr
+}
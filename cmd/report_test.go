@@ -0,0 +1,594 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klmitch/patcher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/report"
+)
+
+var testReport = &report.Report{
+	Version: report.Version,
+	Overall: report.Coverage{Statements: 10, Executed: 5, Coverage: 50.0},
+}
+
+func TestNewOutputText(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&format, formatText),
+		patcher.SetVar(&outputFile, ""),
+	).Install().Restore()
+
+	result := newOutput()
+
+	assert.Equal(t, &output{text: outStream}, result)
+}
+
+func TestNewOutputTextFile(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&format, formatText),
+		patcher.SetVar(&outputFile, "report.txt"),
+	).Install().Restore()
+
+	result := newOutput()
+
+	assert.NotNil(t, result.buf)
+	assert.Same(t, result.buf, result.text)
+}
+
+func TestNewOutputJSON(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&format, formatJSON),
+		patcher.SetVar(&outputFile, ""),
+	).Install().Restore()
+
+	result := newOutput()
+
+	assert.Equal(t, &output{text: io.Discard}, result)
+}
+
+func TestNewOutputMarkdown(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&format, formatMarkdown),
+		patcher.SetVar(&outputFile, ""),
+	).Install().Restore()
+
+	result := newOutput()
+
+	assert.Equal(t, &output{text: io.Discard}, result)
+}
+
+func TestNewOutputUnknown(t *testing.T) {
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&format, "xml"),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(2)", func() { newOutput() })
+	assert.Equal(t, "Unknown report format \"xml\"; use \"text\", \"json\", or \"markdown\"\n", errStream.String())
+}
+
+func TestOutputEmitText(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	writeFileCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&format, formatText),
+		patcher.SetVar(&outputFile, ""),
+		patcher.SetVar(&writeFile, func(_ string, _ []byte, _ os.FileMode) error {
+			writeFileCalled = true
+			return nil
+		}),
+	).Install().Restore()
+	obj := &output{text: outStream}
+
+	obj.emit(testReport)
+
+	assert.Equal(t, "", outStream.String())
+	assert.False(t, writeFileCalled)
+}
+
+func TestOutputEmitTextFile(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	var written []byte
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&format, formatText),
+		patcher.SetVar(&outputFile, "report.txt"),
+		patcher.SetVar(&writeFile, func(name string, data []byte, perm os.FileMode) error {
+			assert.Equal(t, "report.txt", name)
+			assert.Equal(t, os.FileMode(0o666), perm)
+			written = data
+			return nil
+		}),
+	).Install().Restore()
+	buf := bytes.NewBufferString("text report\n")
+	obj := &output{text: buf, buf: buf}
+
+	obj.emit(testReport)
+
+	assert.Equal(t, "", outStream.String())
+	assert.Equal(t, "text report\n", string(written))
+}
+
+func TestOutputEmitJSON(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&format, formatJSON),
+		patcher.SetVar(&outputFile, ""),
+	).Install().Restore()
+	obj := &output{text: io.Discard}
+	expected := &bytes.Buffer{}
+	assert.NoError(t, testReport.WriteJSON(expected))
+
+	obj.emit(testReport)
+
+	assert.Equal(t, expected.String(), outStream.String())
+}
+
+func TestOutputEmitMarkdown(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&format, formatMarkdown),
+		patcher.SetVar(&outputFile, ""),
+		patcher.SetVar(&worst, 5),
+		patcher.UnsetEnv("GITHUB_STEP_SUMMARY"),
+	).Install().Restore()
+	obj := &output{text: io.Discard}
+	expected := &bytes.Buffer{}
+	assert.NoError(t, testReport.WriteMarkdown(expected, 5))
+
+	obj.emit(testReport)
+
+	assert.Equal(t, expected.String(), outStream.String())
+}
+
+func TestOutputEmitMarkdownStepSummary(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	summaryFile := filepath.Join(t.TempDir(), "summary.md")
+	require.NoError(t, os.WriteFile(summaryFile, []byte("previous\n"), 0o600))
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&format, formatMarkdown),
+		patcher.SetVar(&outputFile, ""),
+		patcher.SetVar(&worst, 5),
+		patcher.SetEnv("GITHUB_STEP_SUMMARY", summaryFile),
+	).Install().Restore()
+	obj := &output{text: io.Discard}
+	expected := &bytes.Buffer{}
+	assert.NoError(t, testReport.WriteMarkdown(expected, 5))
+
+	obj.emit(testReport)
+
+	assert.Equal(t, expected.String(), outStream.String())
+	summary, err := os.ReadFile(summaryFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "previous\n"+expected.String(), string(summary))
+}
+
+func TestOutputEmitWriteFails(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&format, formatJSON),
+		patcher.SetVar(&outputFile, "report.json"),
+		patcher.SetVar(&writeFile, func(_ string, _ []byte, _ os.FileMode) error {
+			return assert.AnError
+		}),
+	).Install().Restore()
+	obj := &output{text: io.Discard}
+
+	assert.PanicsWithValue(t, "os.Exit(3)", func() { obj.emit(testReport) })
+	assert.Equal(t, "", outStream.String())
+	assert.Equal(t, "Unable to write json report to report.json: "+assert.AnError.Error()+"\n", errStream.String())
+}
+
+func TestAppendFileBase(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "file")
+
+	err1 := appendFile(fname, []byte("one\n"))
+	err2 := appendFile(fname, []byte("two\n"))
+
+	assert.NoError(t, err1)
+	assert.NoError(t, err2)
+	data, err := os.ReadFile(fname)
+	assert.NoError(t, err)
+	assert.Equal(t, "one\ntwo\n", string(data))
+}
+
+func TestAppendFileOpenFails(t *testing.T) {
+	defer patcher.SetVar(&openFile, func(_ string, _ int, _ os.FileMode) (*os.File, error) {
+		return nil, assert.AnError
+	}).Install().Restore()
+
+	err := appendFile("file", []byte("data"))
+
+	assert.Same(t, assert.AnError, err)
+}
+
+func TestAppendFileWriteFails(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(fname, nil, 0o600))
+	defer patcher.SetVar(&openFile, func(name string, _ int, _ os.FileMode) (*os.File, error) {
+		return os.Open(name)
+	}).Install().Restore()
+
+	err := appendFile(fname, []byte("data"))
+
+	assert.Error(t, err)
+}
+
+func TestAppendStepSummaryUnset(t *testing.T) {
+	openFileCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.UnsetEnv("GITHUB_STEP_SUMMARY"),
+		patcher.SetVar(&openFile, func(_ string, _ int, _ os.FileMode) (*os.File, error) {
+			openFileCalled = true
+			return nil, assert.AnError
+		}),
+	).Install().Restore()
+
+	appendStepSummary([]byte("data"))
+
+	assert.False(t, openFileCalled)
+}
+
+func TestAppendStepSummaryFails(t *testing.T) {
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetEnv("GITHUB_STEP_SUMMARY", "summary.md"),
+		patcher.SetVar(&openFile, func(_ string, _ int, _ os.FileMode) (*os.File, error) {
+			return nil, assert.AnError
+		}),
+	).Install().Restore()
+
+	appendStepSummary([]byte("data"))
+
+	assert.Equal(t, "WARNING: unable to write step summary to summary.md: "+assert.AnError.Error()+"\n", errStream.String())
+}
+
+func TestWriteReportBase(t *testing.T) {
+	var written []byte
+	defer patcher.SetVar(&writeFile, func(name string, data []byte, perm os.FileMode) error {
+		assert.Equal(t, "report.xml", name)
+		assert.Equal(t, os.FileMode(0o666), perm)
+		written = data
+		return nil
+	}).Install().Restore()
+
+	writeReport("XML", "report.xml", func(w io.Writer) error {
+		_, err := io.WriteString(w, "<report/>")
+		return err
+	})
+
+	assert.Equal(t, "<report/>", string(written))
+}
+
+func TestWriteReportGenFails(t *testing.T) {
+	errStream := &bytes.Buffer{}
+	writeFileCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&writeFile, func(_ string, _ []byte, _ os.FileMode) error {
+			writeFileCalled = true
+			return nil
+		}),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(3)", func() {
+		writeReport("XML", "report.xml", func(_ io.Writer) error {
+			return assert.AnError
+		})
+	})
+	assert.Equal(t, "Unable to write XML report to report.xml: "+assert.AnError.Error()+"\n", errStream.String())
+	assert.False(t, writeFileCalled)
+}
+
+func TestWriteCoberturaUnset(t *testing.T) {
+	writeFileCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&cobertura, ""),
+		patcher.SetVar(&writeFile, func(_ string, _ []byte, _ os.FileMode) error {
+			writeFileCalled = true
+			return nil
+		}),
+	).Install().Restore()
+
+	writeCobertura(common.DataSet{}, nil)
+
+	assert.False(t, writeFileCalled)
+}
+
+func TestWriteCoberturaSet(t *testing.T) {
+	var written []byte
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&cobertura, "cover.xml"),
+		patcher.SetVar(&findModule, func(dir string) (string, string, error) {
+			assert.Equal(t, ".", dir)
+			return "/src/mod", "example.com/mod", nil
+		}),
+		patcher.SetVar(&writeFile, func(name string, data []byte, _ os.FileMode) error {
+			assert.Equal(t, "cover.xml", name)
+			written = data
+			return nil
+		}),
+	).Install().Restore()
+
+	writeCobertura(common.DataSet{
+		{Package: "example.com/mod/pkg", Name: "file.go", Count: 2, Exec: 1},
+	}, nil)
+
+	assert.Contains(t, string(written), `<source>/src/mod</source>`)
+	assert.Contains(t, string(written), `<class name="file.go" filename="pkg/file.go" line-rate="0.5"`)
+}
+
+func TestWriteLCOVUnset(t *testing.T) {
+	writeFileCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&lcovOutput, ""),
+		patcher.SetVar(&writeFile, func(_ string, _ []byte, _ os.FileMode) error {
+			writeFileCalled = true
+			return nil
+		}),
+	).Install().Restore()
+
+	writeLCOV(common.DataSet{}, nil)
+
+	assert.False(t, writeFileCalled)
+}
+
+func TestWriteLCOVSet(t *testing.T) {
+	var written []byte
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&lcovOutput, "lcov.info"),
+		patcher.SetVar(&findModule, func(dir string) (string, string, error) {
+			assert.Equal(t, ".", dir)
+			return "/src/mod", "example.com/mod", nil
+		}),
+		patcher.SetVar(&writeFile, func(name string, data []byte, _ os.FileMode) error {
+			assert.Equal(t, "lcov.info", name)
+			written = data
+			return nil
+		}),
+	).Install().Restore()
+
+	writeLCOV(common.DataSet{
+		{Package: "example.com/other", Name: "file.go", Count: 2, Exec: 1},
+	}, nil)
+
+	assert.Equal(t, "TN:\nSF:example.com/other/file.go\nLF:0\nLH:0\nend_of_record\n", string(written))
+}
+
+func TestWriteHTMLUnset(t *testing.T) {
+	writeFileCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&htmlOutput, ""),
+		patcher.SetVar(&writeFile, func(_ string, _ []byte, _ os.FileMode) error {
+			writeFileCalled = true
+			return nil
+		}),
+	).Install().Restore()
+
+	writeHTML(common.DataSet{}, nil, report.Threshold{})
+
+	assert.False(t, writeFileCalled)
+}
+
+func TestWriteHTMLSet(t *testing.T) {
+	var written []byte
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&htmlOutput, "cover.html"),
+		patcher.SetVar(&findModule, func(dir string) (string, string, error) {
+			assert.Equal(t, ".", dir)
+			return "/src/mod", "example.com/mod", nil
+		}),
+		patcher.SetVar(&writeFile, func(name string, data []byte, _ os.FileMode) error {
+			assert.Equal(t, "cover.html", name)
+			written = data
+			return nil
+		}),
+	).Install().Restore()
+
+	writeHTML(common.DataSet{
+		{Package: "example.com/other", Name: "file.go", Count: 2, Exec: 1},
+	}, nil, report.Threshold{Threshold: 60.0})
+
+	assert.Contains(t, string(written), "Failed to meet coverage threshold of 60.0%")
+	assert.Contains(t, string(written), "<h2>File example.com/other/file.go</h2>")
+}
+
+func TestWriteBadgeUnset(t *testing.T) {
+	writeFileCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&badge, ""),
+		patcher.SetVar(&badgeJSON, ""),
+		patcher.SetVar(&writeFile, func(_ string, _ []byte, _ os.FileMode) error {
+			writeFileCalled = true
+			return nil
+		}),
+	).Install().Restore()
+
+	writeBadge(50.0, 60.0)
+
+	assert.False(t, writeFileCalled)
+}
+
+func TestWriteBadgeSVG(t *testing.T) {
+	written := map[string][]byte{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&badge, "badge.svg"),
+		patcher.SetVar(&badgeJSON, ""),
+		patcher.SetVar(&getFloat64, func(name string) float64 {
+			assert.Equal(t, "badge_margin", name)
+			return 5.0
+		}),
+		patcher.SetVar(&writeFile, func(name string, data []byte, _ os.FileMode) error {
+			written[name] = data
+			return nil
+		}),
+	).Install().Restore()
+
+	writeBadge(62.5, 60.0)
+
+	assert.Len(t, written, 1)
+	assert.Contains(t, string(written["badge.svg"]), `<title>coverage: 62.5%</title>`)
+	assert.Contains(t, string(written["badge.svg"]), `fill="#dfb317"`)
+}
+
+func TestWriteBadgeEndpoint(t *testing.T) {
+	written := map[string][]byte{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&badge, ""),
+		patcher.SetVar(&badgeJSON, "badge.json"),
+		patcher.SetVar(&getFloat64, func(name string) float64 {
+			assert.Equal(t, "badge_margin", name)
+			return 5.0
+		}),
+		patcher.SetVar(&writeFile, func(name string, data []byte, _ os.FileMode) error {
+			written[name] = data
+			return nil
+		}),
+	).Install().Restore()
+
+	writeBadge(50.0, 60.0)
+
+	assert.Equal(t, map[string][]byte{
+		"badge.json": []byte(`{
+  "schemaVersion": 1,
+  "label": "coverage",
+  "message": "50.0%",
+  "color": "red"
+}
+`),
+	}, written)
+}
+
+func TestWriteBadgeBoth(t *testing.T) {
+	written := map[string][]byte{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&badge, "badge.svg"),
+		patcher.SetVar(&badgeJSON, "badge.json"),
+		patcher.SetVar(&getFloat64, func(name string) float64 {
+			assert.Equal(t, "badge_margin", name)
+			return 5.0
+		}),
+		patcher.SetVar(&writeFile, func(name string, data []byte, _ os.FileMode) error {
+			written[name] = data
+			return nil
+		}),
+	).Install().Restore()
+
+	writeBadge(90.0, 60.0)
+
+	assert.Len(t, written, 2)
+	assert.Contains(t, string(written["badge.svg"]), `fill="#4c1"`)
+	assert.Contains(t, string(written["badge.json"]), `"color": "brightgreen"`)
+}
+
+func TestWriteJUnitUnset(t *testing.T) {
+	writeFileCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&junit, ""),
+		patcher.SetVar(&writeFile, func(_ string, _ []byte, _ os.FileMode) error {
+			writeFileCalled = true
+			return nil
+		}),
+	).Install().Restore()
+
+	writeJUnit(&report.Report{})
+
+	assert.False(t, writeFileCalled)
+}
+
+func TestWriteJUnitSet(t *testing.T) {
+	var written []byte
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&junit, "junit.xml"),
+		patcher.SetVar(&writeFile, func(name string, data []byte, _ os.FileMode) error {
+			assert.Equal(t, "junit.xml", name)
+			written = data
+			return nil
+		}),
+	).Install().Restore()
+
+	writeJUnit(&report.Report{
+		Overall:   report.Coverage{Statements: 2, Executed: 1, Coverage: 50.0},
+		Threshold: report.Threshold{Threshold: 60.0},
+	})
+
+	assert.Contains(t, string(written), `<testsuite name="overcover" tests="1" failures="1"`)
+	assert.Contains(t, string(written), `<failure message="coverage 50.0% is below the required 60.0%" type="threshold">`)
+}
+
+func TestInfoStreamText(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&format, formatText),
+	).Install().Restore()
+
+	result := infoStream()
+
+	assert.Same(t, outStream, result)
+}
+
+func TestInfoStreamJSON(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&format, formatJSON),
+	).Install().Restore()
+
+	result := infoStream()
+
+	assert.Same(t, errStream, result)
+}
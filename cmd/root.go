@@ -15,12 +15,17 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"math"
 	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
@@ -28,6 +33,10 @@ import (
 
 	"github.com/klmitch/overcover/common"
 	"github.com/klmitch/overcover/coverage"
+	"github.com/klmitch/overcover/diff"
+	"github.com/klmitch/overcover/modules"
+	"github.com/klmitch/overcover/report"
+	"github.com/klmitch/overcover/rules"
 	"github.com/klmitch/overcover/statements"
 )
 
@@ -35,25 +44,49 @@ import (
 var (
 	config       string
 	readOnly     bool
-	coverprofile string
+	coverprofile = []string{}
+	coverdir     = []string{}
+	tracefiles   = []string{}
+	diffBase     string
 	buildArgs    = []string{}
+	excludes     = []string{}
 	detailed     bool
+	functions    bool
+	uncovered    bool
 	summary      bool
+	format       string
+	outputFile   string
+	cobertura    string
+	lcovOutput   string
+	htmlOutput   string
+	junit        string
+	badge        string
+	badgeJSON    string
+	worst        int
+	workspace    bool
 )
 
 // Variables used for mocking for the tests.
 var (
-	stdout         io.Writer                                        = os.Stdout
-	stderr         io.Writer                                        = os.Stderr
-	exit                                                            = os.Exit
-	getFloat64     func(string) float64                             = viper.GetFloat64
-	setConfigFile  func(string)                                     = viper.SetConfigFile
-	readInConfig   func() error                                     = viper.ReadInConfig
-	configFileUsed func() string                                    = viper.ConfigFileUsed
-	setConfig      func(string, interface{})                        = viper.Set
-	writeConfig    func(string) error                               = viper.WriteConfigAs
-	loadCoverage   func(string) (common.DataSet, error)             = coverage.Load
-	loadStatements func([]string, []string) (common.DataSet, error) = statements.Load
+	stdout          io.Writer                                                                  = os.Stdout
+	stderr          io.Writer                                                                  = os.Stderr
+	exit                                                                                       = os.Exit
+	getFloat64      func(string) float64                                                       = viper.GetFloat64
+	getBool         func(string) bool                                                          = viper.GetBool
+	setConfigFile   func(string)                                                               = viper.SetConfigFile
+	readInConfig    func() error                                                               = viper.ReadInConfig
+	configFileUsed  func() string                                                              = viper.ConfigFileUsed
+	setConfig       func(string, interface{})                                                  = viper.Set
+	writeConfig     func(string) error                                                         = writeConfigFile
+	settings        *viper.Viper                                                               = viper.GetViper()
+	loadCoverage    func([]string, []string, []string) (common.DataSet, common.DataSet, error) = coverage.Load
+	loadChanges     func(string) (diff.Changes, error)                                         = diff.Load
+	loadStatements  func(string, []string, []string) (common.DataSet, error)                   = statements.Load
+	unmarshalKey    func(string, interface{}, ...viper.DecoderConfigOption) error              = viper.UnmarshalKey
+	findModule      func(string) (string, string, error)                                       = modules.Find
+	findWorkspace   func(string) ([]modules.Module, error)                                     = modules.FindWorkspace
+	applyDirectives func(common.DataSet, string, string) common.DataSet                        = coverage.ApplyDirectives
+	findFunctions   func(common.DataSet) (common.FuncSet, common.DataSet)                      = coverage.Functions
 )
 
 // rootCmd describes the overcover command to cobra.
@@ -61,102 +94,79 @@ var rootCmd = &cobra.Command{
 	Use:   "overcover [flags] [PACKAGE ...]",
 	Short: "Golang overall coverage tool with threshold enforcement",
 	Long:  `A tool for reporting and testing the overall test suite coverage of a test suite written in go.  This parses the coverage profile output file (generated by passing a filename to the "-coverprofile" option of "go test") and reports the overall coverage of the test suite.  It can also test that the coverage meets a certain minimum threshold.`,
+	Args:  cobra.ArbitraryArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		// Load the coverage; this reads the coverage profile
-		// and sums the statement counts
-		if coverprofile == "" && len(args) == 0 {
-			fmt.Fprintf(stderr, "No coverage profile file specified!  Use -p or provide a configuration file.\n")
-			_ = cmd.Usage()
-			exit(2)
-		}
-		var ds common.DataSet
-		if coverprofile != "" {
-			var err error
-			ds, err = loadCoverage(coverprofile)
-			if err != nil {
-				fmt.Fprintf(stderr, "Unable to read coverage profile file %q: %s\n", coverprofile, err)
-				exit(3)
-			}
-		}
+		// Set up the report output
+		out := newOutput()
 
-		// Next, read in the source if requested to
-		if len(args) > 0 {
-			direct, err := loadStatements(buildArgs, args)
-			if err != nil {
-				fmt.Fprintf(stderr, "Unable to read source: %s\n", err)
-				exit(3)
-			}
+		// Load the coverage data
+		mods := workspaceModules()
+		ds, conflicts := loadData(cmd, mods, args)
+		ds, excluded := excludeData(out.text, ds, mods)
+		rep := report.New(ds)
+		rep.Conflicts = conflicts
+		rep.Excluded = excluded
+		writeCobertura(ds, mods)
+		writeLCOV(ds, mods)
 
-			// Merge the direct-read data
-			var conflict common.DataSet
-			ds, conflict = ds.Merge(direct)
-			if len(conflict) > 0 {
-				fmt.Fprintf(stderr, "WARNING: coverage profile %s may not match source; potentially altered files:\n", coverprofile)
-				for _, fd := range conflict {
-					fmt.Fprintf(stderr, "  %s\n", fd.Handle())
-				}
-			}
-		}
-
-		// Emit summary data, if requested
-		if summary {
-			summary := ds.Reduce()
-			sort.Sort(summary)
-			fmt.Fprintln(stdout, "Per package summary:")
-			tab := tabwriter.NewWriter(stdout, 2, 8, 2, ' ', 0)
-			fmt.Fprintf(tab, " Package\tExecuted\tTotal\tCoverage\n -------\t--------\t-----\t--------\n")
-			for _, rec := range summary {
-				fmt.Fprintf(tab, " %s\t%d\t%d\t%.1f%%\n", rec.Handle(), rec.Exec, rec.Count, rec.Coverage()*100.0)
-			}
-			tab.Flush()
-			fmt.Fprintln(stdout, "")
-		}
-
-		// Emit detailed data, if requested
-		if detailed {
-			sort.Sort(ds)
-			fmt.Fprintln(stdout, "Per file details:")
-			tab := tabwriter.NewWriter(stdout, 2, 8, 2, ' ', 0)
-			fmt.Fprintf(tab, " File\tExecuted\tTotal\tCoverage\n ----\t--------\t-----\t--------\n")
-			for _, rec := range ds {
-				fmt.Fprintf(tab, " %s\t%d\t%d\t%.1f%%\n", rec.Handle(), rec.Exec, rec.Count, rec.Coverage()*100.0)
-			}
-			tab.Flush()
-			fmt.Fprintln(stdout, "")
-		}
+		// Emit the summary and detailed data, if requested; the
+		// function coverage is computed at most once, when needed
+		writeTables(out.text, ds)
+		funcs := sync.OnceValue(func() common.FuncSet {
+			return loadFunctions(ds, mods)
+		})
+		rep.Functions = writeFunctions(out.text, funcs)
 
 		// Compute the overall coverage record
 		overall := ds.Sum()
-		fmt.Fprintln(stdout, overall)
+		fmt.Fprintln(out.text, overall)
 
 		// Verify that we met the threshold
 		coverage := overall.Coverage() * 100.0
 		threshold := getFloat64("threshold")
-		if threshold > 0.0 && coverage < threshold {
+		rep.Threshold = report.NewThreshold(coverage, threshold)
+		writeHTML(ds, mods, rep.Threshold)
+		writeBadge(coverage, threshold)
+		failed := false
+		if !rep.Threshold.Passed {
 			fmt.Fprintf(stderr, "\nFailed to meet coverage threshold of %.1f%%\n", threshold)
+			failed = true
+		}
+
+		// Also verify the per-package rules
+		results, ok := checkRules(ds, funcs)
+		rep.Rules = report.NewRules(results)
+		if !ok {
+			failed = true
+		}
+
+		// And the per-module thresholds of a workspace
+		rep.Modules, ok = checkModules(out.text, ds, mods)
+		if !ok {
+			failed = true
+		}
+
+		// And the coverage of the changes, if requested
+		rep.Patch = checkPatch(ds, mods, out.text)
+		if rep.Patch != nil && !rep.Patch.Threshold.Passed {
+			failed = true
+		}
+		writeJUnit(rep)
+		recordHistory(ds)
+		if failed {
+			out.emit(rep)
 			exit(1)
 		}
 
-		// OK, now let's see if the threshold needs updating
+		// OK, now let's see if the thresholds need updating
 		minHeadroom := getFloat64("min_headroom")
 		maxHeadroom := getFloat64("max_headroom")
-		if config != "" && minHeadroom >= 0.0 && maxHeadroom > minHeadroom && coverage > threshold+maxHeadroom {
-			// Compute new threshold
-			newThreshold := math.Round(coverage*10.0)/10.0 - minHeadroom
-
-			// If we're read-only, generate an error
-			if readOnly {
-				fmt.Fprintf(stderr, "\nCoverage exceeds maximum headroom.  Update threshold to %.1f%%\n", newThreshold)
-				exit(5)
-			}
-
-			// OK, update the configuration
-			fmt.Fprintf(stdout, "Updating configuration file %s with new threshold value %.1f%%\n", config, newThreshold)
-			setConfig("threshold", newThreshold)
-			if err := writeConfig(config); err != nil {
-				fmt.Fprintf(stderr, "\nFailed to write updated config with new threshold %.1f%% to %s: %s\n", newThreshold, config, err)
-				exit(5)
-			}
+		if config != "" && minHeadroom >= 0.0 && maxHeadroom > minHeadroom {
+			rep.Update = proposeUpdate(coverage, threshold, minHeadroom, maxHeadroom, results)
+		}
+		out.emit(rep)
+		if rep.Update != nil {
+			applyUpdate(rep.Update, results)
 		}
 	},
 }
@@ -170,6 +180,198 @@ func Execute() {
 	}
 }
 
+// loadData loads the coverage data from the coverage profiles and
+// coverage data directories, along with the statement counts from the
+// source of any packages specified on the command line.  In a
+// workspace, the source is read from each of the specified modules.
+// Statements excluded by ignore directives in the source are removed.
+// Warnings are emitted for any data that had to be ignored, and the
+// affected files are also returned.
+func loadData(cmd *cobra.Command, mods []modules.Module, args []string) (common.DataSet, report.Conflicts) {
+	// Load the coverage; this reads the coverage profiles
+	// and sums the statement counts
+	if len(coverprofile) == 0 && len(coverdir) == 0 && len(tracefiles) == 0 && len(args) == 0 {
+		fmt.Fprintf(stderr, "No coverage profile file specified!  Use -p, --coverdir, or --lcov, or provide a configuration file.\n")
+		_ = cmd.Usage()
+		exit(2)
+	}
+	var ds common.DataSet
+	conflicts := report.Conflicts{
+		Profiles: []string{},
+		Source:   []string{},
+	}
+	inputs := strings.Join(append(append(append([]string{}, coverprofile...), coverdir...), tracefiles...), ", ")
+	if len(coverprofile) > 0 || len(coverdir) > 0 || len(tracefiles) > 0 {
+		var conflict common.DataSet
+		var err error
+		ds, conflict, err = loadCoverage(coverprofile, coverdir, tracefiles)
+		if err != nil {
+			fmt.Fprintf(stderr, "Unable to read coverage profile file %s\n", err)
+			exit(3)
+		}
+
+		// Apply the ignore directives in the source
+		ds = sourceDirectives(ds, mods)
+
+		// Report profiles that could not be merged
+		if len(conflict) > 0 {
+			fmt.Fprintf(stderr, "WARNING: coverage profiles %s have mismatched blocks; ignored data for files:\n", inputs)
+			for _, fd := range conflict {
+				fmt.Fprintf(stderr, "  %s\n", fd.Handle())
+				conflicts.Profiles = append(conflicts.Profiles, fd.Handle())
+			}
+		}
+	}
+
+	// Next, read in the source if requested to
+	if len(args) > 0 {
+		direct, err := loadSource(mods, args)
+		if err != nil {
+			fmt.Fprintf(stderr, "Unable to read source: %s\n", err)
+			exit(3)
+		}
+
+		// Merge the direct-read data
+		var conflict common.DataSet
+		ds, conflict = ds.Merge(direct)
+		if len(conflict) > 0 {
+			fmt.Fprintf(stderr, "WARNING: coverage profile %s may not match source; potentially altered files:\n", inputs)
+			for _, fd := range conflict {
+				fmt.Fprintf(stderr, "  %s\n", fd.Handle())
+				conflicts.Source = append(conflicts.Source, fd.Handle())
+			}
+		}
+	}
+
+	return ds, conflicts
+}
+
+// writeTables writes the per-package summary and per-file detail
+// tables, and the list of uncovered lines in each file, if requested.
+func writeTables(w io.Writer, ds common.DataSet) {
+	// Emit summary data, if requested
+	if summary {
+		summary := ds.Reduce()
+		sort.Sort(summary)
+		fmt.Fprintln(w, "Per package summary:")
+		tab := tabwriter.NewWriter(w, 2, 8, 2, ' ', 0)
+		fmt.Fprintf(tab, " Package\tExecuted\tTotal\tCoverage\n -------\t--------\t-----\t--------\n")
+		for _, rec := range summary {
+			fmt.Fprintf(tab, " %s\t%d\t%d\t%.1f%%\n", rec.Handle(), rec.Exec, rec.Count, rec.Coverage()*100.0)
+		}
+		tab.Flush()
+		fmt.Fprintln(w, "")
+	}
+
+	// Emit detailed data, if requested
+	if detailed {
+		sorted := append(common.DataSet{}, ds...)
+		sort.Sort(sorted)
+		fmt.Fprintln(w, "Per file details:")
+		tab := tabwriter.NewWriter(w, 2, 8, 2, ' ', 0)
+		fmt.Fprintf(tab, " File\tExecuted\tTotal\tCoverage\n ----\t--------\t-----\t--------\n")
+		for _, rec := range sorted {
+			fmt.Fprintf(tab, " %s\t%d\t%d\t%.1f%%\n", rec.Handle(), rec.Exec, rec.Count, rec.Coverage()*100.0)
+		}
+		tab.Flush()
+		fmt.Fprintln(w, "")
+	}
+
+	// Emit the uncovered lines, if requested
+	if uncovered {
+		sorted := append(common.DataSet{}, ds...)
+		sort.Sort(sorted)
+		fmt.Fprintln(w, "Uncovered lines:")
+		for _, rec := range sorted {
+			if ranges := report.Uncovered(rec.Blocks); ranges != "" {
+				fmt.Fprintf(w, " %s:%s\n", rec.Handle(), ranges)
+			}
+		}
+		fmt.Fprintln(w, "")
+	}
+}
+
+// proposeUpdate computes the new overall threshold, and the new
+// threshold of each per-package rule, if the coverage exceeds them by
+// more than the maximum headroom.  It returns nil if no thresholds
+// need updating.
+func proposeUpdate(coverage, threshold, minHeadroom, maxHeadroom float64, results []rules.Result) *report.Update {
+	upd := &report.Update{}
+
+	// Does the overall threshold need updating?
+	if coverage > threshold+maxHeadroom {
+		newThreshold := math.Round(coverage*10.0)/10.0 - minHeadroom
+		upd.Threshold = &newThreshold
+	}
+
+	// Now check the rules
+	rs := make([]rules.Rule, 0, len(results))
+	rulesUpdated := false
+	for _, result := range results {
+		rule := result.Rule
+		if ruleThreshold, ok := result.Ratchet(minHeadroom, maxHeadroom); ok {
+			rule.Threshold = ruleThreshold
+			rulesUpdated = true
+		}
+		rs = append(rs, rule)
+	}
+	if rulesUpdated {
+		upd.Rules = rs
+	}
+
+	// If nothing needs updating, we're done
+	if upd.Threshold == nil && upd.Rules == nil {
+		return nil
+	}
+
+	return upd
+}
+
+// applyUpdate writes the updated thresholds to the configuration
+// file.  If read-only mode is enabled, all the needed updates are
+// reported instead, and the program exits.
+func applyUpdate(upd *report.Update, results []rules.Result) {
+	// Report the updates
+	if upd.Threshold != nil {
+		if readOnly {
+			fmt.Fprintf(stderr, "\nCoverage exceeds maximum headroom.  Update threshold to %.1f%%\n", *upd.Threshold)
+		} else {
+			fmt.Fprintf(infoStream(), "Updating configuration file %s with new threshold value %.1f%%\n", config, *upd.Threshold)
+		}
+	}
+	for i, rule := range upd.Rules {
+		if rule.Threshold == results[i].Rule.Threshold {
+			continue
+		}
+		if readOnly {
+			fmt.Fprintf(stderr, "\nCoverage for rule %s exceeds maximum headroom.  Update threshold to %.1f%%\n", rule.Pattern, rule.Threshold)
+		} else {
+			fmt.Fprintf(infoStream(), "Updating configuration file %s with new threshold value %.1f%% for rule %s\n", config, rule.Threshold, rule.Pattern)
+		}
+	}
+
+	// If we're read-only, generate an error
+	if readOnly {
+		exit(5)
+	}
+
+	// OK, update the configuration
+	if upd.Threshold != nil {
+		setConfig("threshold", *upd.Threshold)
+	}
+	if upd.Rules != nil {
+		setConfig("rules", upd.Rules)
+	}
+	if err := writeConfig(config); err != nil {
+		if upd.Threshold != nil {
+			fmt.Fprintf(stderr, "\nFailed to write updated config with new threshold %.1f%% to %s: %s\n", *upd.Threshold, config, err)
+		} else {
+			fmt.Fprintf(stderr, "\nFailed to write updated config with new rule thresholds to %s: %s\n", config, err)
+		}
+		exit(5)
+	}
+}
+
 // getBuildArgDefault is a helper that retrieves the default build
 // arguments from the environment.
 func getBuildArgDefault() []string {
@@ -181,6 +383,66 @@ func getBuildArgDefault() []string {
 	return strings.Split(data, " ")
 }
 
+// getExcludeDefault is a helper that retrieves the default exclude
+// patterns from the environment.
+func getExcludeDefault() []string {
+	return strings.Fields(os.Getenv("OVERCOVER_EXCLUDE"))
+}
+
+// getCoverProfileDefault is a helper that retrieves the default
+// coverage profiles from the environment.
+func getCoverProfileDefault() []string {
+	data, ok := os.LookupEnv("OVERCOVER_COVERPROFILE")
+	if !ok || data == "" {
+		return []string{}
+	}
+
+	return filepath.SplitList(data)
+}
+
+// getCoverDirDefault is a helper that retrieves the default coverage
+// data directories from the environment.
+func getCoverDirDefault() []string {
+	data, ok := os.LookupEnv("OVERCOVER_COVERDIR")
+	if !ok || data == "" {
+		return []string{}
+	}
+
+	return filepath.SplitList(data)
+}
+
+// getLCOVDefault is a helper that retrieves the default LCOV
+// tracefiles from the environment.
+func getLCOVDefault() []string {
+	data, ok := os.LookupEnv("OVERCOVER_LCOV")
+	if !ok || data == "" {
+		return []string{}
+	}
+
+	return filepath.SplitList(data)
+}
+
+// getFormatDefault is a helper that retrieves the default report
+// format from the environment.
+func getFormatDefault() string {
+	if data := os.Getenv("OVERCOVER_FORMAT"); data != "" {
+		return data
+	}
+
+	return formatText
+}
+
+// getWorstDefault is a helper that retrieves the default number of
+// packages and files to include in a markdown report from the
+// environment.
+func getWorstDefault() int {
+	if value, err := strconv.Atoi(os.Getenv("OVERCOVER_WORST")); err == nil {
+		return value
+	}
+
+	return 10
+}
+
 // init initializes the flags for overcover.
 func init() {
 	// Initialize cobra and viper
@@ -192,22 +454,75 @@ func init() {
 	_, readOnlyDefault := os.LookupEnv("OVERCOVER_READONLY")
 	rootCmd.Flags().BoolVarP(&readOnly, "readonly", "r", readOnlyDefault, "Used to indicate that the configuration file should only be read, not written.")
 	rootCmd.Flags().Float64P("threshold", "t", 0, "Set the minimum threshold for coverage; coverage below this threshold will result in an error.")
-	rootCmd.Flags().StringVarP(&coverprofile, "coverprofile", "p", os.Getenv("OVERCOVER_COVERPROFILE"), "Specify the coverage profile file to read.")
+	rootCmd.Flags().StringArrayVarP(&coverprofile, "coverprofile", "p", getCoverProfileDefault(), "Specify a coverage profile file to read.  May be a glob pattern, and may be given multiple times; the profiles are merged.")
+	rootCmd.Flags().StringArrayVar(&coverdir, "coverdir", getCoverDirDefault(), "Specify a directory of binary coverage data files, as written to GOCOVERDIR by programs built with \"go build -cover\".  May be given multiple times; the data is merged with any coverage profiles.")
+	rootCmd.Flags().StringVar(&diffBase, "diff-base", os.Getenv("OVERCOVER_DIFF_BASE"), "Compute the coverage of the lines changed relative to the specified git revision, in addition to the overall coverage.")
+	rootCmd.Flags().Float64("patch-threshold", 0, "Set the minimum threshold for the coverage of the lines changed relative to the --diff-base revision.")
+	rootCmd.Flags().StringVarP(&format, "format", "f", getFormatDefault(), "Select the format of the report: \"text\", \"json\", or \"markdown\".")
+	rootCmd.Flags().StringVarP(&outputFile, "output", "o", os.Getenv("OVERCOVER_OUTPUT"), "Write the report to the specified file instead of to standard output.")
+	rootCmd.Flags().StringArrayVar(&tracefiles, "lcov", getLCOVDefault(), "Specify an LCOV tracefile to read, such as a coverage.dat file generated by Bazel.  May be a glob pattern, and may be given multiple times; the data is merged with any coverage profiles.")
+	rootCmd.Flags().StringVar(&lcovOutput, "lcov-output", os.Getenv("OVERCOVER_LCOV_OUTPUT"), "Also write the coverage data as an LCOV tracefile to the specified file.")
+	rootCmd.Flags().IntVar(&worst, "worst", getWorstDefault(), "Set the number of packages and files with the lowest coverage to include in a markdown report.  If zero, all are included.")
+	rootCmd.Flags().StringVar(&htmlOutput, "html", os.Getenv("OVERCOVER_HTML"), "Also write the coverage data as a self-contained HTML report to the specified file.")
+	rootCmd.Flags().StringVar(&junit, "junit", os.Getenv("OVERCOVER_JUNIT"), "Also write the results of the threshold checks as a JUnit XML report to the specified file, with each check as a test case.")
+	rootCmd.Flags().StringVar(&badge, "badge", os.Getenv("OVERCOVER_BADGE"), "Also write a badge displaying the overall coverage to the specified file, as an SVG image.")
+	rootCmd.Flags().StringVar(&badgeJSON, "badge-endpoint", os.Getenv("OVERCOVER_BADGE_ENDPOINT"), "Also write a badge displaying the overall coverage to the specified file, as a JSON document for the shields.io \"endpoint\" badge.")
+	rootCmd.Flags().Float64("badge-margin", 5.0, "Set the badge margin.  The badge is red if the coverage is below the threshold, yellow if it is less than this value above the threshold, and green otherwise.")
+	rootCmd.Flags().StringVar(&historyFile, "history", os.Getenv("OVERCOVER_HISTORY"), "Append the time, the git commit, and the overall and per-package coverage of the run to the specified history file, for use by the history subcommand.")
+	rootCmd.Flags().StringVar(&cobertura, "cobertura", os.Getenv("OVERCOVER_COBERTURA"), "Also write the coverage data as a Cobertura XML report to the specified file.")
 	rootCmd.Flags().Float64P("min-headroom", "m", 0, "Set the minimum headroom.  If the threshold is raised, it will be raised to the current coverage minus this value.")
 	rootCmd.Flags().Float64P("max-headroom", "M", 0, "Set the maximum headroom.  If the coverage is more than the threshold plus this value, the threshold will be raised.")
+	rootCmd.Flags().StringArrayVar(&excludes, "exclude", getExcludeDefault(), "Exclude the files and packages matching a pattern from the coverage.  Patterns are as for rules, and are matched against both the package and the file.  May be given multiple times, and are added to those in the configuration file.")
+	rootCmd.Flags().Bool("exclude-generated", false, "Exclude files containing generated code, marked with the standard \"Code generated ... DO NOT EDIT.\" comment, from the coverage.")
 	rootCmd.Flags().StringArrayVarP(&buildArgs, "build-arg", "b", getBuildArgDefault(), "Add a build argument.  Build arguments are used to select source files for later coverage checking.")
+	_, workspaceDefault := os.LookupEnv("OVERCOVER_WORKSPACE")
+	rootCmd.Flags().BoolVarP(&workspace, "workspace", "w", workspaceDefault, "Used to request that every module of the go.work workspace containing the current directory be checked.  The source of the specified packages is read from each module, and the per-module coverage is reported and checked against the thresholds in the configuration file.")
 	_, detailedDefault := os.LookupEnv("OVERCOVER_DETAILED")
 	rootCmd.Flags().BoolVarP(&detailed, "detailed", "d", detailedDefault, "Used to request per-file detailed coverage data be emitted.  May be used in conjunction with --summary.")
+	_, functionsDefault := os.LookupEnv("OVERCOVER_FUNCTIONS")
+	rootCmd.Flags().BoolVarP(&functions, "functions", "F", functionsDefault, "Used to request per-function coverage data be emitted.  The coverage blocks are mapped onto the functions in the source, including function literals, and the functions are listed in order of increasing coverage.")
+	_, uncoveredDefault := os.LookupEnv("OVERCOVER_UNCOVERED")
+	rootCmd.Flags().BoolVarP(&uncovered, "uncovered", "u", uncoveredDefault, "Used to request the lines of each file containing statements that were never executed be emitted, as ranges of line numbers.")
 	_, summaryDefault := os.LookupEnv("OVERCOVER_SUMMARY")
 	rootCmd.Flags().BoolVarP(&summary, "summary", "s", summaryDefault, "Used to request per-package summary coverage data be emitted.  May be used in conjunction with --detailed.")
 
 	// Bind them to viper
 	_ = viper.BindPFlag("threshold", rootCmd.Flags().Lookup("threshold"))
 	_ = viper.BindEnv("threshold")
+	_ = viper.BindPFlag("patch_threshold", rootCmd.Flags().Lookup("patch-threshold"))
+	_ = viper.BindEnv("patch_threshold")
 	_ = viper.BindPFlag("min_headroom", rootCmd.Flags().Lookup("min-headroom"))
 	_ = viper.BindEnv("min_headroom")
 	_ = viper.BindPFlag("max_headroom", rootCmd.Flags().Lookup("max-headroom"))
 	_ = viper.BindEnv("max_headroom")
+	_ = viper.BindPFlag("exclude_generated", rootCmd.Flags().Lookup("exclude-generated"))
+	_ = viper.BindEnv("exclude_generated")
+	_ = viper.BindPFlag("badge_margin", rootCmd.Flags().Lookup("badge-margin"))
+	_ = viper.BindEnv("badge_margin")
+}
+
+// writeConfigFile writes the configuration file, updated with the
+// threshold and rules of the settings.  The file is written from its
+// own contents rather than from all the settings, so that the values
+// of flags and environment variables are not persisted into it; in
+// particular, the headroom is only written if the file already set
+// it.  If the file exists but cannot be read, it is left untouched
+// and an error is returned.
+func writeConfigFile(fname string) error {
+	// Start from the existing contents of the file, if any
+	v := viper.New()
+	v.SetConfigFile(fname)
+	if err := v.ReadInConfig(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	// Add the keys the update owns
+	v.Set("threshold", settings.GetFloat64("threshold"))
+	if settings.IsSet("rules") {
+		v.Set("rules", settings.Get("rules"))
+	}
+
+	return v.WriteConfigAs(fname)
 }
 
 // readConfig reads the configuration file using Viper.
@@ -222,6 +537,6 @@ func readConfig() {
 
 	// Read the configuration
 	if err := readInConfig(); err == nil {
-		fmt.Fprintf(stdout, "Using configuration file %s\n", configFileUsed())
+		fmt.Fprintf(infoStream(), "Using configuration file %s\n", configFileUsed())
 	}
 }
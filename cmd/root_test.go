@@ -17,13 +17,23 @@ package cmd
 import (
 	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/klmitch/patcher"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/diff"
+	"github.com/klmitch/overcover/modules"
+	"github.com/klmitch/overcover/report"
+	"github.com/klmitch/overcover/rules"
 )
 
 func TestRootCmdBase(t *testing.T) {
@@ -56,8 +66,10 @@ func TestRootCmdBase(t *testing.T) {
 			writeConfigCalled = true
 			return nil
 		}),
-		patcher.SetVar(&loadCoverage, func(filename string) (common.DataSet, error) {
-			assert.Equal(t, "coverage.out", filename)
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
 			loadCoverageCalled = true
 			return common.DataSet{
 				common.FileData{
@@ -78,15 +90,16 @@ func TestRootCmdBase(t *testing.T) {
 					Count:   4,
 					Exec:    4,
 				},
-			}, nil
+			}, nil, nil
 		}),
-		patcher.SetVar(&loadStatements, func(ba, args []string) (common.DataSet, error) {
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
 			assert.Equal(t, []string{}, ba)
 			assert.Equal(t, []string{}, args)
 			loadStatementsCalled = true
 			return common.DataSet{}, nil
 		}),
-		patcher.SetVar(&coverprofile, "coverage.out"),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
 	).Install().Restore()
 
 	rootCmd.Run(rootCmd, []string{})
@@ -129,8 +142,10 @@ func TestRootCmdStatementsOnly(t *testing.T) {
 			writeConfigCalled = true
 			return nil
 		}),
-		patcher.SetVar(&loadCoverage, func(filename string) (common.DataSet, error) {
-			assert.Equal(t, "coverage.out", filename)
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
 			loadCoverageCalled = true
 			return common.DataSet{
 				common.FileData{
@@ -151,9 +166,10 @@ func TestRootCmdStatementsOnly(t *testing.T) {
 					Count:   4,
 					Exec:    4,
 				},
-			}, nil
+			}, nil, nil
 		}),
-		patcher.SetVar(&loadStatements, func(ba, args []string) (common.DataSet, error) {
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
 			assert.Equal(t, []string{}, ba)
 			assert.Equal(t, []string{"./..."}, args)
 			loadStatementsCalled = true
@@ -175,7 +191,7 @@ func TestRootCmdStatementsOnly(t *testing.T) {
 				},
 			}, nil
 		}),
-		patcher.SetVar(&coverprofile, ""),
+		patcher.SetVar(&coverprofile, []string{}),
 	).Install().Restore()
 
 	rootCmd.Run(rootCmd, []string{"./..."})
@@ -218,8 +234,10 @@ func TestRootCmdStatements(t *testing.T) {
 			writeConfigCalled = true
 			return nil
 		}),
-		patcher.SetVar(&loadCoverage, func(filename string) (common.DataSet, error) {
-			assert.Equal(t, "coverage.out", filename)
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
 			loadCoverageCalled = true
 			return common.DataSet{
 				common.FileData{
@@ -240,9 +258,10 @@ func TestRootCmdStatements(t *testing.T) {
 					Count:   4,
 					Exec:    4,
 				},
-			}, nil
+			}, nil, nil
 		}),
-		patcher.SetVar(&loadStatements, func(ba, args []string) (common.DataSet, error) {
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
 			assert.Equal(t, []string{}, ba)
 			assert.Equal(t, []string{"./..."}, args)
 			loadStatementsCalled = true
@@ -264,7 +283,7 @@ func TestRootCmdStatements(t *testing.T) {
 				},
 			}, nil
 		}),
-		patcher.SetVar(&coverprofile, "coverage.out"),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
 	).Install().Restore()
 
 	rootCmd.Run(rootCmd, []string{"./..."})
@@ -307,8 +326,10 @@ func TestRootCmdStatementsBuildArgs(t *testing.T) {
 			writeConfigCalled = true
 			return nil
 		}),
-		patcher.SetVar(&loadCoverage, func(filename string) (common.DataSet, error) {
-			assert.Equal(t, "coverage.out", filename)
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
 			loadCoverageCalled = true
 			return common.DataSet{
 				common.FileData{
@@ -329,9 +350,10 @@ func TestRootCmdStatementsBuildArgs(t *testing.T) {
 					Count:   4,
 					Exec:    4,
 				},
-			}, nil
+			}, nil, nil
 		}),
-		patcher.SetVar(&loadStatements, func(ba, args []string) (common.DataSet, error) {
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
 			assert.Equal(t, []string{"arg1", "arg2", "arg3"}, ba)
 			assert.Equal(t, []string{"./..."}, args)
 			loadStatementsCalled = true
@@ -353,7 +375,7 @@ func TestRootCmdStatementsBuildArgs(t *testing.T) {
 				},
 			}, nil
 		}),
-		patcher.SetVar(&coverprofile, "coverage.out"),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
 		patcher.SetVar(&buildArgs, []string{"arg1", "arg2", "arg3"}),
 	).Install().Restore()
 
@@ -397,8 +419,10 @@ func TestRootCmdStatementsExtra(t *testing.T) {
 			writeConfigCalled = true
 			return nil
 		}),
-		patcher.SetVar(&loadCoverage, func(filename string) (common.DataSet, error) {
-			assert.Equal(t, "coverage.out", filename)
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
 			loadCoverageCalled = true
 			return common.DataSet{
 				common.FileData{
@@ -419,9 +443,10 @@ func TestRootCmdStatementsExtra(t *testing.T) {
 					Count:   4,
 					Exec:    4,
 				},
-			}, nil
+			}, nil, nil
 		}),
-		patcher.SetVar(&loadStatements, func(ba, args []string) (common.DataSet, error) {
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
 			assert.Equal(t, []string{}, ba)
 			assert.Equal(t, []string{"./..."}, args)
 			loadStatementsCalled = true
@@ -443,7 +468,7 @@ func TestRootCmdStatementsExtra(t *testing.T) {
 				},
 			}, nil
 		}),
-		patcher.SetVar(&coverprofile, "coverage.out"),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
 	).Install().Restore()
 
 	rootCmd.Run(rootCmd, []string{"./..."})
@@ -486,8 +511,10 @@ func TestRootCmdStatementsConflict(t *testing.T) {
 			writeConfigCalled = true
 			return nil
 		}),
-		patcher.SetVar(&loadCoverage, func(filename string) (common.DataSet, error) {
-			assert.Equal(t, "coverage.out", filename)
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
 			loadCoverageCalled = true
 			return common.DataSet{
 				common.FileData{
@@ -508,9 +535,10 @@ func TestRootCmdStatementsConflict(t *testing.T) {
 					Count:   4,
 					Exec:    4,
 				},
-			}, nil
+			}, nil, nil
 		}),
-		patcher.SetVar(&loadStatements, func(ba, args []string) (common.DataSet, error) {
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
 			assert.Equal(t, []string{}, ba)
 			assert.Equal(t, []string{"./..."}, args)
 			loadStatementsCalled = true
@@ -532,7 +560,7 @@ func TestRootCmdStatementsConflict(t *testing.T) {
 				},
 			}, nil
 		}),
-		patcher.SetVar(&coverprofile, "coverage.out"),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
 	).Install().Restore()
 
 	rootCmd.Run(rootCmd, []string{"./..."})
@@ -545,7 +573,7 @@ func TestRootCmdStatementsConflict(t *testing.T) {
 	assert.True(t, loadStatementsCalled)
 }
 
-func TestRootCmdNoProfile(t *testing.T) {
+func TestRootCmdProfileConflict(t *testing.T) {
 	outStream := &bytes.Buffer{}
 	errStream := &bytes.Buffer{}
 	values := map[string]float64{
@@ -575,8 +603,8 @@ func TestRootCmdNoProfile(t *testing.T) {
 			writeConfigCalled = true
 			return nil
 		}),
-		patcher.SetVar(&loadCoverage, func(filename string) (common.DataSet, error) {
-			assert.Equal(t, "coverage.out", filename)
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"unit.out", "e2e.out"}, filenames)
 			loadCoverageCalled = true
 			return common.DataSet{
 				common.FileData{
@@ -585,39 +613,42 @@ func TestRootCmdNoProfile(t *testing.T) {
 					Count:   10,
 					Exec:    10,
 				},
-				common.FileData{
-					Package: "some/package",
-					Name:    "file2.go",
-					Count:   5,
-					Exec:    5,
-				},
 				common.FileData{
 					Package: "other/package",
 					Name:    "file3.go",
 					Count:   4,
 					Exec:    4,
 				},
+			}, common.DataSet{
+				common.FileData{
+					Package: "other/package",
+					Name:    "file3.go",
+					Count:   5,
+					Exec:    1,
+				},
 			}, nil
 		}),
-		patcher.SetVar(&loadStatements, func(ba, args []string) (common.DataSet, error) {
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
 			assert.Equal(t, []string{}, ba)
 			assert.Equal(t, []string{}, args)
 			loadStatementsCalled = true
 			return common.DataSet{}, nil
 		}),
-		patcher.SetVar(&coverprofile, ""),
+		patcher.SetVar(&coverprofile, []string{"unit.out", "e2e.out"}),
 	).Install().Restore()
 
-	assert.PanicsWithValue(t, "os.Exit(2)", func() { rootCmd.Run(rootCmd, []string{}) })
-	assert.Equal(t, "", outStream.String())
-	assert.Equal(t, "No coverage profile file specified!  Use -p or provide a configuration file.\n", errStream.String())
+	rootCmd.Run(rootCmd, []string{})
+
+	assert.Equal(t, "14 statements out of 14 covered; overall coverage: 100.0%\n", outStream.String())
+	assert.Equal(t, "WARNING: coverage profiles unit.out, e2e.out have mismatched blocks; ignored data for files:\n  other/package/file3.go\n", errStream.String())
 	assert.False(t, setConfigCalled)
 	assert.False(t, writeConfigCalled)
-	assert.False(t, loadCoverageCalled)
+	assert.True(t, loadCoverageCalled)
 	assert.False(t, loadStatementsCalled)
 }
 
-func TestRootCmdLoadCoverageFails(t *testing.T) {
+func TestRootCmdCoverDir(t *testing.T) {
 	outStream := &bytes.Buffer{}
 	errStream := &bytes.Buffer{}
 	values := map[string]float64{
@@ -647,30 +678,54 @@ func TestRootCmdLoadCoverageFails(t *testing.T) {
 			writeConfigCalled = true
 			return nil
 		}),
-		patcher.SetVar(&loadCoverage, func(filename string) (common.DataSet, error) {
-			assert.Equal(t, "coverage.out", filename)
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{}, filenames)
+			assert.Equal(t, []string{"covdir1", "covdir2"}, dirs)
+			assert.Equal(t, []string{}, tfs)
 			loadCoverageCalled = true
-			return nil, assert.AnError
+			return common.DataSet{
+				common.FileData{
+					Package: "some/package",
+					Name:    "file1.go",
+					Count:   10,
+					Exec:    10,
+				},
+				common.FileData{
+					Package: "other/package",
+					Name:    "file3.go",
+					Count:   4,
+					Exec:    4,
+				},
+			}, common.DataSet{
+				common.FileData{
+					Package: "other/package",
+					Name:    "file3.go",
+					Count:   5,
+					Exec:    1,
+				},
+			}, nil
 		}),
-		patcher.SetVar(&loadStatements, func(ba, args []string) (common.DataSet, error) {
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
 			assert.Equal(t, []string{}, ba)
 			assert.Equal(t, []string{}, args)
 			loadStatementsCalled = true
 			return common.DataSet{}, nil
 		}),
-		patcher.SetVar(&coverprofile, "coverage.out"),
+		patcher.SetVar(&coverprofile, []string{}),
+		patcher.SetVar(&coverdir, []string{"covdir1", "covdir2"}),
 	).Install().Restore()
 
-	assert.PanicsWithValue(t, "os.Exit(3)", func() { rootCmd.Run(rootCmd, []string{}) })
-	assert.Equal(t, "", outStream.String())
-	assert.Equal(t, fmt.Sprintf("Unable to read coverage profile file \"coverage.out\": %s\n", assert.AnError), errStream.String())
+	rootCmd.Run(rootCmd, []string{})
+
+	assert.Equal(t, "14 statements out of 14 covered; overall coverage: 100.0%\n", outStream.String())
+	assert.Equal(t, "WARNING: coverage profiles covdir1, covdir2 have mismatched blocks; ignored data for files:\n  other/package/file3.go\n", errStream.String())
 	assert.False(t, setConfigCalled)
 	assert.False(t, writeConfigCalled)
 	assert.True(t, loadCoverageCalled)
 	assert.False(t, loadStatementsCalled)
 }
-
-func TestRootCmdLoadStatementsFails(t *testing.T) {
+func TestRootCmdLCOV(t *testing.T) {
 	outStream := &bytes.Buffer{}
 	errStream := &bytes.Buffer{}
 	values := map[string]float64{
@@ -700,8 +755,10 @@ func TestRootCmdLoadStatementsFails(t *testing.T) {
 			writeConfigCalled = true
 			return nil
 		}),
-		patcher.SetVar(&loadCoverage, func(filename string) (common.DataSet, error) {
-			assert.Equal(t, "coverage.out", filename)
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{"coverage.dat"}, tfs)
 			loadCoverageCalled = true
 			return common.DataSet{
 				common.FileData{
@@ -710,39 +767,104 @@ func TestRootCmdLoadStatementsFails(t *testing.T) {
 					Count:   10,
 					Exec:    10,
 				},
-				common.FileData{
-					Package: "some/package",
-					Name:    "file2.go",
-					Count:   5,
-					Exec:    5,
-				},
 				common.FileData{
 					Package: "other/package",
 					Name:    "file3.go",
 					Count:   4,
 					Exec:    4,
 				},
+			}, common.DataSet{
+				common.FileData{
+					Package: "other/package",
+					Name:    "file3.go",
+					Count:   5,
+					Exec:    1,
+				},
 			}, nil
 		}),
-		patcher.SetVar(&loadStatements, func(ba, args []string) (common.DataSet, error) {
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
 			assert.Equal(t, []string{}, ba)
-			assert.Equal(t, []string{"./..."}, args)
+			assert.Equal(t, []string{}, args)
 			loadStatementsCalled = true
-			return nil, assert.AnError
+			return common.DataSet{}, nil
 		}),
-		patcher.SetVar(&coverprofile, "coverage.out"),
+		patcher.SetVar(&coverprofile, []string{}),
+		patcher.SetVar(&tracefiles, []string{"coverage.dat"}),
 	).Install().Restore()
 
-	assert.PanicsWithValue(t, "os.Exit(3)", func() { rootCmd.Run(rootCmd, []string{"./..."}) })
-	assert.Equal(t, "", outStream.String())
-	assert.Equal(t, fmt.Sprintf("Unable to read source: %s\n", assert.AnError), errStream.String())
+	rootCmd.Run(rootCmd, []string{})
+
+	assert.Equal(t, "14 statements out of 14 covered; overall coverage: 100.0%\n", outStream.String())
+	assert.Equal(t, "WARNING: coverage profiles coverage.dat have mismatched blocks; ignored data for files:\n  other/package/file3.go\n", errStream.String())
 	assert.False(t, setConfigCalled)
 	assert.False(t, writeConfigCalled)
 	assert.True(t, loadCoverageCalled)
-	assert.True(t, loadStatementsCalled)
+	assert.False(t, loadStatementsCalled)
+}
+func TestRootCmdDirectives(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	values := map[string]float64{
+		"threshold":    0.0,
+		"min_headroom": 0.0,
+		"max_headroom": 0.0,
+	}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&getFloat64, func(name string) float64 {
+			value, ok := values[name]
+			assert.True(t, ok)
+			return value
+		}),
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			return common.DataSet{
+				common.FileData{
+					Package: "example.com/mod",
+					Name:    "file1.go",
+					Count:   10,
+					Exec:    5,
+				},
+			}, nil, nil
+		}),
+		patcher.SetVar(&findModule, func(dir string) (string, string, error) {
+			assert.Equal(t, ".", dir)
+			return "/src/mod", "example.com/mod", nil
+		}),
+		patcher.SetVar(&applyDirectives, func(ds common.DataSet, modRoot, modPath string) common.DataSet {
+			assert.Equal(t, common.DataSet{
+				common.FileData{
+					Package: "example.com/mod",
+					Name:    "file1.go",
+					Count:   10,
+					Exec:    5,
+				},
+			}, ds)
+			assert.Equal(t, "/src/mod", modRoot)
+			assert.Equal(t, "example.com/mod", modPath)
+			return common.DataSet{
+				common.FileData{
+					Package: "example.com/mod",
+					Name:    "file1.go",
+					Count:   8,
+					Exec:    5,
+				},
+			}
+		}),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
+	).Install().Restore()
+
+	rootCmd.Run(rootCmd, []string{})
+
+	assert.Equal(t, "5 statements out of 8 covered; overall coverage: 62.5%\n", outStream.String())
+	assert.Equal(t, "", errStream.String())
 }
 
-func TestRootCmdDetailed(t *testing.T) {
+func TestRootCmdNoProfile(t *testing.T) {
 	outStream := &bytes.Buffer{}
 	errStream := &bytes.Buffer{}
 	values := map[string]float64{
@@ -772,8 +894,10 @@ func TestRootCmdDetailed(t *testing.T) {
 			writeConfigCalled = true
 			return nil
 		}),
-		patcher.SetVar(&loadCoverage, func(filename string) (common.DataSet, error) {
-			assert.Equal(t, "coverage.out", filename)
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
 			loadCoverageCalled = true
 			return common.DataSet{
 				common.FileData{
@@ -794,38 +918,28 @@ func TestRootCmdDetailed(t *testing.T) {
 					Count:   4,
 					Exec:    4,
 				},
-			}, nil
+			}, nil, nil
 		}),
-		patcher.SetVar(&loadStatements, func(ba, args []string) (common.DataSet, error) {
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
 			assert.Equal(t, []string{}, ba)
 			assert.Equal(t, []string{}, args)
 			loadStatementsCalled = true
 			return common.DataSet{}, nil
 		}),
-		patcher.SetVar(&coverprofile, "coverage.out"),
-		patcher.SetVar(&detailed, true),
+		patcher.SetVar(&coverprofile, []string{}),
 	).Install().Restore()
 
-	rootCmd.Run(rootCmd, []string{})
-
-	assert.Equal(t, "Per file details:\n"+
-		" File                    Executed  Total  Coverage\n"+
-		" ----                    --------  -----  --------\n"+
-		" other/package/file3.go  4         4      100.0%\n"+
-		" some/package/file1.go   10        10     100.0%\n"+
-		" some/package/file2.go   5         5      100.0%\n"+
-		"\n"+
-		"19 statements out of 19 covered; overall coverage: 100.0%\n",
-		outStream.String(),
-	)
-	assert.Equal(t, "", errStream.String())
+	assert.PanicsWithValue(t, "os.Exit(2)", func() { rootCmd.Run(rootCmd, []string{}) })
+	assert.Equal(t, "", outStream.String())
+	assert.Equal(t, "No coverage profile file specified!  Use -p, --coverdir, or --lcov, or provide a configuration file.\n", errStream.String())
 	assert.False(t, setConfigCalled)
 	assert.False(t, writeConfigCalled)
-	assert.True(t, loadCoverageCalled)
+	assert.False(t, loadCoverageCalled)
 	assert.False(t, loadStatementsCalled)
 }
 
-func TestRootCmdSummary(t *testing.T) {
+func TestRootCmdLoadCoverageFails(t *testing.T) {
 	outStream := &bytes.Buffer{}
 	errStream := &bytes.Buffer{}
 	values := map[string]float64{
@@ -855,59 +969,33 @@ func TestRootCmdSummary(t *testing.T) {
 			writeConfigCalled = true
 			return nil
 		}),
-		patcher.SetVar(&loadCoverage, func(filename string) (common.DataSet, error) {
-			assert.Equal(t, "coverage.out", filename)
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
 			loadCoverageCalled = true
-			return common.DataSet{
-				common.FileData{
-					Package: "some/package",
-					Name:    "file1.go",
-					Count:   10,
-					Exec:    10,
-				},
-				common.FileData{
-					Package: "some/package",
-					Name:    "file2.go",
-					Count:   5,
-					Exec:    5,
-				},
-				common.FileData{
-					Package: "other/package",
-					Name:    "file3.go",
-					Count:   4,
-					Exec:    4,
-				},
-			}, nil
+			return nil, nil, assert.AnError
 		}),
-		patcher.SetVar(&loadStatements, func(ba, args []string) (common.DataSet, error) {
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
 			assert.Equal(t, []string{}, ba)
 			assert.Equal(t, []string{}, args)
 			loadStatementsCalled = true
 			return common.DataSet{}, nil
 		}),
-		patcher.SetVar(&coverprofile, "coverage.out"),
-		patcher.SetVar(&summary, true),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
 	).Install().Restore()
 
-	rootCmd.Run(rootCmd, []string{})
-
-	assert.Equal(t, "Per package summary:\n"+
-		" Package         Executed  Total  Coverage\n"+
-		" -------         --------  -----  --------\n"+
-		" other/package/  4         4      100.0%\n"+
-		" some/package/   15        15     100.0%\n"+
-		"\n"+
-		"19 statements out of 19 covered; overall coverage: 100.0%\n",
-		outStream.String(),
-	)
-	assert.Equal(t, "", errStream.String())
+	assert.PanicsWithValue(t, "os.Exit(3)", func() { rootCmd.Run(rootCmd, []string{}) })
+	assert.Equal(t, "", outStream.String())
+	assert.Equal(t, fmt.Sprintf("Unable to read coverage profile file %s\n", assert.AnError), errStream.String())
 	assert.False(t, setConfigCalled)
 	assert.False(t, writeConfigCalled)
 	assert.True(t, loadCoverageCalled)
 	assert.False(t, loadStatementsCalled)
 }
 
-func TestRootCmdLowCoverageBase(t *testing.T) {
+func TestRootCmdLoadStatementsFails(t *testing.T) {
 	outStream := &bytes.Buffer{}
 	errStream := &bytes.Buffer{}
 	values := map[string]float64{
@@ -937,14 +1025,17 @@ func TestRootCmdLowCoverageBase(t *testing.T) {
 			writeConfigCalled = true
 			return nil
 		}),
-		patcher.SetVar(&loadCoverage, func(filename string) (common.DataSet, error) {
-			assert.Equal(t, "coverage.out", filename)
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
 			loadCoverageCalled = true
 			return common.DataSet{
 				common.FileData{
 					Package: "some/package",
 					Name:    "file1.go",
 					Count:   10,
+					Exec:    10,
 				},
 				common.FileData{
 					Package: "some/package",
@@ -956,33 +1047,34 @@ func TestRootCmdLowCoverageBase(t *testing.T) {
 					Package: "other/package",
 					Name:    "file3.go",
 					Count:   4,
+					Exec:    4,
 				},
-			}, nil
+			}, nil, nil
 		}),
-		patcher.SetVar(&loadStatements, func(ba, args []string) (common.DataSet, error) {
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
 			assert.Equal(t, []string{}, ba)
-			assert.Equal(t, []string{}, args)
+			assert.Equal(t, []string{"./..."}, args)
 			loadStatementsCalled = true
-			return common.DataSet{}, nil
+			return nil, assert.AnError
 		}),
-		patcher.SetVar(&coverprofile, "coverage.out"),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
 	).Install().Restore()
 
-	rootCmd.Run(rootCmd, []string{})
-
-	assert.Equal(t, "5 statements out of 19 covered; overall coverage: 26.3%\n", outStream.String())
-	assert.Equal(t, "", errStream.String())
+	assert.PanicsWithValue(t, "os.Exit(3)", func() { rootCmd.Run(rootCmd, []string{"./..."}) })
+	assert.Equal(t, "", outStream.String())
+	assert.Equal(t, fmt.Sprintf("Unable to read source: %s\n", assert.AnError), errStream.String())
 	assert.False(t, setConfigCalled)
 	assert.False(t, writeConfigCalled)
 	assert.True(t, loadCoverageCalled)
-	assert.False(t, loadStatementsCalled)
+	assert.True(t, loadStatementsCalled)
 }
 
-func TestRootCmdLowCoverageLowThreshold(t *testing.T) {
+func TestRootCmdDetailed(t *testing.T) {
 	outStream := &bytes.Buffer{}
 	errStream := &bytes.Buffer{}
 	values := map[string]float64{
-		"threshold":    25.0,
+		"threshold":    0.0,
 		"min_headroom": 0.0,
 		"max_headroom": 0.0,
 	}
@@ -1008,14 +1100,17 @@ func TestRootCmdLowCoverageLowThreshold(t *testing.T) {
 			writeConfigCalled = true
 			return nil
 		}),
-		patcher.SetVar(&loadCoverage, func(filename string) (common.DataSet, error) {
-			assert.Equal(t, "coverage.out", filename)
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
 			loadCoverageCalled = true
 			return common.DataSet{
 				common.FileData{
 					Package: "some/package",
 					Name:    "file1.go",
 					Count:   10,
+					Exec:    10,
 				},
 				common.FileData{
 					Package: "some/package",
@@ -1027,21 +1122,33 @@ func TestRootCmdLowCoverageLowThreshold(t *testing.T) {
 					Package: "other/package",
 					Name:    "file3.go",
 					Count:   4,
+					Exec:    4,
 				},
-			}, nil
+			}, nil, nil
 		}),
-		patcher.SetVar(&loadStatements, func(ba, args []string) (common.DataSet, error) {
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
 			assert.Equal(t, []string{}, ba)
 			assert.Equal(t, []string{}, args)
 			loadStatementsCalled = true
 			return common.DataSet{}, nil
 		}),
-		patcher.SetVar(&coverprofile, "coverage.out"),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
+		patcher.SetVar(&detailed, true),
 	).Install().Restore()
 
 	rootCmd.Run(rootCmd, []string{})
 
-	assert.Equal(t, "5 statements out of 19 covered; overall coverage: 26.3%\n", outStream.String())
+	assert.Equal(t, "Per file details:\n"+
+		" File                    Executed  Total  Coverage\n"+
+		" ----                    --------  -----  --------\n"+
+		" other/package/file3.go  4         4      100.0%\n"+
+		" some/package/file1.go   10        10     100.0%\n"+
+		" some/package/file2.go   5         5      100.0%\n"+
+		"\n"+
+		"19 statements out of 19 covered; overall coverage: 100.0%\n",
+		outStream.String(),
+	)
 	assert.Equal(t, "", errStream.String())
 	assert.False(t, setConfigCalled)
 	assert.False(t, writeConfigCalled)
@@ -1049,11 +1156,11 @@ func TestRootCmdLowCoverageLowThreshold(t *testing.T) {
 	assert.False(t, loadStatementsCalled)
 }
 
-func TestRootCmdLowCoverageHighThreshold(t *testing.T) {
+func TestRootCmdUncovered(t *testing.T) {
 	outStream := &bytes.Buffer{}
 	errStream := &bytes.Buffer{}
 	values := map[string]float64{
-		"threshold":    50.0,
+		"threshold":    0.0,
 		"min_headroom": 0.0,
 		"max_headroom": 0.0,
 	}
@@ -1079,14 +1186,23 @@ func TestRootCmdLowCoverageHighThreshold(t *testing.T) {
 			writeConfigCalled = true
 			return nil
 		}),
-		patcher.SetVar(&loadCoverage, func(filename string) (common.DataSet, error) {
-			assert.Equal(t, "coverage.out", filename)
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
 			loadCoverageCalled = true
 			return common.DataSet{
 				common.FileData{
 					Package: "some/package",
 					Name:    "file1.go",
 					Count:   10,
+					Exec:    6,
+					Blocks: []common.Block{
+						{StartLine: 3, StartCol: 2, EndLine: 5, EndCol: 10, NumStmt: 6, Count: 1},
+						{StartLine: 6, StartCol: 3, EndLine: 8, EndCol: 1, NumStmt: 2},
+						{StartLine: 10, StartCol: 2, EndLine: 10, EndCol: 10, NumStmt: 1},
+						{StartLine: 12, StartCol: 2, EndLine: 12, EndCol: 10, NumStmt: 1},
+					},
 				},
 				common.FileData{
 					Package: "some/package",
@@ -1098,34 +1214,46 @@ func TestRootCmdLowCoverageHighThreshold(t *testing.T) {
 					Package: "other/package",
 					Name:    "file3.go",
 					Count:   4,
+					Exec:    4,
+					Blocks: []common.Block{
+						{StartLine: 3, StartCol: 2, EndLine: 5, EndCol: 10, NumStmt: 4, Count: 1},
+					},
 				},
-			}, nil
+			}, nil, nil
 		}),
-		patcher.SetVar(&loadStatements, func(ba, args []string) (common.DataSet, error) {
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
 			assert.Equal(t, []string{}, ba)
 			assert.Equal(t, []string{}, args)
 			loadStatementsCalled = true
 			return common.DataSet{}, nil
 		}),
-		patcher.SetVar(&coverprofile, "coverage.out"),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
+		patcher.SetVar(&uncovered, true),
 	).Install().Restore()
 
-	assert.PanicsWithValue(t, "os.Exit(1)", func() { rootCmd.Run(rootCmd, []string{}) })
-	assert.Equal(t, "5 statements out of 19 covered; overall coverage: 26.3%\n", outStream.String())
-	assert.Equal(t, "\nFailed to meet coverage threshold of 50.0%\n", errStream.String())
+	rootCmd.Run(rootCmd, []string{})
+
+	assert.Equal(t, "Uncovered lines:\n"+
+		" some/package/file1.go:6-12\n"+
+		"\n"+
+		"15 statements out of 19 covered; overall coverage: 78.9%\n",
+		outStream.String(),
+	)
+	assert.Equal(t, "", errStream.String())
 	assert.False(t, setConfigCalled)
 	assert.False(t, writeConfigCalled)
 	assert.True(t, loadCoverageCalled)
 	assert.False(t, loadStatementsCalled)
 }
 
-func TestRootCmdUpdateNeededNoConfig(t *testing.T) {
+func TestRootCmdSummary(t *testing.T) {
 	outStream := &bytes.Buffer{}
 	errStream := &bytes.Buffer{}
 	values := map[string]float64{
-		"threshold":    75.0,
-		"min_headroom": 1.0,
-		"max_headroom": 2.0,
+		"threshold":    0.0,
+		"min_headroom": 0.0,
+		"max_headroom": 0.0,
 	}
 	setConfigCalled := false
 	writeConfigCalled := false
@@ -1149,8 +1277,10 @@ func TestRootCmdUpdateNeededNoConfig(t *testing.T) {
 			writeConfigCalled = true
 			return nil
 		}),
-		patcher.SetVar(&loadCoverage, func(filename string) (common.DataSet, error) {
-			assert.Equal(t, "coverage.out", filename)
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
 			loadCoverageCalled = true
 			return common.DataSet{
 				common.FileData{
@@ -1171,20 +1301,30 @@ func TestRootCmdUpdateNeededNoConfig(t *testing.T) {
 					Count:   4,
 					Exec:    4,
 				},
-			}, nil
+			}, nil, nil
 		}),
-		patcher.SetVar(&loadStatements, func(ba, args []string) (common.DataSet, error) {
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
 			assert.Equal(t, []string{}, ba)
 			assert.Equal(t, []string{}, args)
 			loadStatementsCalled = true
 			return common.DataSet{}, nil
 		}),
-		patcher.SetVar(&coverprofile, "coverage.out"),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
+		patcher.SetVar(&summary, true),
 	).Install().Restore()
 
 	rootCmd.Run(rootCmd, []string{})
 
-	assert.Equal(t, "19 statements out of 19 covered; overall coverage: 100.0%\n", outStream.String())
+	assert.Equal(t, "Per package summary:\n"+
+		" Package         Executed  Total  Coverage\n"+
+		" -------         --------  -----  --------\n"+
+		" other/package/  4         4      100.0%\n"+
+		" some/package/   15        15     100.0%\n"+
+		"\n"+
+		"19 statements out of 19 covered; overall coverage: 100.0%\n",
+		outStream.String(),
+	)
 	assert.Equal(t, "", errStream.String())
 	assert.False(t, setConfigCalled)
 	assert.False(t, writeConfigCalled)
@@ -1192,13 +1332,13 @@ func TestRootCmdUpdateNeededNoConfig(t *testing.T) {
 	assert.False(t, loadStatementsCalled)
 }
 
-func TestRootCmdUpdateNeededWithConfigReadOnly(t *testing.T) {
+func TestRootCmdLowCoverageBase(t *testing.T) {
 	outStream := &bytes.Buffer{}
 	errStream := &bytes.Buffer{}
 	values := map[string]float64{
-		"threshold":    75.0,
-		"min_headroom": 1.0,
-		"max_headroom": 2.0,
+		"threshold":    0.0,
+		"min_headroom": 0.0,
+		"max_headroom": 0.0,
 	}
 	setConfigCalled := false
 	writeConfigCalled := false
@@ -1207,8 +1347,6 @@ func TestRootCmdUpdateNeededWithConfigReadOnly(t *testing.T) {
 	defer patcher.NewPatchMaster(
 		patcher.SetVar(&stdout, outStream),
 		patcher.SetVar(&stderr, errStream),
-		patcher.SetVar(&config, "test.yaml"),
-		patcher.SetVar(&readOnly, true),
 		patcher.SetVar(&exit, func(code int) {
 			panic(fmt.Sprintf("os.Exit(%d)", code))
 		}),
@@ -1224,15 +1362,766 @@ func TestRootCmdUpdateNeededWithConfigReadOnly(t *testing.T) {
 			writeConfigCalled = true
 			return nil
 		}),
-		patcher.SetVar(&loadCoverage, func(filename string) (common.DataSet, error) {
-			assert.Equal(t, "coverage.out", filename)
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
 			loadCoverageCalled = true
 			return common.DataSet{
 				common.FileData{
 					Package: "some/package",
 					Name:    "file1.go",
 					Count:   10,
-					Exec:    10,
+				},
+				common.FileData{
+					Package: "some/package",
+					Name:    "file2.go",
+					Count:   5,
+					Exec:    5,
+				},
+				common.FileData{
+					Package: "other/package",
+					Name:    "file3.go",
+					Count:   4,
+				},
+			}, nil, nil
+		}),
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
+			assert.Equal(t, []string{}, ba)
+			assert.Equal(t, []string{}, args)
+			loadStatementsCalled = true
+			return common.DataSet{}, nil
+		}),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
+	).Install().Restore()
+
+	rootCmd.Run(rootCmd, []string{})
+
+	assert.Equal(t, "5 statements out of 19 covered; overall coverage: 26.3%\n", outStream.String())
+	assert.Equal(t, "", errStream.String())
+	assert.False(t, setConfigCalled)
+	assert.False(t, writeConfigCalled)
+	assert.True(t, loadCoverageCalled)
+	assert.False(t, loadStatementsCalled)
+}
+
+func TestRootCmdLowCoverageLowThreshold(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	values := map[string]float64{
+		"threshold":    25.0,
+		"min_headroom": 0.0,
+		"max_headroom": 0.0,
+	}
+	setConfigCalled := false
+	writeConfigCalled := false
+	loadCoverageCalled := false
+	loadStatementsCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&getFloat64, func(name string) float64 {
+			value, ok := values[name]
+			assert.True(t, ok)
+			return value
+		}),
+		patcher.SetVar(&setConfig, func(_ string, _ interface{}) {
+			setConfigCalled = true
+		}),
+		patcher.SetVar(&writeConfig, func(_ string) error {
+			writeConfigCalled = true
+			return nil
+		}),
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
+			loadCoverageCalled = true
+			return common.DataSet{
+				common.FileData{
+					Package: "some/package",
+					Name:    "file1.go",
+					Count:   10,
+				},
+				common.FileData{
+					Package: "some/package",
+					Name:    "file2.go",
+					Count:   5,
+					Exec:    5,
+				},
+				common.FileData{
+					Package: "other/package",
+					Name:    "file3.go",
+					Count:   4,
+				},
+			}, nil, nil
+		}),
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
+			assert.Equal(t, []string{}, ba)
+			assert.Equal(t, []string{}, args)
+			loadStatementsCalled = true
+			return common.DataSet{}, nil
+		}),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
+	).Install().Restore()
+
+	rootCmd.Run(rootCmd, []string{})
+
+	assert.Equal(t, "5 statements out of 19 covered; overall coverage: 26.3%\n", outStream.String())
+	assert.Equal(t, "", errStream.String())
+	assert.False(t, setConfigCalled)
+	assert.False(t, writeConfigCalled)
+	assert.True(t, loadCoverageCalled)
+	assert.False(t, loadStatementsCalled)
+}
+
+func TestRootCmdLowCoverageHighThreshold(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	values := map[string]float64{
+		"threshold":    50.0,
+		"min_headroom": 0.0,
+		"max_headroom": 0.0,
+	}
+	setConfigCalled := false
+	writeConfigCalled := false
+	loadCoverageCalled := false
+	loadStatementsCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&getFloat64, func(name string) float64 {
+			value, ok := values[name]
+			assert.True(t, ok)
+			return value
+		}),
+		patcher.SetVar(&setConfig, func(_ string, _ interface{}) {
+			setConfigCalled = true
+		}),
+		patcher.SetVar(&writeConfig, func(_ string) error {
+			writeConfigCalled = true
+			return nil
+		}),
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
+			loadCoverageCalled = true
+			return common.DataSet{
+				common.FileData{
+					Package: "some/package",
+					Name:    "file1.go",
+					Count:   10,
+				},
+				common.FileData{
+					Package: "some/package",
+					Name:    "file2.go",
+					Count:   5,
+					Exec:    5,
+				},
+				common.FileData{
+					Package: "other/package",
+					Name:    "file3.go",
+					Count:   4,
+				},
+			}, nil, nil
+		}),
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
+			assert.Equal(t, []string{}, ba)
+			assert.Equal(t, []string{}, args)
+			loadStatementsCalled = true
+			return common.DataSet{}, nil
+		}),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(1)", func() { rootCmd.Run(rootCmd, []string{}) })
+	assert.Equal(t, "5 statements out of 19 covered; overall coverage: 26.3%\n", outStream.String())
+	assert.Equal(t, "\nFailed to meet coverage threshold of 50.0%\n", errStream.String())
+	assert.False(t, setConfigCalled)
+	assert.False(t, writeConfigCalled)
+	assert.True(t, loadCoverageCalled)
+	assert.False(t, loadStatementsCalled)
+}
+
+func TestRootCmdRuleFailure(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	values := map[string]float64{
+		"threshold":    0.0,
+		"min_headroom": 0.0,
+		"max_headroom": 0.0,
+	}
+	setConfigCalled := false
+	writeConfigCalled := false
+	loadCoverageCalled := false
+	loadStatementsCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&getFloat64, func(name string) float64 {
+			value, ok := values[name]
+			assert.True(t, ok)
+			return value
+		}),
+		patcher.SetVar(&setConfig, func(_ string, _ interface{}) {
+			setConfigCalled = true
+		}),
+		patcher.SetVar(&writeConfig, func(_ string) error {
+			writeConfigCalled = true
+			return nil
+		}),
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
+			loadCoverageCalled = true
+			return common.DataSet{
+				common.FileData{
+					Package: "some/package",
+					Name:    "file1.go",
+					Count:   10,
+					Exec:    10,
+				},
+				common.FileData{
+					Package: "some/package",
+					Name:    "file2.go",
+					Count:   5,
+					Exec:    5,
+				},
+				common.FileData{
+					Package: "other/package",
+					Name:    "file3.go",
+					Count:   4,
+					Exec:    2,
+				},
+			}, nil, nil
+		}),
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
+			assert.Equal(t, []string{}, ba)
+			assert.Equal(t, []string{}, args)
+			loadStatementsCalled = true
+			return common.DataSet{}, nil
+		}),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
+		patchRules(t, []rules.Rule{
+			{Pattern: "other/...", Threshold: 90.0},
+		}, nil),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(1)", func() { rootCmd.Run(rootCmd, []string{}) })
+	assert.Equal(t, "17 statements out of 19 covered; overall coverage: 89.5%\n", outStream.String())
+	assert.Equal(t, "\nFailed to meet coverage threshold of 90.0% for rule other/...:\n  other/package: 50.0%\n", errStream.String())
+	assert.False(t, setConfigCalled)
+	assert.False(t, writeConfigCalled)
+	assert.True(t, loadCoverageCalled)
+	assert.False(t, loadStatementsCalled)
+}
+
+func TestRootCmdExportedRuleFailure(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	values := map[string]float64{
+		"threshold":    0.0,
+		"min_headroom": 0.0,
+		"max_headroom": 0.0,
+	}
+	findFunctionsCalled := 0
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&getFloat64, func(name string) float64 {
+			value, ok := values[name]
+			assert.True(t, ok)
+			return value
+		}),
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			return common.DataSet{
+				common.FileData{
+					Package: "some/package",
+					Name:    "file1.go",
+					Count:   10,
+					Exec:    10,
+				},
+				common.FileData{
+					Package: "other/package",
+					Name:    "file3.go",
+					Count:   4,
+					Exec:    2,
+				},
+			}, nil, nil
+		}),
+		patcher.SetVar(&findFunctions, func(ds common.DataSet) (common.FuncSet, common.DataSet) {
+			assert.Len(t, ds, 2)
+			findFunctionsCalled++
+			return common.FuncSet{
+				{Package: "other/package", File: "file3.go", Name: "Get", Line: 3, Count: 2, Exec: 2, Exported: true},
+				{Package: "other/package", File: "file3.go", Name: "Put", Line: 9, Count: 2, Exported: true},
+			}, nil
+		}),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
+		patcher.SetVar(&functions, true),
+		patchRules(t, []rules.Rule{
+			{Pattern: "other/...", Type: rules.TypeExported},
+		}, nil),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(1)", func() { rootCmd.Run(rootCmd, []string{}) })
+	assert.Equal(t, `Per function details:
+ Function  File                      Executed  Total  Coverage
+ --------  ----                      --------  -----  --------
+ Put       other/package/file3.go:9  0         2      0.0%
+ Get       other/package/file3.go:3  2         2      100.0%
+
+12 statements out of 14 covered; overall coverage: 85.7%
+`, outStream.String())
+	assert.Equal(t, "\nFailed to meet coverage threshold of 0.0% for exported functions matching rule other/...:\n  other/package/file3.go:9: Put: 0.0%\n", errStream.String())
+	assert.Equal(t, 1, findFunctionsCalled)
+}
+
+func TestRootCmdWorkspaceFailure(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	values := map[string]float64{
+		"threshold":    0.0,
+		"min_headroom": 0.0,
+		"max_headroom": 0.0,
+	}
+	setConfigCalled := false
+	writeConfigCalled := false
+	loadCoverageCalled := false
+	loadStatementsCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&getFloat64, func(name string) float64 {
+			value, ok := values[name]
+			assert.True(t, ok)
+			return value
+		}),
+		patcher.SetVar(&setConfig, func(_ string, _ interface{}) {
+			setConfigCalled = true
+		}),
+		patcher.SetVar(&writeConfig, func(_ string) error {
+			writeConfigCalled = true
+			return nil
+		}),
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
+			loadCoverageCalled = true
+			return common.DataSet{
+				common.FileData{
+					Package: "some/package",
+					Name:    "file1.go",
+					Count:   10,
+					Exec:    10,
+				},
+				common.FileData{
+					Package: "some/package",
+					Name:    "file2.go",
+					Count:   5,
+					Exec:    5,
+				},
+				common.FileData{
+					Package: "other/package",
+					Name:    "file3.go",
+					Count:   4,
+					Exec:    2,
+				},
+			}, nil, nil
+		}),
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
+			assert.Equal(t, []string{}, ba)
+			assert.Equal(t, []string{}, args)
+			loadStatementsCalled = true
+			return common.DataSet{}, nil
+		}),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
+		patcher.SetVar(&workspace, true),
+		patcher.SetVar(&findWorkspace, func(dir string) ([]modules.Module, error) {
+			assert.Equal(t, ".", dir)
+			return []modules.Module{
+				{Root: "/src/some", Path: "some"},
+				{Root: "/src/other", Path: "other"},
+			}, nil
+		}),
+		patcher.SetVar(&applyDirectives, func(ds common.DataSet, _, _ string) common.DataSet {
+			return ds
+		}),
+		patchModules(t, []moduleThreshold{
+			{Module: "other", Threshold: 90.0},
+		}, nil),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(1)", func() { rootCmd.Run(rootCmd, []string{}) })
+	assert.Equal(t, "17 statements out of 19 covered; overall coverage: 89.5%\nPer module summary:\n Module  Executed  Total  Coverage\n ------  --------  -----  --------\n other   2         4      50.0%\n some    15        15     100.0%\n\n", outStream.String())
+	assert.Equal(t, "\nFailed to meet coverage threshold of 90.0% for module other: 50.0%\n", errStream.String())
+	assert.False(t, setConfigCalled)
+	assert.False(t, writeConfigCalled)
+	assert.True(t, loadCoverageCalled)
+	assert.False(t, loadStatementsCalled)
+}
+
+func TestRootCmdPatchFailure(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	values := map[string]float64{
+		"threshold":       0.0,
+		"min_headroom":    0.0,
+		"max_headroom":    0.0,
+		"patch_threshold": 80.0,
+	}
+	setConfigCalled := false
+	writeConfigCalled := false
+	loadCoverageCalled := false
+	loadStatementsCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&getFloat64, func(name string) float64 {
+			value, ok := values[name]
+			assert.True(t, ok)
+			return value
+		}),
+		patcher.SetVar(&setConfig, func(_ string, _ interface{}) {
+			setConfigCalled = true
+		}),
+		patcher.SetVar(&writeConfig, func(_ string) error {
+			writeConfigCalled = true
+			return nil
+		}),
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
+			loadCoverageCalled = true
+			return common.DataSet{
+				common.FileData{
+					Package: "some/package",
+					Name:    "file1.go",
+					Count:   10,
+					Exec:    10,
+					Blocks: []common.Block{
+						{StartLine: 1, EndLine: 3, NumStmt: 4, Count: 1},
+						{StartLine: 4, EndLine: 6, NumStmt: 6, Count: 0},
+					},
+				},
+				common.FileData{
+					Package: "some/package",
+					Name:    "file2.go",
+					Count:   5,
+					Exec:    5,
+				},
+				common.FileData{
+					Package: "other/package",
+					Name:    "file3.go",
+					Count:   4,
+					Exec:    4,
+				},
+			}, nil, nil
+		}),
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
+			assert.Equal(t, []string{}, ba)
+			assert.Equal(t, []string{}, args)
+			loadStatementsCalled = true
+			return common.DataSet{}, nil
+		}),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
+		patcher.SetVar(&diffBase, "main"),
+		patcher.SetVar(&loadChanges, func(base string) (diff.Changes, error) {
+			assert.Equal(t, "main", base)
+			return diff.Changes{
+				filepath.FromSlash("/src/mod/package/file1.go"): {{Start: 5, End: 5}},
+			}, nil
+		}),
+		patcher.SetVar(&findModule, func(_ string) (string, string, error) {
+			return filepath.FromSlash("/src/mod"), "some", nil
+		}),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(1)", func() { rootCmd.Run(rootCmd, []string{}) })
+	assert.Equal(t, "19 statements out of 19 covered; overall coverage: 100.0%\n0 statements in changed blocks out of 6 covered; patch coverage: 0.0%\n", outStream.String())
+	assert.Equal(t, "\nFailed to meet patch coverage threshold of 80.0%\n", errStream.String())
+	assert.False(t, setConfigCalled)
+	assert.False(t, writeConfigCalled)
+	assert.True(t, loadCoverageCalled)
+	assert.False(t, loadStatementsCalled)
+}
+
+func TestRootCmdJSON(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	values := map[string]float64{
+		"threshold":    80.0,
+		"min_headroom": 0.0,
+		"max_headroom": 0.0,
+	}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&getFloat64, func(name string) float64 {
+			value, ok := values[name]
+			assert.True(t, ok)
+			return value
+		}),
+		patcher.SetVar(&loadCoverage, func(_, _, _ []string) (common.DataSet, common.DataSet, error) {
+			return common.DataSet{
+				common.FileData{
+					Package: "some/package",
+					Name:    "file1.go",
+					Count:   10,
+					Exec:    5,
+				},
+			}, common.DataSet{
+				common.FileData{
+					Package: "other/package",
+					Name:    "file3.go",
+					Count:   5,
+					Exec:    1,
+				},
+			}, nil
+		}),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
+		patcher.SetVar(&summary, true),
+		patcher.SetVar(&format, formatJSON),
+		patcher.SetVar(&outputFile, ""),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(1)", func() { rootCmd.Run(rootCmd, []string{}) })
+	assert.Equal(t, `{
+  "version": 1,
+  "overall": {
+    "statements": 10,
+    "executed": 5,
+    "coverage": 50
+  },
+  "packages": [
+    {
+      "package": "some/package",
+      "statements": 10,
+      "executed": 5,
+      "coverage": 50
+    }
+  ],
+  "files": [
+    {
+      "package": "some/package",
+      "file": "file1.go",
+      "statements": 10,
+      "executed": 5,
+      "coverage": 50
+    }
+  ],
+  "threshold": {
+    "threshold": 80,
+    "passed": false
+  },
+  "conflicts": {
+    "profiles": [
+      "other/package/file3.go"
+    ],
+    "source": []
+  }
+}
+`, outStream.String())
+	assert.Equal(t, "WARNING: coverage profiles coverage.out have mismatched blocks; ignored data for files:\n  other/package/file3.go\n\nFailed to meet coverage threshold of 80.0%\n", errStream.String())
+}
+
+func TestRootCmdOutputFile(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	values := map[string]float64{
+		"threshold":    0.0,
+		"min_headroom": 0.0,
+		"max_headroom": 0.0,
+	}
+	var written []byte
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&getFloat64, func(name string) float64 {
+			value, ok := values[name]
+			assert.True(t, ok)
+			return value
+		}),
+		patcher.SetVar(&loadCoverage, func(_, _, _ []string) (common.DataSet, common.DataSet, error) {
+			return common.DataSet{
+				common.FileData{
+					Package: "some/package",
+					Name:    "file1.go",
+					Count:   10,
+					Exec:    5,
+				},
+			}, nil, nil
+		}),
+		patcher.SetVar(&writeFile, func(name string, data []byte, _ os.FileMode) error {
+			assert.Equal(t, "report.txt", name)
+			written = data
+			return nil
+		}),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
+		patcher.SetVar(&format, formatText),
+		patcher.SetVar(&outputFile, "report.txt"),
+	).Install().Restore()
+
+	rootCmd.Run(rootCmd, []string{})
+
+	assert.Equal(t, "", outStream.String())
+	assert.Equal(t, "", errStream.String())
+	assert.Equal(t, "5 statements out of 10 covered; overall coverage: 50.0%\n", string(written))
+}
+
+func TestRootCmdUpdateNeededNoConfig(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	values := map[string]float64{
+		"threshold":    75.0,
+		"min_headroom": 1.0,
+		"max_headroom": 2.0,
+	}
+	setConfigCalled := false
+	writeConfigCalled := false
+	loadCoverageCalled := false
+	loadStatementsCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&getFloat64, func(name string) float64 {
+			value, ok := values[name]
+			assert.True(t, ok)
+			return value
+		}),
+		patcher.SetVar(&setConfig, func(_ string, _ interface{}) {
+			setConfigCalled = true
+		}),
+		patcher.SetVar(&writeConfig, func(_ string) error {
+			writeConfigCalled = true
+			return nil
+		}),
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
+			loadCoverageCalled = true
+			return common.DataSet{
+				common.FileData{
+					Package: "some/package",
+					Name:    "file1.go",
+					Count:   10,
+					Exec:    10,
+				},
+				common.FileData{
+					Package: "some/package",
+					Name:    "file2.go",
+					Count:   5,
+					Exec:    5,
+				},
+				common.FileData{
+					Package: "other/package",
+					Name:    "file3.go",
+					Count:   4,
+					Exec:    4,
+				},
+			}, nil, nil
+		}),
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
+			assert.Equal(t, []string{}, ba)
+			assert.Equal(t, []string{}, args)
+			loadStatementsCalled = true
+			return common.DataSet{}, nil
+		}),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
+	).Install().Restore()
+
+	rootCmd.Run(rootCmd, []string{})
+
+	assert.Equal(t, "19 statements out of 19 covered; overall coverage: 100.0%\n", outStream.String())
+	assert.Equal(t, "", errStream.String())
+	assert.False(t, setConfigCalled)
+	assert.False(t, writeConfigCalled)
+	assert.True(t, loadCoverageCalled)
+	assert.False(t, loadStatementsCalled)
+}
+
+func TestRootCmdUpdateNeededWithConfigReadOnly(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	values := map[string]float64{
+		"threshold":    75.0,
+		"min_headroom": 1.0,
+		"max_headroom": 2.0,
+	}
+	setConfigCalled := false
+	writeConfigCalled := false
+	loadCoverageCalled := false
+	loadStatementsCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&config, "test.yaml"),
+		patcher.SetVar(&readOnly, true),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&getFloat64, func(name string) float64 {
+			value, ok := values[name]
+			assert.True(t, ok)
+			return value
+		}),
+		patcher.SetVar(&setConfig, func(_ string, _ interface{}) {
+			setConfigCalled = true
+		}),
+		patcher.SetVar(&writeConfig, func(_ string) error {
+			writeConfigCalled = true
+			return nil
+		}),
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
+			loadCoverageCalled = true
+			return common.DataSet{
+				common.FileData{
+					Package: "some/package",
+					Name:    "file1.go",
+					Count:   10,
+					Exec:    10,
 				},
 				common.FileData{
 					Package: "some/package",
@@ -1246,15 +2135,16 @@ func TestRootCmdUpdateNeededWithConfigReadOnly(t *testing.T) {
 					Count:   4,
 					Exec:    4,
 				},
-			}, nil
+			}, nil, nil
 		}),
-		patcher.SetVar(&loadStatements, func(ba, args []string) (common.DataSet, error) {
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
 			assert.Equal(t, []string{}, ba)
 			assert.Equal(t, []string{}, args)
 			loadStatementsCalled = true
 			return common.DataSet{}, nil
 		}),
-		patcher.SetVar(&coverprofile, "coverage.out"),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
 	).Install().Restore()
 
 	assert.PanicsWithValue(t, "os.Exit(5)", func() { rootCmd.Run(rootCmd, []string{}) })
@@ -1298,8 +2188,10 @@ func TestRootCmdUpdateUnneededWithConfigReadOnly(t *testing.T) {
 			writeConfigCalled = true
 			return nil
 		}),
-		patcher.SetVar(&loadCoverage, func(filename string) (common.DataSet, error) {
-			assert.Equal(t, "coverage.out", filename)
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
 			loadCoverageCalled = true
 			return common.DataSet{
 				common.FileData{
@@ -1320,15 +2212,16 @@ func TestRootCmdUpdateUnneededWithConfigReadOnly(t *testing.T) {
 					Count:   4,
 					Exec:    4,
 				},
-			}, nil
+			}, nil, nil
 		}),
-		patcher.SetVar(&loadStatements, func(ba, args []string) (common.DataSet, error) {
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
 			assert.Equal(t, []string{}, ba)
 			assert.Equal(t, []string{}, args)
 			loadStatementsCalled = true
 			return common.DataSet{}, nil
 		}),
-		patcher.SetVar(&coverprofile, "coverage.out"),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
 	).Install().Restore()
 
 	rootCmd.Run(rootCmd, []string{})
@@ -1375,8 +2268,10 @@ func TestRootCmdUpdateNeededWithConfig(t *testing.T) {
 			writeConfigCalled = true
 			return nil
 		}),
-		patcher.SetVar(&loadCoverage, func(filename string) (common.DataSet, error) {
-			assert.Equal(t, "coverage.out", filename)
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
 			loadCoverageCalled = true
 			return common.DataSet{
 				common.FileData{
@@ -1397,15 +2292,16 @@ func TestRootCmdUpdateNeededWithConfig(t *testing.T) {
 					Count:   4,
 					Exec:    4,
 				},
-			}, nil
+			}, nil, nil
 		}),
-		patcher.SetVar(&loadStatements, func(ba, args []string) (common.DataSet, error) {
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
 			assert.Equal(t, []string{}, ba)
 			assert.Equal(t, []string{}, args)
 			loadStatementsCalled = true
 			return common.DataSet{}, nil
 		}),
-		patcher.SetVar(&coverprofile, "coverage.out"),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
 	).Install().Restore()
 
 	rootCmd.Run(rootCmd, []string{})
@@ -1452,8 +2348,10 @@ func TestRootCmdUpdateNeededWithConfigFails(t *testing.T) {
 			writeConfigCalled = true
 			return assert.AnError
 		}),
-		patcher.SetVar(&loadCoverage, func(filename string) (common.DataSet, error) {
-			assert.Equal(t, "coverage.out", filename)
+		patcher.SetVar(&loadCoverage, func(filenames, dirs, tfs []string) (common.DataSet, common.DataSet, error) {
+			assert.Equal(t, []string{"coverage.out"}, filenames)
+			assert.Equal(t, []string{}, dirs)
+			assert.Equal(t, []string{}, tfs)
 			loadCoverageCalled = true
 			return common.DataSet{
 				common.FileData{
@@ -1474,15 +2372,16 @@ func TestRootCmdUpdateNeededWithConfigFails(t *testing.T) {
 					Count:   4,
 					Exec:    4,
 				},
-			}, nil
+			}, nil, nil
 		}),
-		patcher.SetVar(&loadStatements, func(ba, args []string) (common.DataSet, error) {
+		patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+			assert.Equal(t, "", dir)
 			assert.Equal(t, []string{}, ba)
 			assert.Equal(t, []string{}, args)
 			loadStatementsCalled = true
 			return common.DataSet{}, nil
 		}),
-		patcher.SetVar(&coverprofile, "coverage.out"),
+		patcher.SetVar(&coverprofile, []string{"coverage.out"}),
 	).Install().Restore()
 
 	assert.PanicsWithValue(t, "os.Exit(5)", func() { rootCmd.Run(rootCmd, []string{}) })
@@ -1494,6 +2393,270 @@ func TestRootCmdUpdateNeededWithConfigFails(t *testing.T) {
 	assert.False(t, loadStatementsCalled)
 }
 
+var testResults = []rules.Result{
+	{
+		Rule: rules.Rule{Pattern: "./internal/...", Threshold: 75.0},
+		Packages: common.DataSet{
+			{Package: "example.com/mod/internal/auth", Count: 10, Exec: 9},
+		},
+	},
+	{
+		Rule: rules.Rule{Pattern: "./api", Threshold: 75.0},
+		Packages: common.DataSet{
+			{Package: "example.com/mod/api", Count: 10, Exec: 8},
+		},
+	},
+}
+
+func TestProposeUpdateUnneeded(t *testing.T) {
+	result := proposeUpdate(90.0, 88.0, 1.0, 2.0, []rules.Result{
+		{
+			Rule:     rules.Rule{Pattern: "./api", Threshold: 79.0},
+			Packages: testResults[1].Packages,
+		},
+	})
+
+	assert.Nil(t, result)
+}
+
+func TestProposeUpdateBoth(t *testing.T) {
+	result := proposeUpdate(85.0, 75.0, 1.0, 2.0, testResults)
+
+	threshold := 84.0
+	assert.Equal(t, &report.Update{
+		Threshold: &threshold,
+		Rules: []rules.Rule{
+			{Pattern: "./internal/...", Threshold: 89.0},
+			{Pattern: "./api", Threshold: 79.0},
+		},
+	}, result)
+}
+
+func TestProposeUpdateThresholdOnly(t *testing.T) {
+	result := proposeUpdate(85.0, 75.0, 1.0, 2.0, nil)
+
+	threshold := 84.0
+	assert.Equal(t, &report.Update{
+		Threshold: &threshold,
+	}, result)
+}
+
+func TestProposeUpdateRulesOnly(t *testing.T) {
+	result := proposeUpdate(85.0, 84.0, 1.0, 2.0, []rules.Result{
+		testResults[0],
+		{
+			Rule:     rules.Rule{Pattern: "./api", Threshold: 79.0},
+			Packages: testResults[1].Packages,
+		},
+	})
+
+	assert.Equal(t, &report.Update{
+		Rules: []rules.Rule{
+			{Pattern: "./internal/...", Threshold: 89.0},
+			{Pattern: "./api", Threshold: 79.0},
+		},
+	}, result)
+}
+
+func TestApplyUpdateBase(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	values := map[string]interface{}{}
+	writeConfigCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&config, "test.yaml"),
+		patcher.SetVar(&readOnly, false),
+		patcher.SetVar(&format, formatText),
+		patcher.SetVar(&setConfig, func(name string, value interface{}) {
+			values[name] = value
+		}),
+		patcher.SetVar(&writeConfig, func(fname string) error {
+			assert.Equal(t, "test.yaml", fname)
+			writeConfigCalled = true
+			return nil
+		}),
+	).Install().Restore()
+	threshold := 84.0
+
+	applyUpdate(&report.Update{
+		Threshold: &threshold,
+		Rules: []rules.Rule{
+			{Pattern: "./internal/...", Threshold: 89.0},
+			{Pattern: "./api", Threshold: 79.0},
+		},
+	}, testResults)
+
+	assert.Equal(t, "Updating configuration file test.yaml with new threshold value 84.0%\nUpdating configuration file test.yaml with new threshold value 89.0% for rule ./internal/...\nUpdating configuration file test.yaml with new threshold value 79.0% for rule ./api\n", outStream.String())
+	assert.Equal(t, "", errStream.String())
+	assert.Equal(t, map[string]interface{}{
+		"threshold": 84.0,
+		"rules": []rules.Rule{
+			{Pattern: "./internal/...", Threshold: 89.0},
+			{Pattern: "./api", Threshold: 79.0},
+		},
+	}, values)
+	assert.True(t, writeConfigCalled)
+}
+
+func TestApplyUpdateRulesOnly(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	values := map[string]interface{}{}
+	writeConfigCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&config, "test.yaml"),
+		patcher.SetVar(&readOnly, false),
+		patcher.SetVar(&format, formatText),
+		patcher.SetVar(&setConfig, func(name string, value interface{}) {
+			values[name] = value
+		}),
+		patcher.SetVar(&writeConfig, func(fname string) error {
+			assert.Equal(t, "test.yaml", fname)
+			writeConfigCalled = true
+			return nil
+		}),
+	).Install().Restore()
+
+	applyUpdate(&report.Update{
+		Rules: []rules.Rule{
+			{Pattern: "./internal/...", Threshold: 89.0},
+			{Pattern: "./api", Threshold: 75.0},
+		},
+	}, testResults)
+
+	assert.Equal(t, "Updating configuration file test.yaml with new threshold value 89.0% for rule ./internal/...\n", outStream.String())
+	assert.Equal(t, "", errStream.String())
+	assert.Equal(t, map[string]interface{}{
+		"rules": []rules.Rule{
+			{Pattern: "./internal/...", Threshold: 89.0},
+			{Pattern: "./api", Threshold: 75.0},
+		},
+	}, values)
+	assert.True(t, writeConfigCalled)
+}
+
+func TestApplyUpdateJSON(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	values := map[string]interface{}{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&config, "test.yaml"),
+		patcher.SetVar(&readOnly, false),
+		patcher.SetVar(&format, formatJSON),
+		patcher.SetVar(&setConfig, func(name string, value interface{}) {
+			values[name] = value
+		}),
+		patcher.SetVar(&writeConfig, func(_ string) error {
+			return nil
+		}),
+	).Install().Restore()
+	threshold := 84.0
+
+	applyUpdate(&report.Update{Threshold: &threshold}, nil)
+
+	assert.Equal(t, "", outStream.String())
+	assert.Equal(t, "Updating configuration file test.yaml with new threshold value 84.0%\n", errStream.String())
+	assert.Equal(t, map[string]interface{}{
+		"threshold": 84.0,
+	}, values)
+}
+
+func TestApplyUpdateReadOnly(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	setConfigCalled := false
+	writeConfigCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&config, "test.yaml"),
+		patcher.SetVar(&readOnly, true),
+		patcher.SetVar(&setConfig, func(_ string, _ interface{}) {
+			setConfigCalled = true
+		}),
+		patcher.SetVar(&writeConfig, func(_ string) error {
+			writeConfigCalled = true
+			return nil
+		}),
+	).Install().Restore()
+	threshold := 84.0
+	upd := &report.Update{
+		Threshold: &threshold,
+		Rules: []rules.Rule{
+			{Pattern: "./internal/...", Threshold: 89.0},
+			{Pattern: "./api", Threshold: 79.0},
+		},
+	}
+
+	assert.PanicsWithValue(t, "os.Exit(5)", func() { applyUpdate(upd, testResults) })
+	assert.Equal(t, "", outStream.String())
+	assert.Equal(t, "\nCoverage exceeds maximum headroom.  Update threshold to 84.0%\n\nCoverage for rule ./internal/... exceeds maximum headroom.  Update threshold to 89.0%\n\nCoverage for rule ./api exceeds maximum headroom.  Update threshold to 79.0%\n", errStream.String())
+	assert.False(t, setConfigCalled)
+	assert.False(t, writeConfigCalled)
+}
+
+func TestApplyUpdateWriteFails(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&config, "test.yaml"),
+		patcher.SetVar(&readOnly, false),
+		patcher.SetVar(&format, formatText),
+		patcher.SetVar(&setConfig, func(_ string, _ interface{}) {}),
+		patcher.SetVar(&writeConfig, func(_ string) error {
+			return assert.AnError
+		}),
+	).Install().Restore()
+	threshold := 84.0
+
+	assert.PanicsWithValue(t, "os.Exit(5)", func() { applyUpdate(&report.Update{Threshold: &threshold}, nil) })
+	assert.Equal(t, "Updating configuration file test.yaml with new threshold value 84.0%\n", outStream.String())
+	assert.Equal(t, "\nFailed to write updated config with new threshold 84.0% to test.yaml: "+assert.AnError.Error()+"\n", errStream.String())
+}
+
+func TestApplyUpdateRulesWriteFails(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stdout, outStream),
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&config, "test.yaml"),
+		patcher.SetVar(&readOnly, false),
+		patcher.SetVar(&format, formatText),
+		patcher.SetVar(&setConfig, func(_ string, _ interface{}) {}),
+		patcher.SetVar(&writeConfig, func(_ string) error {
+			return assert.AnError
+		}),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(5)", func() {
+		applyUpdate(&report.Update{
+			Rules: []rules.Rule{
+				{Pattern: "./internal/...", Threshold: 89.0},
+			},
+		}, testResults[:1])
+	})
+	assert.Equal(t, "Updating configuration file test.yaml with new threshold value 89.0% for rule ./internal/...\n", outStream.String())
+	assert.Equal(t, "\nFailed to write updated config with new rule thresholds to test.yaml: "+assert.AnError.Error()+"\n", errStream.String())
+}
+
 func TestExecuteSuccess(t *testing.T) {
 	errStream := &bytes.Buffer{}
 	defer patcher.NewPatchMaster(
@@ -1541,6 +2704,233 @@ func TestGetBuildArgDefaultSet(t *testing.T) {
 	assert.Equal(t, []string{"this", "is", "a", "test"}, result)
 }
 
+func TestGetExcludeDefaultUnset(t *testing.T) {
+	defer patcher.UnsetEnv("OVERCOVER_EXCLUDE").Install().Restore()
+
+	result := getExcludeDefault()
+
+	assert.Equal(t, []string{}, result)
+}
+
+func TestGetExcludeDefaultSet(t *testing.T) {
+	defer patcher.SetEnv("OVERCOVER_EXCLUDE", "./mocks/...  **/*_mock.go").Install().Restore()
+
+	result := getExcludeDefault()
+
+	assert.Equal(t, []string{"./mocks/...", "**/*_mock.go"}, result)
+}
+
+func TestGetCoverProfileDefaultUnset(t *testing.T) {
+	defer patcher.UnsetEnv("OVERCOVER_COVERPROFILE").Install().Restore()
+
+	result := getCoverProfileDefault()
+
+	assert.Equal(t, []string{}, result)
+}
+
+func TestGetCoverProfileDefaultEmpty(t *testing.T) {
+	defer patcher.SetEnv("OVERCOVER_COVERPROFILE", "").Install().Restore()
+
+	result := getCoverProfileDefault()
+
+	assert.Equal(t, []string{}, result)
+}
+
+func TestGetCoverProfileDefaultSet(t *testing.T) {
+	defer patcher.SetEnv("OVERCOVER_COVERPROFILE", strings.Join([]string{"unit.out", "e2e.out"}, string(os.PathListSeparator))).Install().Restore()
+
+	result := getCoverProfileDefault()
+
+	assert.Equal(t, []string{"unit.out", "e2e.out"}, result)
+}
+
+func TestGetCoverDirDefaultUnset(t *testing.T) {
+	defer patcher.UnsetEnv("OVERCOVER_COVERDIR").Install().Restore()
+
+	result := getCoverDirDefault()
+
+	assert.Equal(t, []string{}, result)
+}
+
+func TestGetCoverDirDefaultEmpty(t *testing.T) {
+	defer patcher.SetEnv("OVERCOVER_COVERDIR", "").Install().Restore()
+
+	result := getCoverDirDefault()
+
+	assert.Equal(t, []string{}, result)
+}
+
+func TestGetCoverDirDefaultSet(t *testing.T) {
+	defer patcher.SetEnv("OVERCOVER_COVERDIR", strings.Join([]string{"covdir1", "covdir2"}, string(os.PathListSeparator))).Install().Restore()
+
+	result := getCoverDirDefault()
+
+	assert.Equal(t, []string{"covdir1", "covdir2"}, result)
+}
+
+func TestGetLCOVDefaultUnset(t *testing.T) {
+	defer patcher.UnsetEnv("OVERCOVER_LCOV").Install().Restore()
+
+	result := getLCOVDefault()
+
+	assert.Equal(t, []string{}, result)
+}
+
+func TestGetLCOVDefaultEmpty(t *testing.T) {
+	defer patcher.SetEnv("OVERCOVER_LCOV", "").Install().Restore()
+
+	result := getLCOVDefault()
+
+	assert.Equal(t, []string{}, result)
+}
+
+func TestGetLCOVDefaultSet(t *testing.T) {
+	defer patcher.SetEnv("OVERCOVER_LCOV", strings.Join([]string{"unit.dat", "e2e.dat"}, string(os.PathListSeparator))).Install().Restore()
+
+	result := getLCOVDefault()
+
+	assert.Equal(t, []string{"unit.dat", "e2e.dat"}, result)
+}
+
+func TestGetFormatDefaultUnset(t *testing.T) {
+	defer patcher.UnsetEnv("OVERCOVER_FORMAT").Install().Restore()
+
+	result := getFormatDefault()
+
+	assert.Equal(t, formatText, result)
+}
+
+func TestGetFormatDefaultSet(t *testing.T) {
+	defer patcher.SetEnv("OVERCOVER_FORMAT", "json").Install().Restore()
+
+	result := getFormatDefault()
+
+	assert.Equal(t, formatJSON, result)
+}
+
+func TestGetWorstDefaultUnset(t *testing.T) {
+	defer patcher.UnsetEnv("OVERCOVER_WORST").Install().Restore()
+
+	result := getWorstDefault()
+
+	assert.Equal(t, 10, result)
+}
+
+func TestGetWorstDefaultSet(t *testing.T) {
+	defer patcher.SetEnv("OVERCOVER_WORST", "5").Install().Restore()
+
+	result := getWorstDefault()
+
+	assert.Equal(t, 5, result)
+}
+
+func TestGetWorstDefaultInvalid(t *testing.T) {
+	defer patcher.SetEnv("OVERCOVER_WORST", "many").Install().Restore()
+
+	result := getWorstDefault()
+
+	assert.Equal(t, 10, result)
+}
+
+func TestWriteConfigFileBase(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "overcover.yaml")
+	require.NoError(t, os.WriteFile(fname, []byte("threshold: 50\nexclude:\n  - ./cmd/...\n"), 0o666))
+	v := viper.New()
+	v.Set("threshold", 75.0)
+	v.Set("min_headroom", 1.0)
+	v.Set("max_headroom", 2.0)
+	v.Set("patch_threshold", "0")
+	defer patcher.SetVar(&settings, v).Install().Restore()
+
+	err := writeConfigFile(fname)
+
+	assert.NoError(t, err)
+	data, err := os.ReadFile(fname)
+	require.NoError(t, err)
+	assert.Equal(t, "exclude:\n    - ./cmd/...\nthreshold: 75\n", string(data))
+}
+
+func TestWriteConfigFileHeadroom(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "overcover.yaml")
+	require.NoError(t, os.WriteFile(fname, []byte("threshold: 50\nmin_headroom: 1\nmax_headroom: 2\n"), 0o666))
+	v := viper.New()
+	v.Set("threshold", 75.0)
+	v.Set("min_headroom", 3.0)
+	v.Set("max_headroom", 4.0)
+	defer patcher.SetVar(&settings, v).Install().Restore()
+
+	err := writeConfigFile(fname)
+
+	assert.NoError(t, err)
+	data, err := os.ReadFile(fname)
+	require.NoError(t, err)
+	assert.Equal(t, "max_headroom: 2\nmin_headroom: 1\nthreshold: 75\n", string(data))
+}
+
+func TestWriteConfigFileMalformed(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "overcover.yaml")
+	require.NoError(t, os.WriteFile(fname, []byte("threshold: [50\nexclude:\n  - ./cmd/...\n"), 0o666))
+	v := viper.New()
+	v.Set("threshold", 75.0)
+	defer patcher.SetVar(&settings, v).Install().Restore()
+
+	err := writeConfigFile(fname)
+
+	assert.Error(t, err)
+	data, err := os.ReadFile(fname)
+	require.NoError(t, err)
+	assert.Equal(t, "threshold: [50\nexclude:\n  - ./cmd/...\n", string(data))
+}
+
+func TestWriteConfigFileRules(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "overcover.yaml")
+	v := viper.New()
+	v.Set("threshold", 75.0)
+	v.Set("rules", []rules.Rule{{Pattern: "./api", Threshold: 80.0}})
+	defer patcher.SetVar(&settings, v).Install().Restore()
+
+	err := writeConfigFile(fname)
+
+	assert.NoError(t, err)
+	data, err := os.ReadFile(fname)
+	require.NoError(t, err)
+	assert.Equal(t, "rules:\n    - pattern: ./api\n      threshold: 80\nthreshold: 75\n", string(data))
+}
+
+func TestWriteConfigFileBadgeMargin(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "overcover.yaml")
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.Float64("badge-margin", 5.0, "")
+	v := viper.New()
+	require.NoError(t, v.BindPFlag("badge_margin", flags.Lookup("badge-margin")))
+	v.Set("threshold", 75.0)
+	defer patcher.SetVar(&settings, v).Install().Restore()
+
+	err := writeConfigFile(fname)
+
+	assert.NoError(t, err)
+	data, err := os.ReadFile(fname)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "badge_margin")
+}
+
+func TestWriteConfigFileExcludeGenerated(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "overcover.yaml")
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.Bool("exclude-generated", false, "")
+	v := viper.New()
+	require.NoError(t, v.BindPFlag("exclude_generated", flags.Lookup("exclude-generated")))
+	v.Set("threshold", 75.0)
+	defer patcher.SetVar(&settings, v).Install().Restore()
+
+	err := writeConfigFile(fname)
+
+	assert.NoError(t, err)
+	data, err := os.ReadFile(fname)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "exclude_generated")
+}
+
 func TestReadConfigBase(t *testing.T) {
 	outStream := &bytes.Buffer{}
 	var setCalled, readCalled bool
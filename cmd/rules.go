@@ -0,0 +1,93 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/rules"
+)
+
+// checkRules evaluates the per-package threshold rules from the
+// configuration file against the data set.  Each package that fails
+// to meet the threshold of a rule is reported, along with each
+// exported function failing an exported rule, and false is returned
+// if there are any such failures.  The function coverage is obtained
+// by calling funcs, which is only done if there are exported rules.
+// The results of evaluating the rules are also returned, for use in
+// updating their thresholds.
+func checkRules(ds common.DataSet, funcs func() common.FuncSet) ([]rules.Result, bool) {
+	// Read the rules from the configuration
+	var rs []rules.Rule
+	if err := unmarshalKey("rules", &rs); err != nil {
+		fmt.Fprintf(stderr, "Unable to read rules from configuration: %s\n", err)
+		exit(2)
+	}
+	if len(rs) == 0 {
+		return nil, true
+	}
+
+	// Exported rules need the coverage of the functions
+	var fs common.FuncSet
+	for _, r := range rs {
+		if r.Exported() {
+			fs = funcs()
+			break
+		}
+	}
+
+	// Evaluate them; relative patterns need the module path
+	_, modPath, _ := findModule(".")
+	results, err := rules.Evaluate(rs, modPath, ds, fs)
+	if err != nil {
+		fmt.Fprintf(stderr, "Invalid rule in configuration: %s\n", err)
+		exit(2)
+	}
+
+	// Report the failures
+	ok := true
+	for _, result := range results {
+		if len(result.Packages) == 0 {
+			fmt.Fprintf(stderr, "WARNING: rule %s matches no packages\n", result.Rule.Pattern)
+			continue
+		}
+
+		if result.Rule.Exported() {
+			failures := result.FunctionFailures()
+			if len(failures) == 0 {
+				continue
+			}
+			fmt.Fprintf(stderr, "\nFailed to meet coverage threshold of %.1f%% for exported functions matching rule %s:\n", result.Rule.Threshold, result.Rule.Pattern)
+			for _, fd := range failures {
+				fmt.Fprintf(stderr, "  %s: %s: %.1f%%\n", fd.Handle(), fd.Name, fd.Coverage()*100.0)
+			}
+			ok = false
+			continue
+		}
+
+		failures := result.Failures()
+		if len(failures) == 0 {
+			continue
+		}
+		fmt.Fprintf(stderr, "\nFailed to meet coverage threshold of %.1f%% for rule %s:\n", result.Rule.Threshold, result.Rule.Pattern)
+		for _, fd := range failures {
+			fmt.Fprintf(stderr, "  %s: %.1f%%\n", fd.Package, fd.Coverage()*100.0)
+		}
+		ok = false
+	}
+
+	return results, ok
+}
@@ -0,0 +1,229 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/klmitch/patcher"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/rules"
+)
+
+var testRulesData = common.DataSet{
+	{Package: "example.com/mod/internal/auth", Name: "a.go", Count: 10, Exec: 8},
+	{Package: "example.com/mod/internal/gen", Name: "a.go", Count: 10, Exec: 3},
+	{Package: "example.com/mod/api", Name: "a.go", Count: 10, Exec: 10},
+}
+
+func patchRules(t *testing.T, rs []rules.Rule, err error) patcher.Patcher {
+	return patcher.NewPatchMaster(
+		patcher.SetVar(&unmarshalKey, func(key string, rawVal interface{}, _ ...viper.DecoderConfigOption) error {
+			if key == "exclude" {
+				return nil
+			}
+			assert.Equal(t, "rules", key)
+			*(rawVal.(*[]rules.Rule)) = rs
+			return err
+		}),
+		patcher.SetVar(&findModule, func(dir string) (string, string, error) {
+			assert.Equal(t, ".", dir)
+			return "/src/mod", "example.com/mod", nil
+		}),
+	)
+}
+
+func noFuncs(t *testing.T) func() common.FuncSet {
+	return func() common.FuncSet {
+		assert.Fail(t, "unexpected call to load functions")
+		return nil
+	}
+}
+
+func TestCheckRulesNoRules(t *testing.T) {
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patchRules(t, nil, nil),
+	).Install().Restore()
+
+	results, result := checkRules(testRulesData, noFuncs(t))
+
+	assert.Nil(t, results)
+	assert.True(t, result)
+	assert.Equal(t, "", errStream.String())
+}
+
+func TestCheckRulesPass(t *testing.T) {
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patchRules(t, []rules.Rule{
+			{Pattern: "./internal/auth", Threshold: 80.0},
+			{Pattern: "**/gen", Threshold: 30.0},
+		}, nil),
+	).Install().Restore()
+
+	results, result := checkRules(testRulesData, noFuncs(t))
+
+	assert.Equal(t, []rules.Result{
+		{
+			Rule: rules.Rule{Pattern: "./internal/auth", Threshold: 80.0},
+			Packages: common.DataSet{
+				{Package: "example.com/mod/internal/auth", Count: 10, Exec: 8},
+			},
+		},
+		{
+			Rule: rules.Rule{Pattern: "**/gen", Threshold: 30.0},
+			Packages: common.DataSet{
+				{Package: "example.com/mod/internal/gen", Count: 10, Exec: 3},
+			},
+		},
+	}, results)
+	assert.True(t, result)
+	assert.Equal(t, "", errStream.String())
+}
+
+func TestCheckRulesFail(t *testing.T) {
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patchRules(t, []rules.Rule{
+			{Pattern: "./internal/...", Threshold: 90.0},
+			{Pattern: "./api", Threshold: 90.0},
+			{Pattern: "**/gen", Threshold: 40.0},
+		}, nil),
+	).Install().Restore()
+
+	results, result := checkRules(testRulesData, noFuncs(t))
+
+	assert.Len(t, results, 3)
+	assert.False(t, result)
+	assert.Equal(t, "\nFailed to meet coverage threshold of 90.0% for rule ./internal/...:\n  example.com/mod/internal/auth: 80.0%\n  example.com/mod/internal/gen: 30.0%\n\nFailed to meet coverage threshold of 40.0% for rule **/gen:\n  example.com/mod/internal/gen: 30.0%\n", errStream.String())
+}
+
+func TestCheckRulesNoMatch(t *testing.T) {
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patchRules(t, []rules.Rule{
+			{Pattern: "./cmd/...", Threshold: 90.0},
+		}, nil),
+	).Install().Restore()
+
+	results, result := checkRules(testRulesData, noFuncs(t))
+
+	assert.Len(t, results, 1)
+	assert.True(t, result)
+	assert.Equal(t, "WARNING: rule ./cmd/... matches no packages\n", errStream.String())
+}
+
+func TestCheckRulesUnmarshalFails(t *testing.T) {
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patchRules(t, nil, assert.AnError),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(2)", func() { checkRules(testRulesData, noFuncs(t)) })
+	assert.Equal(t, "Unable to read rules from configuration: "+assert.AnError.Error()+"\n", errStream.String())
+}
+
+func TestCheckRulesInvalid(t *testing.T) {
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patchRules(t, []rules.Rule{
+			{Threshold: 90.0},
+		}, nil),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(2)", func() { checkRules(testRulesData, noFuncs(t)) })
+	assert.Equal(t, "Invalid rule in configuration: rule \"\": empty pattern\n", errStream.String())
+}
+
+func TestCheckRulesExported(t *testing.T) {
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patchRules(t, []rules.Rule{
+			{Pattern: "./api", Type: rules.TypeExported, Threshold: 50.0},
+			{Pattern: "./internal/...", Type: rules.TypeExported},
+		}, nil),
+	).Install().Restore()
+	funcsCalled := 0
+
+	results, result := checkRules(testRulesData, func() common.FuncSet {
+		funcsCalled++
+		return common.FuncSet{
+			{Package: "example.com/mod/api", File: "a.go", Name: "New", Line: 3, Count: 4, Exec: 1, Exported: true},
+			{Package: "example.com/mod/api", File: "a.go", Name: "(*T).Get", Line: 12, Count: 10, Exec: 8, Exported: true},
+			{Package: "example.com/mod/api", File: "a.go", Name: "helper", Line: 20, Count: 2},
+			{Package: "example.com/mod/internal/auth", File: "a.go", Name: "Check", Line: 5, Count: 3, Exec: 3, Exported: true},
+		}
+	})
+
+	assert.Len(t, results, 2)
+	assert.False(t, result)
+	assert.Equal(t, 1, funcsCalled)
+	assert.Equal(t, "\nFailed to meet coverage threshold of 50.0% for exported functions matching rule ./api:\n  example.com/mod/api/a.go:3: New: 25.0%\n", errStream.String())
+}
+
+func TestCheckRulesExportedUnexecuted(t *testing.T) {
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patchRules(t, []rules.Rule{
+			{Pattern: "./api", Type: rules.TypeExported},
+		}, nil),
+	).Install().Restore()
+
+	results, result := checkRules(testRulesData, func() common.FuncSet {
+		return common.FuncSet{
+			{Package: "example.com/mod/api", File: "a.go", Name: "New", Line: 3, Count: 4, Exported: true},
+		}
+	})
+
+	assert.Len(t, results, 1)
+	assert.False(t, result)
+	assert.Equal(t, "\nFailed to meet coverage threshold of 0.0% for exported functions matching rule ./api:\n  example.com/mod/api/a.go:3: New: 0.0%\n", errStream.String())
+}
+
+func TestCheckRulesBadType(t *testing.T) {
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patchRules(t, []rules.Rule{
+			{Pattern: "./api", Type: "bogus"},
+		}, nil),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(2)", func() { checkRules(testRulesData, noFuncs(t)) })
+	assert.Contains(t, errStream.String(), "Invalid rule in configuration: ")
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/modules"
+	"github.com/klmitch/overcover/report"
+)
+
+// moduleThreshold describes a per-module threshold in the
+// configuration file.
+type moduleThreshold struct {
+	Module    string  `mapstructure:"module"`    // The module path
+	Threshold float64 `mapstructure:"threshold"` // The module threshold
+}
+
+// workspaceModules returns the modules of the workspace containing the
+// current directory, if --workspace was specified.  If it was not, nil
+// is returned, and only the current module is considered.
+func workspaceModules() []modules.Module {
+	if !workspace {
+		return nil
+	}
+
+	mods, err := findWorkspace(".")
+	if err != nil {
+		fmt.Fprintf(stderr, "Unable to read workspace: %s\n", err)
+		exit(2)
+	}
+
+	return mods
+}
+
+// loadSource loads the statement counts from the source of the
+// packages specified on the command line.  In a workspace, the
+// packages are loaded from each module in turn, so that patterns such
+// as "./..." select the packages of every module; files loaded from
+// more than one module are only included once.
+func loadSource(mods []modules.Module, args []string) (common.DataSet, error) {
+	if mods == nil {
+		return loadStatements("", buildArgs, args)
+	}
+
+	var result common.DataSet
+	seen := map[string]bool{}
+	for _, mod := range mods {
+		ds, err := loadStatements(mod.Root, buildArgs, args)
+		if err != nil {
+			return nil, fmt.Errorf("module %s: %w", mod.Path, err)
+		}
+		for _, fd := range ds {
+			if !seen[fd.Handle()] {
+				seen[fd.Handle()] = true
+				result = append(result, fd)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// forModules calls a function with the files of a data set owned by
+// each module of the workspace, along with the root directory and
+// path of the module.  Outside of a workspace, the function is called
+// once, with all the files and the module containing the current
+// directory.  The files owned by no module are returned.
+func forModules(ds common.DataSet, mods []modules.Module, fn func(common.DataSet, string, string)) common.DataSet {
+	if mods == nil {
+		modRoot, modPath, _ := findModule(".")
+		fn(ds, modRoot, modPath)
+		return nil
+	}
+
+	// Group the files by module
+	var unowned common.DataSet
+	owned := map[string]common.DataSet{}
+	for _, fd := range ds {
+		if mod, ok := modules.Owner(mods, fd.Package); ok {
+			owned[mod.Path] = append(owned[mod.Path], fd)
+		} else {
+			unowned = append(unowned, fd)
+		}
+	}
+
+	// Process the files of each module
+	for _, mod := range mods {
+		if len(owned[mod.Path]) > 0 {
+			fn(owned[mod.Path], mod.Root, mod.Path)
+		}
+	}
+
+	return unowned
+}
+
+// sourceModules returns the modules in which source files are
+// located: the modules of the workspace, or, outside of a workspace,
+// the module containing the current directory.
+func sourceModules(mods []modules.Module) []modules.Module {
+	if mods != nil {
+		return mods
+	}
+
+	modRoot, modPath, _ := findModule(".")
+	return []modules.Module{{Root: modRoot, Path: modPath}}
+}
+
+// sourceDirectives applies the ignore directives in the source to the
+// coverage data.  In a workspace, each file is checked against the
+// source of the module that owns it.
+func sourceDirectives(ds common.DataSet, mods []modules.Module) common.DataSet {
+	var result common.DataSet
+	unowned := forModules(ds, mods, func(owned common.DataSet, modRoot, modPath string) {
+		result = append(result, applyDirectives(owned, modRoot, modPath)...)
+	})
+
+	return append(unowned, result...)
+}
+
+// checkModules computes the coverage of each module of the workspace,
+// reports it to the specified stream, and checks it against the
+// per-module thresholds from the configuration file.  Each module
+// that fails to meet its threshold is reported, and false is returned
+// if there are any such modules.  Outside of a workspace, nil is
+// returned.
+func checkModules(w io.Writer, ds common.DataSet, mods []modules.Module) ([]report.Module, bool) {
+	if mods == nil {
+		return nil, true
+	}
+
+	// Read the thresholds from the configuration
+	var mts []moduleThreshold
+	if err := unmarshalKey("modules", &mts); err != nil {
+		fmt.Fprintf(stderr, "Unable to read module thresholds from configuration: %s\n", err)
+		exit(2)
+	}
+	thresholds := map[string]float64{}
+	for _, mt := range mts {
+		thresholds[mt.Module] = mt.Threshold
+	}
+
+	// Roll up the coverage of each module
+	owned := map[string]common.DataSet{}
+	for _, fd := range ds {
+		if mod, ok := modules.Owner(mods, fd.Package); ok {
+			owned[mod.Path] = append(owned[mod.Path], fd)
+		}
+	}
+	sorted := append([]modules.Module{}, mods...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Path < sorted[j].Path
+	})
+	result := make([]report.Module, 0, len(sorted))
+	for _, mod := range sorted {
+		sum := owned[mod.Path].Sum()
+		coverage := sum.Coverage() * 100.0
+		result = append(result, report.Module{
+			Module:    mod.Path,
+			Coverage:  report.NewCoverage(sum),
+			Threshold: report.NewThreshold(coverage, thresholds[mod.Path]),
+		})
+		delete(thresholds, mod.Path)
+	}
+
+	// Report the coverage
+	fmt.Fprintln(w, "Per module summary:")
+	tab := tabwriter.NewWriter(w, 2, 8, 2, ' ', 0)
+	fmt.Fprintf(tab, " Module\tExecuted\tTotal\tCoverage\n ------\t--------\t-----\t--------\n")
+	for _, mod := range result {
+		fmt.Fprintf(tab, " %s\t%d\t%d\t%.1f%%\n", mod.Module, mod.Executed, mod.Statements, mod.Coverage.Coverage)
+	}
+	tab.Flush()
+	fmt.Fprintln(w, "")
+
+	// Report the failures
+	for _, mt := range mts {
+		if _, ok := thresholds[mt.Module]; ok {
+			fmt.Fprintf(stderr, "WARNING: module %s is not in the workspace\n", mt.Module)
+		}
+	}
+	ok := true
+	for _, mod := range result {
+		if !mod.Threshold.Passed {
+			fmt.Fprintf(stderr, "\nFailed to meet coverage threshold of %.1f%% for module %s: %.1f%%\n", mod.Threshold.Threshold, mod.Module, mod.Coverage.Coverage)
+			ok = false
+		}
+	}
+
+	return result, ok
+}
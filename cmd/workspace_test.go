@@ -0,0 +1,334 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/klmitch/patcher"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/modules"
+	"github.com/klmitch/overcover/report"
+)
+
+var testModules = []modules.Module{
+	{Root: "/src/mod", Path: "example.com/mod"},
+	{Root: "/src/mod/tools", Path: "example.com/mod/tools"},
+	{Root: "/src/other", Path: "example.com/other"},
+}
+
+var testModulesData = common.DataSet{
+	{Package: "example.com/mod/api", Name: "a.go", Count: 10, Exec: 8},
+	{Package: "example.com/mod/tools/gen", Name: "a.go", Count: 10, Exec: 3},
+	{Package: "example.com/other", Name: "a.go", Count: 10, Exec: 10},
+	{Package: "example.com/unknown", Name: "a.go", Count: 10, Exec: 0},
+}
+
+func patchModules(t *testing.T, mts []moduleThreshold, err error) patcher.Patcher {
+	return patcher.SetVar(&unmarshalKey, func(key string, rawVal interface{}, _ ...viper.DecoderConfigOption) error {
+		if key == "exclude" || key == "rules" {
+			return nil
+		}
+		assert.Equal(t, "modules", key)
+		*(rawVal.(*[]moduleThreshold)) = mts
+		return err
+	})
+}
+
+func TestWorkspaceModulesDisabled(t *testing.T) {
+	findWorkspaceCalled := false
+	defer patcher.SetVar(&findWorkspace, func(_ string) ([]modules.Module, error) {
+		findWorkspaceCalled = true
+		return testModules, nil
+	}).Install().Restore()
+
+	result := workspaceModules()
+
+	assert.Nil(t, result)
+	assert.False(t, findWorkspaceCalled)
+}
+
+func TestWorkspaceModulesEnabled(t *testing.T) {
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&workspace, true),
+		patcher.SetVar(&findWorkspace, func(dir string) ([]modules.Module, error) {
+			assert.Equal(t, ".", dir)
+			return testModules, nil
+		}),
+	).Install().Restore()
+
+	result := workspaceModules()
+
+	assert.Equal(t, testModules, result)
+}
+
+func TestWorkspaceModulesError(t *testing.T) {
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patcher.SetVar(&workspace, true),
+		patcher.SetVar(&findWorkspace, func(_ string) ([]modules.Module, error) {
+			return nil, modules.ErrNoWorkspace
+		}),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(2)", func() { workspaceModules() })
+	assert.Equal(t, "Unable to read workspace: no go.work file found\n", errStream.String())
+}
+
+func TestLoadSourceModule(t *testing.T) {
+	defer patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+		assert.Equal(t, "", dir)
+		assert.Equal(t, []string{}, ba)
+		assert.Equal(t, []string{"./..."}, args)
+		return common.DataSet{
+			{Package: "example.com/mod/api", Name: "a.go", Count: 10},
+		}, nil
+	}).Install().Restore()
+
+	result, err := loadSource(nil, []string{"./..."})
+
+	assert.NoError(t, err)
+	assert.Equal(t, common.DataSet{
+		{Package: "example.com/mod/api", Name: "a.go", Count: 10},
+	}, result)
+}
+
+func TestLoadSourceWorkspace(t *testing.T) {
+	dirs := []string{}
+	defer patcher.SetVar(&loadStatements, func(dir string, ba, args []string) (common.DataSet, error) {
+		assert.Equal(t, []string{}, ba)
+		assert.Equal(t, []string{"./...", "example.com/other"}, args)
+		dirs = append(dirs, dir)
+		switch dir {
+		case "/src/mod":
+			return common.DataSet{
+				{Package: "example.com/mod/api", Name: "a.go", Count: 10},
+				{Package: "example.com/other", Name: "a.go", Count: 10},
+			}, nil
+		case "/src/mod/tools":
+			return common.DataSet{
+				{Package: "example.com/mod/tools/gen", Name: "a.go", Count: 10},
+				{Package: "example.com/other", Name: "a.go", Count: 10},
+			}, nil
+		}
+		return common.DataSet{
+			{Package: "example.com/other", Name: "a.go", Count: 10},
+		}, nil
+	}).Install().Restore()
+
+	result, err := loadSource(testModules, []string{"./...", "example.com/other"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, common.DataSet{
+		{Package: "example.com/mod/api", Name: "a.go", Count: 10},
+		{Package: "example.com/other", Name: "a.go", Count: 10},
+		{Package: "example.com/mod/tools/gen", Name: "a.go", Count: 10},
+	}, result)
+	assert.Equal(t, []string{"/src/mod", "/src/mod/tools", "/src/other"}, dirs)
+}
+
+func TestLoadSourceWorkspaceError(t *testing.T) {
+	defer patcher.SetVar(&loadStatements, func(dir string, _, _ []string) (common.DataSet, error) {
+		if dir == "/src/mod/tools" {
+			return nil, assert.AnError
+		}
+		return common.DataSet{}, nil
+	}).Install().Restore()
+
+	result, err := loadSource(testModules, []string{"./..."})
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.EqualError(t, err, "module example.com/mod/tools: "+assert.AnError.Error())
+	assert.Nil(t, result)
+}
+
+func TestSourceModulesModule(t *testing.T) {
+	defer patcher.SetVar(&findModule, func(dir string) (string, string, error) {
+		assert.Equal(t, ".", dir)
+		return "/src/mod", "example.com/mod", nil
+	}).Install().Restore()
+
+	result := sourceModules(nil)
+
+	assert.Equal(t, []modules.Module{{Root: "/src/mod", Path: "example.com/mod"}}, result)
+}
+
+func TestSourceModulesWorkspace(t *testing.T) {
+	findModuleCalled := false
+	defer patcher.SetVar(&findModule, func(_ string) (string, string, error) {
+		findModuleCalled = true
+		return "", "", nil
+	}).Install().Restore()
+
+	result := sourceModules(testModules)
+
+	assert.Equal(t, testModules, result)
+	assert.False(t, findModuleCalled)
+}
+
+func TestSourceDirectivesModule(t *testing.T) {
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&findModule, func(dir string) (string, string, error) {
+			assert.Equal(t, ".", dir)
+			return "/src/mod", "example.com/mod", nil
+		}),
+		patcher.SetVar(&applyDirectives, func(ds common.DataSet, modRoot, modPath string) common.DataSet {
+			assert.Equal(t, testModulesData, ds)
+			assert.Equal(t, "/src/mod", modRoot)
+			assert.Equal(t, "example.com/mod", modPath)
+			return ds[:1]
+		}),
+	).Install().Restore()
+
+	result := sourceDirectives(testModulesData, nil)
+
+	assert.Equal(t, testModulesData[:1], result)
+}
+
+func TestSourceDirectivesWorkspace(t *testing.T) {
+	roots := map[string]string{}
+	defer patcher.SetVar(&applyDirectives, func(ds common.DataSet, modRoot, modPath string) common.DataSet {
+		roots[modPath] = modRoot
+		assert.Len(t, ds, 1)
+		return ds
+	}).Install().Restore()
+
+	result := sourceDirectives(testModulesData, testModules)
+
+	assert.ElementsMatch(t, testModulesData, result)
+	assert.Equal(t, map[string]string{
+		"example.com/mod":       "/src/mod",
+		"example.com/mod/tools": "/src/mod/tools",
+		"example.com/other":     "/src/other",
+	}, roots)
+}
+
+func TestSourceDirectivesWorkspaceUnused(t *testing.T) {
+	applyDirectivesCalled := false
+	defer patcher.SetVar(&applyDirectives, func(ds common.DataSet, _, _ string) common.DataSet {
+		applyDirectivesCalled = true
+		return ds
+	}).Install().Restore()
+
+	result := sourceDirectives(testModulesData[3:], testModules)
+
+	assert.Equal(t, testModulesData[3:], result)
+	assert.False(t, applyDirectivesCalled)
+}
+
+func TestCheckModulesNoWorkspace(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	unmarshalKeyCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&unmarshalKey, func(_ string, _ interface{}, _ ...viper.DecoderConfigOption) error {
+			unmarshalKeyCalled = true
+			return nil
+		}),
+	).Install().Restore()
+
+	results, result := checkModules(outStream, testModulesData, nil)
+
+	assert.Nil(t, results)
+	assert.True(t, result)
+	assert.Equal(t, "", outStream.String())
+	assert.Equal(t, "", errStream.String())
+	assert.False(t, unmarshalKeyCalled)
+}
+
+func TestCheckModulesPass(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patchModules(t, []moduleThreshold{
+			{Module: "example.com/mod", Threshold: 80.0},
+		}, nil),
+	).Install().Restore()
+
+	results, result := checkModules(outStream, testModulesData, []modules.Module{testModules[2], testModules[0], testModules[1]})
+
+	assert.Equal(t, []report.Module{
+		{
+			Module:    "example.com/mod",
+			Coverage:  report.Coverage{Statements: 10, Executed: 8, Coverage: 80.0},
+			Threshold: report.Threshold{Threshold: 80.0, Passed: true},
+		},
+		{
+			Module:    "example.com/mod/tools",
+			Coverage:  report.Coverage{Statements: 10, Executed: 3, Coverage: 30.0},
+			Threshold: report.Threshold{Passed: true},
+		},
+		{
+			Module:    "example.com/other",
+			Coverage:  report.Coverage{Statements: 10, Executed: 10, Coverage: 100.0},
+			Threshold: report.Threshold{Passed: true},
+		},
+	}, results)
+	assert.True(t, result)
+	assert.Equal(t, `Per module summary:
+ Module                 Executed  Total  Coverage
+ ------                 --------  -----  --------
+ example.com/mod        8         10     80.0%
+ example.com/mod/tools  3         10     30.0%
+ example.com/other      10        10     100.0%
+
+`, outStream.String())
+	assert.Equal(t, "", errStream.String())
+}
+
+func TestCheckModulesFail(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patchModules(t, []moduleThreshold{
+			{Module: "example.com/mod", Threshold: 90.0},
+			{Module: "example.com/mod/tools", Threshold: 40.0},
+			{Module: "example.com/missing", Threshold: 40.0},
+		}, nil),
+	).Install().Restore()
+
+	results, result := checkModules(outStream, testModulesData, testModules)
+
+	assert.Len(t, results, 3)
+	assert.False(t, result)
+	assert.Equal(t, "WARNING: module example.com/missing is not in the workspace\n\nFailed to meet coverage threshold of 90.0% for module example.com/mod: 80.0%\n\nFailed to meet coverage threshold of 40.0% for module example.com/mod/tools: 30.0%\n", errStream.String())
+}
+
+func TestCheckModulesConfigError(t *testing.T) {
+	outStream := &bytes.Buffer{}
+	errStream := &bytes.Buffer{}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&stderr, errStream),
+		patcher.SetVar(&exit, func(code int) {
+			panic(fmt.Sprintf("os.Exit(%d)", code))
+		}),
+		patchModules(t, nil, assert.AnError),
+	).Install().Restore()
+
+	assert.PanicsWithValue(t, "os.Exit(2)", func() { checkModules(outStream, testModulesData, testModules) })
+	assert.Equal(t, "Unable to read module thresholds from configuration: "+assert.AnError.Error()+"\n", errStream.String())
+}
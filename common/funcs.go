@@ -0,0 +1,116 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import "fmt"
+
+// FuncData contains the summarized data about a function, including
+// the file it is in, its name, the line on which it starts, the total
+// number of statements, and the number of executed statements.
+// Function literals are named after the function containing them, as
+// by the compiler: the first literal in F is "F.func1", and the first
+// literal within that is "F.func1.1".  Literals outside of any
+// function, such as in variable initializers, are numbered within the
+// package, as in "glob..func1".  Exported functions, and exported
+// methods callable through exported types, including those promoted
+// through embedding, are part of the package's API.
+type FuncData struct {
+	Package  string // Name of the package
+	File     string // Name of the file (basename)
+	Name     string // Name of the function
+	Line     int    // Line on which the function starts
+	Count    int64  // Number of statements in the function
+	Exec     int64  // Number of statements in the function that were executed
+	Exported bool   // Whether the function is part of the package's API
+}
+
+// Coverage reports the coverage of the function as a float.
+func (fd FuncData) Coverage() float64 {
+	// Avoid divide-by-zero
+	if fd.Count <= 0 {
+		return 1.0
+	}
+
+	return float64(fd.Exec) / float64(fd.Count)
+}
+
+// Handle reports a handle for the FuncData record.  This is the full
+// file name, including package, followed by the line on which the
+// function starts.
+func (fd FuncData) Handle() string {
+	return fmt.Sprintf("%s/%s:%d", fd.Package, fd.File, fd.Line)
+}
+
+// Extent describes a function declaration or function literal found
+// in the source of a file: its name, as for FuncData, the line on
+// which it starts, and the positions of the braces enclosing its
+// body.
+type Extent struct {
+	Name      string // Name of the function
+	Line      int    // Line on which the function starts
+	StartLine int    // Line of the opening brace of the body
+	StartCol  int    // Column of the opening brace of the body
+	EndLine   int    // Line of the closing brace of the body
+	EndCol    int    // Column of the closing brace of the body
+	Exported  bool   // Whether the function is part of the package's API
+}
+
+// Contains reports whether a block starts within the body of the
+// function.
+func (e Extent) Contains(blk Block) bool {
+	switch {
+	case blk.StartLine < e.StartLine || blk.StartLine > e.EndLine:
+		return false
+	case blk.StartLine == e.StartLine && blk.StartCol < e.StartCol:
+		return false
+	case blk.StartLine == e.EndLine && blk.StartCol > e.EndCol:
+		return false
+	}
+
+	return true
+}
+
+// FuncSet is a list of FuncData instances.  It implements
+// sort.Interface, and sorts in the same order as DataSet.
+type FuncSet []FuncData
+
+// Len returns the number of elements in the function set.
+func (fs FuncSet) Len() int {
+	return len(fs)
+}
+
+// Less reports whether the element with index i should sort before
+// the element with index j.  Functions in the same file sort by line.
+func (fs FuncSet) Less(i, j int) bool {
+	iCov := fs[i].Coverage()
+	jCov := fs[j].Coverage()
+	switch {
+	case iCov < jCov:
+		return true
+	case jCov < iCov:
+		return false
+	case fs[i].Package != fs[j].Package:
+		return fs[i].Package < fs[j].Package
+	case fs[i].File != fs[j].File:
+		return fs[i].File < fs[j].File
+	default:
+		return fs[i].Line < fs[j].Line
+	}
+}
+
+// Swap swaps the two elements with the specified indexes.
+func (fs FuncSet) Swap(i, j int) {
+	fs[i], fs[j] = fs[j], fs[i]
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package common
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuncDataCoverageBase(t *testing.T) {
+	obj := FuncData{Count: 4, Exec: 1}
+
+	result := obj.Coverage()
+
+	assert.Equal(t, 0.25, result)
+}
+
+func TestFuncDataCoverageEmpty(t *testing.T) {
+	obj := FuncData{}
+
+	result := obj.Coverage()
+
+	assert.Equal(t, 1.0, result)
+}
+
+func TestFuncDataHandle(t *testing.T) {
+	obj := FuncData{
+		Package: "some/package",
+		File:    "file.go",
+		Name:    "(*T).Method",
+		Line:    42,
+	}
+
+	result := obj.Handle()
+
+	assert.Equal(t, "some/package/file.go:42", result)
+}
+
+func TestExtentContains(t *testing.T) {
+	obj := Extent{StartLine: 2, StartCol: 10, EndLine: 5, EndCol: 1}
+
+	assert.True(t, obj.Contains(Block{StartLine: 2, StartCol: 10}))
+	assert.True(t, obj.Contains(Block{StartLine: 3, StartCol: 2}))
+	assert.True(t, obj.Contains(Block{StartLine: 5, StartCol: 1}))
+	assert.False(t, obj.Contains(Block{StartLine: 1, StartCol: 20}))
+	assert.False(t, obj.Contains(Block{StartLine: 2, StartCol: 9}))
+	assert.False(t, obj.Contains(Block{StartLine: 5, StartCol: 2}))
+	assert.False(t, obj.Contains(Block{StartLine: 6, StartCol: 1}))
+}
+
+func TestFuncSetLen(t *testing.T) {
+	obj := FuncSet{{}, {}, {}}
+
+	result := obj.Len()
+
+	assert.Equal(t, 3, result)
+}
+
+func TestFuncSetSwap(t *testing.T) {
+	obj := FuncSet{{Name: "a"}, {Name: "b"}}
+
+	obj.Swap(0, 1)
+
+	assert.Equal(t, FuncSet{{Name: "b"}, {Name: "a"}}, obj)
+}
+
+func TestFuncSetSorts(t *testing.T) {
+	obj := FuncSet{
+		{Package: "pkg/b", File: "a.go", Name: "F", Line: 3, Count: 4, Exec: 2},
+		{Package: "pkg/a", File: "b.go", Name: "G", Line: 20, Count: 4, Exec: 4},
+		{Package: "pkg/a", File: "b.go", Name: "H", Line: 10, Count: 2, Exec: 1},
+		{Package: "pkg/a", File: "a.go", Name: "I", Line: 30, Count: 4, Exec: 2},
+		{Package: "pkg/a", File: "a.go", Name: "J", Line: 5, Count: 4, Exec: 0},
+		{Package: "pkg/a", File: "a.go", Name: "K", Line: 8, Count: 6, Exec: 3},
+	}
+
+	sort.Sort(obj)
+
+	assert.Equal(t, FuncSet{
+		{Package: "pkg/a", File: "a.go", Name: "J", Line: 5, Count: 4, Exec: 0},
+		{Package: "pkg/a", File: "a.go", Name: "K", Line: 8, Count: 6, Exec: 3},
+		{Package: "pkg/a", File: "a.go", Name: "I", Line: 30, Count: 4, Exec: 2},
+		{Package: "pkg/a", File: "b.go", Name: "H", Line: 10, Count: 2, Exec: 1},
+		{Package: "pkg/b", File: "a.go", Name: "F", Line: 3, Count: 4, Exec: 2},
+		{Package: "pkg/a", File: "b.go", Name: "G", Line: 20, Count: 4, Exec: 4},
+	}, obj)
+}
@@ -16,14 +16,34 @@ package common
 
 import "fmt"
 
+// Block describes a single block of statements within a file, as
+// recorded in a coverage profile.
+type Block struct {
+	StartLine int // Line on which the block starts
+	StartCol  int // Column at which the block starts
+	EndLine   int // Line on which the block ends
+	EndCol    int // Column at which the block ends
+	NumStmt   int // Number of statements in the block
+	Count     int // Number of times the block was executed
+}
+
 // FileData contains the summarized data about the file, including its
 // package, its base name, the total number of statements, and the
-// number of executed statements.
+// number of executed statements.  The individual blocks of statements
+// are also available; when the data comes only from the source, these
+// are synthetic blocks, computed as the cover tool would, none of which
+// have been executed.  When the source has been read, whether the file
+// contains generated code is also recorded, along with the functions
+// in the file.
 type FileData struct {
-	Package string // Name of the package
-	Name    string // Name of the file (basename)
-	Count   int64  // Number of statements in the file
-	Exec    int64  // Number of statements in the file that were executed
+	Package   string   // Name of the package
+	Name      string   // Name of the file (basename)
+	Count     int64    // Number of statements in the file
+	Exec      int64    // Number of statements in the file that were executed
+	Blocks    []Block  // Blocks of statements in the file, if known
+	Funcs     []Extent // Functions in the file, if the source was read
+	Generated bool     // Whether the file contains generated code
+	Synthetic bool     // Whether the blocks were computed from the source
 }
 
 // Coverage reports the coverage of the file as a float.
@@ -64,10 +84,12 @@ type DataSet []FileData
 
 // Merge is a utility function that merges a list of FileData
 // instances with another FileData list.  It ensures that the Count
-// fields are the same and picks an Exec field.  It returns the
-// resulting list, along with a list of FileData where Count did not
-// match; the order will match the elements of a first, followed by
-// any elements of b which did not appear in a.
+// fields are the same and picks an Exec field, along with the
+// corresponding Blocks; a file is generated if either list says so,
+// and the functions are taken from whichever list has them.
+// It returns the resulting list, along with a list of FileData where
+// Count did not match; the order will match the elements of a first,
+// followed by any elements of b which did not appear in a.
 func (ds DataSet) Merge(other DataSet) (DataSet, DataSet) {
 	// Set up our index and result sets
 	idx := map[string]map[string]int{}
@@ -98,9 +120,18 @@ func (ds DataSet) Merge(other DataSet) (DataSet, DataSet) {
 			// Are the counts consistent?
 			if result[j].Count != fd.Count {
 				conflict = append(conflict, fd)
-			} else if result[j].Exec == 0 {
+				continue
+			}
+			if result[j].Exec == 0 {
 				result[j].Exec = fd.Exec
+				if fd.Blocks != nil {
+					result[j].Blocks = fd.Blocks
+				}
+			}
+			if result[j].Funcs == nil {
+				result[j].Funcs = fd.Funcs
 			}
+			result[j].Generated = result[j].Generated || fd.Generated
 			continue
 		}
 
@@ -115,7 +146,7 @@ func (ds DataSet) Merge(other DataSet) (DataSet, DataSet) {
 
 // Reduce is a utility function that reduces a list of FileData
 // instances into a list of FileData instances that only contain
-// counts for packages.
+// counts for packages.  The blocks are not included.
 func (ds DataSet) Reduce() DataSet {
 	// Set up our index and result set
 	idx := map[string]int{}
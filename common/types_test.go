@@ -155,6 +155,168 @@ func TestDataSetMergeBase(t *testing.T) {
 	assert.Nil(t, conflict)
 }
 
+func TestDataSetMergeBlocks(t *testing.T) {
+	ds := DataSet{
+		FileData{
+			Package: "example.com/some/package",
+			Name:    "file1.go",
+			Count:   3,
+			Blocks: []Block{
+				{StartLine: 1, EndLine: 2, NumStmt: 3},
+			},
+		},
+		FileData{
+			Package: "example.com/some/package",
+			Name:    "file2.go",
+			Count:   3,
+			Exec:    3,
+			Blocks: []Block{
+				{StartLine: 1, EndLine: 2, NumStmt: 3, Count: 1},
+			},
+		},
+	}
+	other := DataSet{
+		FileData{
+			Package: "example.com/some/package",
+			Name:    "file1.go",
+			Count:   3,
+			Exec:    3,
+			Blocks: []Block{
+				{StartLine: 1, EndLine: 2, NumStmt: 3, Count: 1},
+			},
+		},
+		FileData{
+			Package: "example.com/some/package",
+			Name:    "file2.go",
+			Count:   3,
+		},
+	}
+
+	result, conflict := ds.Merge(other)
+
+	assert.Equal(t, DataSet{
+		FileData{
+			Package: "example.com/some/package",
+			Name:    "file1.go",
+			Count:   3,
+			Exec:    3,
+			Blocks: []Block{
+				{StartLine: 1, EndLine: 2, NumStmt: 3, Count: 1},
+			},
+		},
+		FileData{
+			Package: "example.com/some/package",
+			Name:    "file2.go",
+			Count:   3,
+			Exec:    3,
+			Blocks: []Block{
+				{StartLine: 1, EndLine: 2, NumStmt: 3, Count: 1},
+			},
+		},
+	}, result)
+	assert.Nil(t, conflict)
+}
+
+func TestDataSetMergeGenerated(t *testing.T) {
+	ds := DataSet{
+		FileData{
+			Package: "example.com/some/package",
+			Name:    "file1.go",
+			Count:   3,
+			Exec:    2,
+		},
+		FileData{
+			Package:   "example.com/some/package",
+			Name:      "file2.go",
+			Count:     3,
+			Generated: true,
+		},
+	}
+	other := DataSet{
+		FileData{
+			Package:   "example.com/some/package",
+			Name:      "file1.go",
+			Count:     3,
+			Generated: true,
+		},
+		FileData{
+			Package: "example.com/some/package",
+			Name:    "file2.go",
+			Count:   3,
+		},
+	}
+
+	result, conflict := ds.Merge(other)
+
+	assert.Equal(t, DataSet{
+		FileData{
+			Package:   "example.com/some/package",
+			Name:      "file1.go",
+			Count:     3,
+			Exec:      2,
+			Generated: true,
+		},
+		FileData{
+			Package:   "example.com/some/package",
+			Name:      "file2.go",
+			Count:     3,
+			Generated: true,
+		},
+	}, result)
+	assert.Nil(t, conflict)
+}
+
+func TestDataSetMergeFuncs(t *testing.T) {
+	funcs := []Extent{{Name: "F", Line: 3, StartLine: 3, StartCol: 10, EndLine: 5, EndCol: 1}}
+	ds := DataSet{
+		FileData{
+			Package: "example.com/some/package",
+			Name:    "file1.go",
+			Count:   3,
+			Exec:    2,
+		},
+		FileData{
+			Package: "example.com/some/package",
+			Name:    "file2.go",
+			Count:   3,
+			Funcs:   funcs,
+		},
+	}
+	other := DataSet{
+		FileData{
+			Package: "example.com/some/package",
+			Name:    "file1.go",
+			Count:   3,
+			Funcs:   funcs,
+		},
+		FileData{
+			Package: "example.com/some/package",
+			Name:    "file2.go",
+			Count:   3,
+			Funcs:   []Extent{},
+		},
+	}
+
+	result, conflict := ds.Merge(other)
+
+	assert.Equal(t, DataSet{
+		FileData{
+			Package: "example.com/some/package",
+			Name:    "file1.go",
+			Count:   3,
+			Exec:    2,
+			Funcs:   funcs,
+		},
+		FileData{
+			Package: "example.com/some/package",
+			Name:    "file2.go",
+			Count:   3,
+			Funcs:   funcs,
+		},
+	}, result)
+	assert.Nil(t, conflict)
+}
+
 func TestDataSetMergeConflict(t *testing.T) {
 	ds := DataSet{
 		FileData{
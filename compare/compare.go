@@ -0,0 +1,149 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package compare computes the changes in coverage between two
+// coverage runs.
+package compare
+
+import (
+	"sort"
+
+	"github.com/klmitch/overcover/common"
+)
+
+// Delta describes the change in the coverage of a file, a package, or
+// the whole code base between a base run and a head run.
+type Delta struct {
+	Base *common.FileData // Coverage in the base run; nil if added
+	Head *common.FileData // Coverage in the head run; nil if removed
+}
+
+// Handle reports the handle of the file or package described by the
+// delta.
+func (d Delta) Handle() string {
+	if d.Head != nil {
+		return d.Head.Handle()
+	}
+
+	return d.Base.Handle()
+}
+
+// Added reports whether the file or package is new in the head run.
+func (d Delta) Added() bool {
+	return d.Base == nil
+}
+
+// Removed reports whether the file or package is absent from the
+// head run.
+func (d Delta) Removed() bool {
+	return d.Head == nil
+}
+
+// fraction returns the coverage of a file or package as the numerator
+// and denominator of a fraction; as for FileData.Coverage, a file
+// with no statements is fully covered.
+func fraction(fd *common.FileData) (int64, int64) {
+	if fd.Count <= 0 {
+		return 1, 1
+	}
+
+	return fd.Exec, fd.Count
+}
+
+// Change reports the change in coverage, in percentage points.  A
+// file or package that was added or removed has no change.  The
+// change is computed from the statement counts in a single division,
+// so that equal changes compare equal to the tolerance.
+func (d Delta) Change() float64 {
+	if d.Added() || d.Removed() {
+		return 0.0
+	}
+
+	baseExec, baseCount := fraction(d.Base)
+	headExec, headCount := fraction(d.Head)
+	return float64(headExec*baseCount-baseExec*headCount) * 100.0 / float64(headCount*baseCount)
+}
+
+// Changed reports whether the file or package was added or removed,
+// or whether its statement counts differ between the runs.
+func (d Delta) Changed() bool {
+	if d.Added() || d.Removed() {
+		return true
+	}
+
+	return d.Base.Count != d.Head.Count || d.Base.Exec != d.Head.Exec
+}
+
+// Regressed reports whether the coverage dropped by more than the
+// tolerance, in percentage points.  A file or package that was added
+// or removed cannot regress.
+func (d Delta) Regressed(tolerance float64) bool {
+	return -d.Change() > tolerance
+}
+
+// Comparison describes the changes in coverage between two runs.
+type Comparison struct {
+	Overall  Delta   // Change in the overall coverage
+	Packages []Delta // Per-package changes, sorted by handle
+	Files    []Delta // Per-file changes, sorted by handle
+}
+
+// deltas pairs up the elements of two data sets by handle.  The
+// deltas are sorted by handle.
+func deltas(base, head common.DataSet) []Delta {
+	idx := map[string]int{}
+	var result []Delta
+	for i := range base {
+		idx[base[i].Handle()] = len(result)
+		result = append(result, Delta{Base: &base[i]})
+	}
+	for i := range head {
+		if j, ok := idx[head[i].Handle()]; ok {
+			result[j].Head = &head[i]
+			continue
+		}
+		result = append(result, Delta{Head: &head[i]})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Handle() < result[j].Handle()
+	})
+
+	return result
+}
+
+// Compare computes the changes in coverage from a base data set to a
+// head data set.
+func Compare(base, head common.DataSet) *Comparison {
+	baseSum := base.Sum()
+	headSum := head.Sum()
+	return &Comparison{
+		Overall:  Delta{Base: &baseSum, Head: &headSum},
+		Packages: deltas(base.Reduce(), head.Reduce()),
+		Files:    deltas(base, head),
+	}
+}
+
+// Regressions returns the files whose coverage dropped by more than
+// the tolerance, in percentage points.
+func (c *Comparison) Regressions(tolerance float64) []Delta {
+	var result []Delta
+	for _, d := range c.Files {
+		if d.Regressed(tolerance) {
+			result = append(result, d)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package compare
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/klmitch/overcover/common"
+)
+
+func TestDeltaHandleHead(t *testing.T) {
+	obj := Delta{
+		Base: &common.FileData{Package: "pkg/a", Name: "old.go"},
+		Head: &common.FileData{Package: "pkg/a", Name: "new.go"},
+	}
+
+	result := obj.Handle()
+
+	assert.Equal(t, "pkg/a/new.go", result)
+}
+
+func TestDeltaHandleRemoved(t *testing.T) {
+	obj := Delta{Base: &common.FileData{Package: "pkg/a", Name: "old.go"}}
+
+	result := obj.Handle()
+
+	assert.Equal(t, "pkg/a/old.go", result)
+}
+
+func TestDeltaAdded(t *testing.T) {
+	obj := Delta{Head: &common.FileData{}}
+
+	assert.True(t, obj.Added())
+	assert.False(t, obj.Removed())
+}
+
+func TestDeltaRemoved(t *testing.T) {
+	obj := Delta{Base: &common.FileData{}}
+
+	assert.False(t, obj.Added())
+	assert.True(t, obj.Removed())
+}
+
+func TestDeltaChangeBase(t *testing.T) {
+	obj := Delta{
+		Base: &common.FileData{Count: 10, Exec: 8},
+		Head: &common.FileData{Count: 10, Exec: 5},
+	}
+
+	result := obj.Change()
+
+	assert.InDelta(t, -30.0, result, 0.0001)
+}
+
+func TestDeltaChangeEmpty(t *testing.T) {
+	obj := Delta{
+		Base: &common.FileData{},
+		Head: &common.FileData{Count: 4, Exec: 1},
+	}
+
+	result := obj.Change()
+
+	assert.Equal(t, -75.0, result)
+}
+
+func TestDeltaChangeAdded(t *testing.T) {
+	obj := Delta{Head: &common.FileData{Count: 10, Exec: 5}}
+
+	result := obj.Change()
+
+	assert.Equal(t, 0.0, result)
+}
+
+func TestDeltaChangedAdded(t *testing.T) {
+	obj := Delta{Head: &common.FileData{}}
+
+	assert.True(t, obj.Changed())
+}
+
+func TestDeltaChangedCounts(t *testing.T) {
+	obj := Delta{
+		Base: &common.FileData{Count: 10, Exec: 5},
+		Head: &common.FileData{Count: 12, Exec: 6},
+	}
+
+	assert.True(t, obj.Changed())
+}
+
+func TestDeltaChangedUnchanged(t *testing.T) {
+	obj := Delta{
+		Base: &common.FileData{Count: 10, Exec: 5},
+		Head: &common.FileData{Count: 10, Exec: 5},
+	}
+
+	assert.False(t, obj.Changed())
+}
+
+func TestDeltaRegressed(t *testing.T) {
+	obj := Delta{
+		Base: &common.FileData{Count: 10, Exec: 8},
+		Head: &common.FileData{Count: 10, Exec: 5},
+	}
+
+	assert.True(t, obj.Regressed(0.0))
+	assert.True(t, obj.Regressed(25.0))
+	assert.False(t, obj.Regressed(35.0))
+}
+
+func TestDeltaRegressedImproved(t *testing.T) {
+	obj := Delta{
+		Base: &common.FileData{Count: 10, Exec: 5},
+		Head: &common.FileData{Count: 10, Exec: 8},
+	}
+
+	assert.False(t, obj.Regressed(0.0))
+}
+
+func TestCompare(t *testing.T) {
+	base := common.DataSet{
+		{Package: "pkg/b", Name: "b.go", Count: 10, Exec: 8},
+		{Package: "pkg/a", Name: "a1.go", Count: 10, Exec: 5},
+		{Package: "pkg/a", Name: "old.go", Count: 4, Exec: 4},
+	}
+	head := common.DataSet{
+		{Package: "pkg/a", Name: "a1.go", Count: 10, Exec: 7},
+		{Package: "pkg/b", Name: "b.go", Count: 10, Exec: 6},
+		{Package: "pkg/c", Name: "new.go", Count: 2, Exec: 1},
+	}
+
+	result := Compare(base, head)
+
+	assert.Equal(t, &Comparison{
+		Overall: Delta{
+			Base: &common.FileData{Count: 24, Exec: 17},
+			Head: &common.FileData{Count: 22, Exec: 14},
+		},
+		Packages: []Delta{
+			{
+				Base: &common.FileData{Package: "pkg/a", Count: 14, Exec: 9},
+				Head: &common.FileData{Package: "pkg/a", Count: 10, Exec: 7},
+			},
+			{
+				Base: &common.FileData{Package: "pkg/b", Count: 10, Exec: 8},
+				Head: &common.FileData{Package: "pkg/b", Count: 10, Exec: 6},
+			},
+			{
+				Head: &common.FileData{Package: "pkg/c", Count: 2, Exec: 1},
+			},
+		},
+		Files: []Delta{
+			{Base: &base[1], Head: &head[0]},
+			{Base: &base[2]},
+			{Base: &base[0], Head: &head[1]},
+			{Head: &head[2]},
+		},
+	}, result)
+}
+
+func TestComparisonRegressions(t *testing.T) {
+	obj := &Comparison{
+		Files: []Delta{
+			{
+				Base: &common.FileData{Package: "pkg/a", Name: "a.go", Count: 10, Exec: 5},
+				Head: &common.FileData{Package: "pkg/a", Name: "a.go", Count: 10, Exec: 7},
+			},
+			{
+				Base: &common.FileData{Package: "pkg/b", Name: "b.go", Count: 10, Exec: 8},
+				Head: &common.FileData{Package: "pkg/b", Name: "b.go", Count: 10, Exec: 6},
+			},
+			{
+				Base: &common.FileData{Package: "pkg/c", Name: "c.go", Count: 10, Exec: 8},
+				Head: &common.FileData{Package: "pkg/c", Name: "c.go", Count: 10, Exec: 7},
+			},
+			{
+				Base: &common.FileData{Package: "pkg/d", Name: "d.go", Count: 10, Exec: 8},
+			},
+		},
+	}
+
+	result := obj.Regressions(10.0)
+
+	assert.Equal(t, []Delta{obj.Files[1]}, result)
+}
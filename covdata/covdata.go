@@ -0,0 +1,472 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package covdata reads the binary coverage data files written to
+// the GOCOVERDIR directory by programs built with "go build -cover".
+// The data is converted into the same profile representation used
+// for the textual coverage profiles generated by "go test".
+package covdata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// Prefixes of the names of the meta-data and counter data files.
+const (
+	metaPrefix    = "covmeta."
+	counterPrefix = "covcounters."
+)
+
+// Sizes of fixed-size structures within the files.
+const (
+	metaHeaderSize    = 56 // Size of the meta-data file header
+	pkgHeaderSize     = 44 // Size of a package meta-data header
+	counterHeaderSize = 32 // Size of the counter data file header
+	counterFooterSize = 16 // Size of a counter data segment footer
+)
+
+// Most recent versions of the file formats.
+const (
+	metaVersion    = 1
+	counterVersion = 1
+)
+
+// Counter data flavors.
+const (
+	flavorRaw     = 1 // Counter data stored as fixed-size integers
+	flavorULeb128 = 2 // Counter data stored LEB128-encoded
+)
+
+// Magic numbers for the meta-data and counter data files.
+var (
+	metaMagic    = []byte{0x00, 0x63, 0x76, 0x6d}
+	counterMagic = []byte{0x00, 0x63, 0x77, 0x6d}
+)
+
+// Counter mode names, indexed by the mode value in the meta-data
+// file header.
+var modes = []string{"", "set", "count", "atomic"}
+
+// Errors that may be returned while reading coverage data.
+var (
+	ErrTruncated  = errors.New("truncated coverage data file")
+	ErrBadMagic   = errors.New("not a coverage data file")
+	ErrBadVersion = errors.New("unsupported coverage data file version")
+	ErrBadFlavor  = errors.New("unsupported coverage counter data flavor")
+	ErrBadIndex   = errors.New("invalid string table index in coverage data file")
+	ErrNoData     = errors.New("no coverage meta-data files found")
+)
+
+// Patch points for top-level functions called by functions in this
+// file.
+var (
+	readDir  func(string) ([]os.DirEntry, error) = os.ReadDir
+	readFile func(string) ([]byte, error)        = os.ReadFile
+)
+
+// unit describes a single coverable unit--a basic block--within a
+// function.
+type unit struct {
+	stLine, stCol int // Start position of the unit
+	enLine, enCol int // End position of the unit
+	numStmt       int // Number of statements in the unit
+}
+
+// funcDesc describes a single function.
+type funcDesc struct {
+	file  string // Name of the source file, including import path
+	units []unit // The coverable units in the function
+}
+
+// funcKey identifies a function by package and function index.
+type funcKey struct {
+	pkg uint32 // Index of the package within the meta-data file
+	fn  uint32 // Index of the function within the package
+}
+
+// payload contains the counters for a single function.
+type payload struct {
+	key      funcKey // The function the counters are for
+	counters []int   // The counters for each unit
+}
+
+// metaFile describes the contents of a meta-data file.
+type metaFile struct {
+	hash  string            // Hash identifying the meta-data file
+	mode  string            // Counter mode
+	pkgs  [][]funcDesc      // Functions in each package
+	count map[funcKey][]int // Accumulated counters for each function
+}
+
+// checkMagic reads and verifies a magic number.
+func checkMagic(r *reader, magic []byte) error {
+	if data := r.bytes(len(magic)); r.err != nil {
+		return r.err
+	} else if !bytes.Equal(data, magic) {
+		return ErrBadMagic
+	}
+
+	return nil
+}
+
+// parsePackage parses the meta-data blob for a single package.
+func parsePackage(data []byte) ([]funcDesc, error) {
+	// Read the header; we only need the number of functions
+	r := newReader(data)
+	r.seek(pkgHeaderSize - 4)
+	numFuncs := int(r.u32(binary.LittleEndian))
+
+	// Read the function offsets, then the string table
+	offsets := []int{}
+	for i := 0; i < numFuncs && r.err == nil; i++ {
+		offsets = append(offsets, int(r.u32(binary.LittleEndian)))
+	}
+	strs := r.strtab()
+	if r.err != nil {
+		return nil, r.err
+	}
+	str := func(idx uint64) (string, error) {
+		if idx >= uint64(len(strs)) {
+			return "", ErrBadIndex
+		}
+		return strs[idx], nil
+	}
+
+	// Now read the functions
+	funcs := make([]funcDesc, 0, len(offsets))
+	for _, off := range offsets {
+		r.seek(off)
+		numUnits := r.uleb()
+		_ = r.uleb() // function name
+		file, err := str(r.uleb())
+		if r.err != nil {
+			return nil, r.err
+		} else if err != nil {
+			return nil, err
+		}
+
+		fd := funcDesc{file: file}
+		for i := uint64(0); i < numUnits && r.err == nil; i++ {
+			fd.units = append(fd.units, unit{
+				stLine:  int(r.uleb()),
+				stCol:   int(r.uleb()),
+				enLine:  int(r.uleb()),
+				enCol:   int(r.uleb()),
+				numStmt: int(r.uleb()),
+			})
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+
+		funcs = append(funcs, fd)
+	}
+
+	return funcs, nil
+}
+
+// parseMeta parses a meta-data file.
+func parseMeta(data []byte) (*metaFile, error) {
+	// Read the file header
+	r := newReader(data)
+	if err := checkMagic(r, metaMagic); err != nil {
+		return nil, err
+	}
+	if version := r.u32(binary.LittleEndian); r.err == nil && version > metaVersion {
+		return nil, fmt.Errorf("%w %d", ErrBadVersion, version)
+	}
+	_ = r.u64(binary.LittleEndian) // total length
+	entries := r.u64(binary.LittleEndian)
+	hash := hex.EncodeToString(r.bytes(16))
+	_ = r.u32(binary.LittleEndian) // string table offset
+	_ = r.u32(binary.LittleEndian) // string table length
+	mode := int(r.u8())
+	r.seek(metaHeaderSize)
+	if r.err != nil {
+		return nil, r.err
+	}
+	if entries > uint64(len(data)) {
+		return nil, ErrTruncated
+	}
+
+	// Read the package offsets and lengths
+	offsets := make([]int, entries)
+	for i := range offsets {
+		offsets[i] = int(r.u64(binary.LittleEndian))
+	}
+	lengths := make([]int, entries)
+	for i := range lengths {
+		lengths[i] = int(r.u64(binary.LittleEndian))
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	// Construct the result
+	mf := &metaFile{
+		hash:  hash,
+		count: map[funcKey][]int{},
+	}
+	if mode < len(modes) {
+		mf.mode = modes[mode]
+	}
+
+	// Parse the packages
+	for i, off := range offsets {
+		if off < 0 || lengths[i] < 0 || off+lengths[i] > len(data) {
+			return nil, ErrTruncated
+		}
+		funcs, err := parsePackage(data[off : off+lengths[i]])
+		if err != nil {
+			return nil, err
+		}
+		mf.pkgs = append(mf.pkgs, funcs)
+	}
+
+	return mf, nil
+}
+
+// parseCounters parses a counter data file, returning the hash
+// identifying the corresponding meta-data file and the counters for
+// each function.
+func parseCounters(data []byte) (string, []payload, error) {
+	// Read the file header
+	r := newReader(data)
+	if err := checkMagic(r, counterMagic); err != nil {
+		return "", nil, err
+	}
+	if version := r.u32(binary.LittleEndian); r.err == nil && version > counterVersion {
+		return "", nil, fmt.Errorf("%w %d", ErrBadVersion, version)
+	}
+	hash := hex.EncodeToString(r.bytes(16))
+	flavor := r.u8()
+	var order binary.ByteOrder = binary.LittleEndian
+	if r.u8() != 0 {
+		order = binary.BigEndian
+	}
+	r.seek(counterHeaderSize)
+	if r.err != nil {
+		return "", nil, r.err
+	}
+
+	// Select the decoder for counter values
+	var value func() uint32
+	switch flavor {
+	case flavorRaw:
+		value = func() uint32 { return r.u32(order) }
+	case flavorULeb128:
+		value = func() uint32 { return uint32(r.uleb()) }
+	default:
+		return "", nil, fmt.Errorf("%w %d", ErrBadFlavor, flavor)
+	}
+
+	// Read the footer to determine the number of segments
+	if len(data) < counterHeaderSize+counterFooterSize {
+		return "", nil, ErrTruncated
+	}
+	footer := newReader(data[len(data)-counterFooterSize:])
+	if err := checkMagic(footer, counterMagic); err != nil {
+		return "", nil, err
+	}
+	_ = footer.u32(binary.LittleEndian) // padding
+	numSegments := int(footer.u32(binary.LittleEndian))
+
+	// Read each segment
+	var result []payload
+	for seg := 0; seg < numSegments; seg++ {
+		// Each segment after the first follows a footer
+		if seg > 0 {
+			r.seek(r.off + counterFooterSize)
+		}
+
+		// Read the segment header, skipping the string and
+		// argument tables
+		numFuncs := r.u64(binary.LittleEndian)
+		strLen := int(r.u32(binary.LittleEndian))
+		argsLen := int(r.u32(binary.LittleEndian))
+		r.bytes(strLen)
+		r.bytes(argsLen)
+		r.align(4)
+
+		// Read the counters for each function
+		for i := uint64(0); i < numFuncs && r.err == nil; i++ {
+			numCtrs := value()
+			key := funcKey{pkg: value(), fn: value()}
+			counters := []int{}
+			for j := uint32(0); j < numCtrs && r.err == nil; j++ {
+				counters = append(counters, int(value()))
+			}
+			if r.err == nil {
+				result = append(result, payload{key: key, counters: counters})
+			}
+		}
+		if r.err != nil {
+			return "", nil, r.err
+		}
+	}
+
+	return hash, result, nil
+}
+
+// accumulate merges a set of counters for a function into the
+// counters accumulated so far.
+func (mf *metaFile) accumulate(p payload) {
+	prev, ok := mf.count[p.key]
+	if !ok {
+		mf.count[p.key] = p.counters
+		return
+	}
+
+	for i, c := range p.counters {
+		if i >= len(prev) {
+			prev = append(prev, c)
+		} else if mf.mode == "set" {
+			if c != 0 {
+				prev[i] = 1
+			}
+		} else {
+			prev[i] += c
+		}
+	}
+	mf.count[p.key] = prev
+}
+
+// profiles converts the data in the meta-data file, together with
+// the accumulated counters, into a list of profiles.
+func (mf *metaFile) profiles() []*cover.Profile {
+	idx := map[string]*cover.Profile{}
+	var result []*cover.Profile
+	for pkg, funcs := range mf.pkgs {
+		for fn, fd := range funcs {
+			// Find the profile for the file
+			prof, ok := idx[fd.file]
+			if !ok {
+				prof = &cover.Profile{
+					FileName: fd.file,
+					Mode:     mf.mode,
+				}
+				idx[fd.file] = prof
+				result = append(result, prof)
+			}
+
+			// Add the blocks
+			counters := mf.count[funcKey{pkg: uint32(pkg), fn: uint32(fn)}]
+			for i, u := range fd.units {
+				blk := cover.ProfileBlock{
+					StartLine: u.stLine,
+					StartCol:  u.stCol,
+					EndLine:   u.enLine,
+					EndCol:    u.enCol,
+					NumStmt:   u.numStmt,
+				}
+				if i < len(counters) {
+					blk.Count = counters[i]
+				}
+				prof.Blocks = append(prof.Blocks, blk)
+			}
+		}
+	}
+
+	// Sort the blocks in each profile
+	for _, prof := range result {
+		sort.Slice(prof.Blocks, func(i, j int) bool {
+			bi, bj := prof.Blocks[i], prof.Blocks[j]
+			return bi.StartLine < bj.StartLine || (bi.StartLine == bj.StartLine && bi.StartCol < bj.StartCol)
+		})
+	}
+
+	return result
+}
+
+// Load loads the binary coverage data files in the specified
+// directory and returns a list of profiles.  Counter data files are
+// matched to the meta-data files that describe them, and the
+// counters from multiple runs are merged.
+func Load(dir string) ([]*cover.Profile, error) {
+	ents, err := readDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read the meta-data files first
+	metas := map[string]*metaFile{}
+	var order []*metaFile
+	for _, ent := range ents {
+		if ent.IsDir() || !strings.HasPrefix(ent.Name(), metaPrefix) {
+			continue
+		}
+
+		fname := filepath.Join(dir, ent.Name())
+		data, err := readFile(fname)
+		if err != nil {
+			return nil, err
+		}
+		mf, err := parseMeta(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fname, err)
+		}
+		if _, ok := metas[mf.hash]; !ok {
+			metas[mf.hash] = mf
+			order = append(order, mf)
+		}
+	}
+	if len(order) == 0 {
+		return nil, ErrNoData
+	}
+
+	// Now read the counter data files
+	for _, ent := range ents {
+		if ent.IsDir() || !strings.HasPrefix(ent.Name(), counterPrefix) {
+			continue
+		}
+
+		fname := filepath.Join(dir, ent.Name())
+		data, err := readFile(fname)
+		if err != nil {
+			return nil, err
+		}
+		hash, payloads, err := parseCounters(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fname, err)
+		}
+
+		// Counters without meta-data are ignored
+		if mf, ok := metas[hash]; ok {
+			for _, p := range payloads {
+				mf.accumulate(p)
+			}
+		}
+	}
+
+	// Assemble the profiles
+	var result []*cover.Profile
+	for _, mf := range order {
+		result = append(result, mf.profiles()...)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].FileName < result[j].FileName
+	})
+
+	return result, nil
+}
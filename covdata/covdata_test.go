@@ -0,0 +1,726 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package covdata
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klmitch/patcher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/cover"
+)
+
+var testHash = [16]byte{0xde, 0xad, 0xbe, 0xef}
+
+const testHashHex = "deadbeef000000000000000000000000"
+
+func encULEB(v uint64) []byte {
+	var result []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(result, b)
+		}
+		result = append(result, b|0x80)
+	}
+}
+
+func encU32(order binary.ByteOrder, v uint32) []byte {
+	result := make([]byte, 4)
+	order.PutUint32(result, v)
+	return result
+}
+
+func encU64(v uint64) []byte {
+	return binary.LittleEndian.AppendUint64(nil, v)
+}
+
+func encPkg(file string, funcs [][]unit) []byte {
+	// Construct the string table
+	strtab := append(encULEB(1), encULEB(uint64(len(file)))...)
+	strtab = append(strtab, file...)
+
+	// Encode the functions
+	base := pkgHeaderSize + 4*len(funcs) + len(strtab)
+	var offsets []byte
+	var body []byte
+	for _, units := range funcs {
+		offsets = append(offsets, encU32(binary.LittleEndian, uint32(base+len(body)))...)
+		body = append(body, encULEB(uint64(len(units)))...)
+		body = append(body, encULEB(0)...)
+		body = append(body, encULEB(0)...)
+		for _, u := range units {
+			body = append(body, encULEB(uint64(u.stLine))...)
+			body = append(body, encULEB(uint64(u.stCol))...)
+			body = append(body, encULEB(uint64(u.enLine))...)
+			body = append(body, encULEB(uint64(u.enCol))...)
+			body = append(body, encULEB(uint64(u.numStmt))...)
+		}
+		body = append(body, encULEB(0)...)
+	}
+
+	// Assemble the header
+	hdr := make([]byte, pkgHeaderSize)
+	binary.LittleEndian.PutUint32(hdr[0:], uint32(base+len(body)))
+	binary.LittleEndian.PutUint32(hdr[36:], 1)
+	binary.LittleEndian.PutUint32(hdr[40:], uint32(len(funcs)))
+
+	result := append(hdr, offsets...)
+	result = append(result, strtab...)
+	return append(result, body...)
+}
+
+func encMeta(version uint32, mode uint8, pkgs ...[]byte) []byte {
+	strtab := []byte{0}
+	result := append([]byte{}, metaMagic...)
+	result = append(result, encU32(binary.LittleEndian, version)...)
+	result = append(result, encU64(0)...)
+	result = append(result, encU64(uint64(len(pkgs)))...)
+	result = append(result, testHash[:]...)
+	result = append(result, encU32(binary.LittleEndian, 0)...)
+	result = append(result, encU32(binary.LittleEndian, uint32(len(strtab)))...)
+	result = append(result, mode, 1, 0, 0, 0, 0, 0, 0)
+
+	// Compute offsets
+	off := metaHeaderSize + 16*len(pkgs) + len(strtab)
+	var lengths []byte
+	for _, pkg := range pkgs {
+		result = append(result, encU64(uint64(off))...)
+		lengths = append(lengths, encU64(uint64(len(pkg)))...)
+		off += len(pkg)
+	}
+	result = append(result, lengths...)
+	result = append(result, strtab...)
+	for _, pkg := range pkgs {
+		result = append(result, pkg...)
+	}
+
+	return result
+}
+
+func encCounters(flavor uint8, bigEndian bool, segs ...[]payload) []byte {
+	var order binary.ByteOrder = binary.LittleEndian
+	result := append([]byte{}, counterMagic...)
+	result = append(result, encU32(binary.LittleEndian, counterVersion)...)
+	result = append(result, testHash[:]...)
+	result = append(result, flavor, 0, 0, 0, 0, 0, 0, 0)
+	if bigEndian {
+		result[25] = 1
+		order = binary.BigEndian
+	}
+	value := func(v uint32) []byte {
+		if flavor == flavorULeb128 {
+			return encULEB(uint64(v))
+		}
+		return encU32(order, v)
+	}
+
+	for i, seg := range segs {
+		result = append(result, encU64(uint64(len(seg)))...)
+		result = append(result, encU32(binary.LittleEndian, 2)...)
+		result = append(result, encU32(binary.LittleEndian, 2)...)
+		result = append(result, 1, 0, 0, 0)
+		for _, p := range seg {
+			result = append(result, value(uint32(len(p.counters)))...)
+			result = append(result, value(p.key.pkg)...)
+			result = append(result, value(p.key.fn)...)
+			for _, c := range p.counters {
+				result = append(result, value(uint32(c))...)
+			}
+		}
+		result = append(result, counterMagic...)
+		result = append(result, encU32(binary.LittleEndian, 0)...)
+		result = append(result, encU32(binary.LittleEndian, uint32(i+1))...)
+		result = append(result, encU32(binary.LittleEndian, 0)...)
+	}
+
+	return result
+}
+
+func TestCheckMagicBase(t *testing.T) {
+	r := newReader([]byte{0x00, 0x63, 0x76, 0x6d})
+
+	err := checkMagic(r, metaMagic)
+
+	assert.NoError(t, err)
+}
+
+func TestCheckMagicMismatch(t *testing.T) {
+	r := newReader([]byte{0x00, 0x63, 0x77, 0x6d})
+
+	err := checkMagic(r, metaMagic)
+
+	assert.Same(t, ErrBadMagic, err)
+}
+
+func TestCheckMagicTruncated(t *testing.T) {
+	r := newReader([]byte{0x00, 0x63})
+
+	err := checkMagic(r, metaMagic)
+
+	assert.Same(t, ErrTruncated, err)
+}
+
+func TestParsePackageBase(t *testing.T) {
+	data := encPkg("example.com/pkg/file.go", [][]unit{
+		{
+			{stLine: 1, stCol: 2, enLine: 3, enCol: 4, numStmt: 5},
+			{stLine: 6, stCol: 7, enLine: 8, enCol: 9, numStmt: 10},
+		},
+		{
+			{stLine: 11, stCol: 12, enLine: 13, enCol: 14, numStmt: 15},
+		},
+	})
+
+	result, err := parsePackage(data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []funcDesc{
+		{
+			file: "example.com/pkg/file.go",
+			units: []unit{
+				{stLine: 1, stCol: 2, enLine: 3, enCol: 4, numStmt: 5},
+				{stLine: 6, stCol: 7, enLine: 8, enCol: 9, numStmt: 10},
+			},
+		},
+		{
+			file: "example.com/pkg/file.go",
+			units: []unit{
+				{stLine: 11, stCol: 12, enLine: 13, enCol: 14, numStmt: 15},
+			},
+		},
+	}, result)
+}
+
+func TestParsePackageTruncatedHeader(t *testing.T) {
+	data := encPkg("example.com/pkg/file.go", [][]unit{{{stLine: 1}}})
+
+	result, err := parsePackage(data[:pkgHeaderSize])
+
+	assert.Same(t, ErrTruncated, err)
+	assert.Nil(t, result)
+}
+
+func TestParsePackageTruncatedFunc(t *testing.T) {
+	data := encPkg("example.com/pkg/file.go", [][]unit{{{stLine: 1}}})
+
+	result, err := parsePackage(data[:len(data)-8])
+
+	assert.Same(t, ErrTruncated, err)
+	assert.Nil(t, result)
+}
+
+func TestParsePackageTruncatedUnits(t *testing.T) {
+	data := encPkg("example.com/pkg/file.go", [][]unit{{{stLine: 1}, {stLine: 2}}})
+
+	result, err := parsePackage(data[:len(data)-4])
+
+	assert.Same(t, ErrTruncated, err)
+	assert.Nil(t, result)
+}
+
+func TestParsePackageBadIndex(t *testing.T) {
+	data := encPkg("example.com/pkg/file.go", [][]unit{{{stLine: 1}}})
+	data[len(data)-7] = 1 // file index of the function
+
+	result, err := parsePackage(data)
+
+	assert.Same(t, ErrBadIndex, err)
+	assert.Nil(t, result)
+}
+
+func TestParseMetaBase(t *testing.T) {
+	data := encMeta(1, 2,
+		encPkg("example.com/pkg1/file.go", [][]unit{{{stLine: 1, numStmt: 1}}}),
+		encPkg("example.com/pkg2/file.go", [][]unit{{{stLine: 2, numStmt: 2}}}),
+	)
+
+	result, err := parseMeta(data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &metaFile{
+		hash: testHashHex,
+		mode: "count",
+		pkgs: [][]funcDesc{
+			{{file: "example.com/pkg1/file.go", units: []unit{{stLine: 1, numStmt: 1}}}},
+			{{file: "example.com/pkg2/file.go", units: []unit{{stLine: 2, numStmt: 2}}}},
+		},
+		count: map[funcKey][]int{},
+	}, result)
+}
+
+func TestParseMetaUnknownMode(t *testing.T) {
+	data := encMeta(1, 42)
+
+	result, err := parseMeta(data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "", result.mode)
+}
+
+func TestParseMetaBadMagic(t *testing.T) {
+	data := encMeta(1, 2)
+	data[3] = 0
+
+	result, err := parseMeta(data)
+
+	assert.Same(t, ErrBadMagic, err)
+	assert.Nil(t, result)
+}
+
+func TestParseMetaBadVersion(t *testing.T) {
+	data := encMeta(2, 2)
+
+	result, err := parseMeta(data)
+
+	assert.ErrorIs(t, err, ErrBadVersion)
+	assert.Nil(t, result)
+}
+
+func TestParseMetaTruncatedHeader(t *testing.T) {
+	data := encMeta(1, 2)
+
+	result, err := parseMeta(data[:metaHeaderSize-1])
+
+	assert.Same(t, ErrTruncated, err)
+	assert.Nil(t, result)
+}
+
+func TestParseMetaTooManyEntries(t *testing.T) {
+	data := encMeta(1, 2)
+	binary.LittleEndian.PutUint64(data[16:], 1<<40)
+
+	result, err := parseMeta(data)
+
+	assert.Same(t, ErrTruncated, err)
+	assert.Nil(t, result)
+}
+
+func TestParseMetaTruncatedOffsets(t *testing.T) {
+	data := encMeta(1, 2, encPkg("example.com/pkg1/file.go", nil))
+
+	result, err := parseMeta(data[:metaHeaderSize+8])
+
+	assert.Same(t, ErrTruncated, err)
+	assert.Nil(t, result)
+}
+
+func TestParseMetaTruncatedPackage(t *testing.T) {
+	data := encMeta(1, 2, encPkg("example.com/pkg1/file.go", nil))
+
+	result, err := parseMeta(data[:len(data)-1])
+
+	assert.Same(t, ErrTruncated, err)
+	assert.Nil(t, result)
+}
+
+func TestParseMetaBadPackage(t *testing.T) {
+	pkg := encPkg("example.com/pkg1/file.go", [][]unit{{{stLine: 1}}})
+	pkg[len(pkg)-7] = 1 // file index of the function
+	data := encMeta(1, 2, pkg)
+
+	result, err := parseMeta(data)
+
+	assert.Same(t, ErrBadIndex, err)
+	assert.Nil(t, result)
+}
+
+func TestParseCountersRaw(t *testing.T) {
+	data := encCounters(flavorRaw, false,
+		[]payload{
+			{key: funcKey{pkg: 0, fn: 0}, counters: []int{1, 2}},
+			{key: funcKey{pkg: 1, fn: 3}, counters: []int{3}},
+		},
+		[]payload{
+			{key: funcKey{pkg: 0, fn: 0}, counters: []int{4, 5}},
+		},
+	)
+
+	hash, result, err := parseCounters(data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, testHashHex, hash)
+	assert.Equal(t, []payload{
+		{key: funcKey{pkg: 0, fn: 0}, counters: []int{1, 2}},
+		{key: funcKey{pkg: 1, fn: 3}, counters: []int{3}},
+		{key: funcKey{pkg: 0, fn: 0}, counters: []int{4, 5}},
+	}, result)
+}
+
+func TestParseCountersRawBigEndian(t *testing.T) {
+	data := encCounters(flavorRaw, true,
+		[]payload{
+			{key: funcKey{pkg: 0, fn: 1}, counters: []int{1, 258}},
+		},
+	)
+
+	hash, result, err := parseCounters(data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, testHashHex, hash)
+	assert.Equal(t, []payload{
+		{key: funcKey{pkg: 0, fn: 1}, counters: []int{1, 258}},
+	}, result)
+}
+
+func TestParseCountersULeb128(t *testing.T) {
+	data := encCounters(flavorULeb128, false,
+		[]payload{
+			{key: funcKey{pkg: 0, fn: 1}, counters: []int{1, 300}},
+		},
+	)
+
+	hash, result, err := parseCounters(data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, testHashHex, hash)
+	assert.Equal(t, []payload{
+		{key: funcKey{pkg: 0, fn: 1}, counters: []int{1, 300}},
+	}, result)
+}
+
+func TestParseCountersBadMagic(t *testing.T) {
+	data := encCounters(flavorRaw, false, nil)
+	data[3] = 0
+
+	hash, result, err := parseCounters(data)
+
+	assert.Same(t, ErrBadMagic, err)
+	assert.Equal(t, "", hash)
+	assert.Nil(t, result)
+}
+
+func TestParseCountersBadVersion(t *testing.T) {
+	data := encCounters(flavorRaw, false, nil)
+	data[4] = 2
+
+	hash, result, err := parseCounters(data)
+
+	assert.ErrorIs(t, err, ErrBadVersion)
+	assert.Equal(t, "", hash)
+	assert.Nil(t, result)
+}
+
+func TestParseCountersTruncatedHeader(t *testing.T) {
+	data := encCounters(flavorRaw, false, nil)
+
+	hash, result, err := parseCounters(data[:counterHeaderSize-1])
+
+	assert.Same(t, ErrTruncated, err)
+	assert.Equal(t, "", hash)
+	assert.Nil(t, result)
+}
+
+func TestParseCountersBadFlavor(t *testing.T) {
+	data := encCounters(3, false, nil)
+
+	hash, result, err := parseCounters(data)
+
+	assert.ErrorIs(t, err, ErrBadFlavor)
+	assert.Equal(t, "", hash)
+	assert.Nil(t, result)
+}
+
+func TestParseCountersNoFooter(t *testing.T) {
+	data := encCounters(flavorRaw, false)
+
+	hash, result, err := parseCounters(data)
+
+	assert.Same(t, ErrTruncated, err)
+	assert.Equal(t, "", hash)
+	assert.Nil(t, result)
+}
+
+func TestParseCountersBadFooter(t *testing.T) {
+	data := encCounters(flavorRaw, false, nil)
+	data[len(data)-counterFooterSize+3] = 0
+
+	hash, result, err := parseCounters(data)
+
+	assert.Same(t, ErrBadMagic, err)
+	assert.Equal(t, "", hash)
+	assert.Nil(t, result)
+}
+
+func TestParseCountersTruncatedSegment(t *testing.T) {
+	data := encCounters(flavorRaw, false,
+		[]payload{
+			{key: funcKey{pkg: 0, fn: 1}, counters: []int{1, 2}},
+		},
+	)
+	binary.LittleEndian.PutUint32(data[counterHeaderSize+8:], 1000)
+
+	hash, result, err := parseCounters(data)
+
+	assert.Same(t, ErrTruncated, err)
+	assert.Equal(t, "", hash)
+	assert.Nil(t, result)
+}
+
+func TestMetaFileAccumulateNew(t *testing.T) {
+	obj := &metaFile{
+		mode:  "count",
+		count: map[funcKey][]int{},
+	}
+
+	obj.accumulate(payload{key: funcKey{pkg: 1, fn: 2}, counters: []int{1, 2}})
+
+	assert.Equal(t, map[funcKey][]int{
+		{pkg: 1, fn: 2}: {1, 2},
+	}, obj.count)
+}
+
+func TestMetaFileAccumulateCount(t *testing.T) {
+	obj := &metaFile{
+		mode: "count",
+		count: map[funcKey][]int{
+			{pkg: 1, fn: 2}: {1, 0},
+		},
+	}
+
+	obj.accumulate(payload{key: funcKey{pkg: 1, fn: 2}, counters: []int{1, 2, 3}})
+
+	assert.Equal(t, map[funcKey][]int{
+		{pkg: 1, fn: 2}: {2, 2, 3},
+	}, obj.count)
+}
+
+func TestMetaFileAccumulateSet(t *testing.T) {
+	obj := &metaFile{
+		mode: "set",
+		count: map[funcKey][]int{
+			{pkg: 1, fn: 2}: {1, 0, 0},
+		},
+	}
+
+	obj.accumulate(payload{key: funcKey{pkg: 1, fn: 2}, counters: []int{1, 1, 0}})
+
+	assert.Equal(t, map[funcKey][]int{
+		{pkg: 1, fn: 2}: {1, 1, 0},
+	}, obj.count)
+}
+
+func TestMetaFileProfiles(t *testing.T) {
+	obj := &metaFile{
+		mode: "set",
+		pkgs: [][]funcDesc{
+			{
+				{file: "example.com/pkg1/b.go", units: []unit{
+					{stLine: 5, stCol: 1, enLine: 6, enCol: 2, numStmt: 1},
+					{stLine: 1, stCol: 3, enLine: 2, enCol: 4, numStmt: 2},
+				}},
+				{file: "example.com/pkg1/a.go", units: []unit{
+					{stLine: 1, stCol: 1, enLine: 2, enCol: 2, numStmt: 3},
+				}},
+				{file: "example.com/pkg1/b.go", units: []unit{
+					{stLine: 1, stCol: 1, enLine: 1, enCol: 2, numStmt: 4},
+				}},
+			},
+		},
+		count: map[funcKey][]int{
+			{pkg: 0, fn: 0}: {1},
+			{pkg: 0, fn: 2}: {1},
+		},
+	}
+
+	result := obj.profiles()
+
+	assert.Equal(t, []*cover.Profile{
+		{
+			FileName: "example.com/pkg1/b.go",
+			Mode:     "set",
+			Blocks: []cover.ProfileBlock{
+				{StartLine: 1, StartCol: 1, EndLine: 1, EndCol: 2, NumStmt: 4, Count: 1},
+				{StartLine: 1, StartCol: 3, EndLine: 2, EndCol: 4, NumStmt: 2, Count: 0},
+				{StartLine: 5, StartCol: 1, EndLine: 6, EndCol: 2, NumStmt: 1, Count: 1},
+			},
+		},
+		{
+			FileName: "example.com/pkg1/a.go",
+			Mode:     "set",
+			Blocks: []cover.ProfileBlock{
+				{StartLine: 1, StartCol: 1, EndLine: 2, EndCol: 2, NumStmt: 3, Count: 0},
+			},
+		},
+	}, result)
+}
+
+func TestLoadGolden(t *testing.T) {
+	expected, err := cover.ParseProfiles(filepath.Join("testdata", "count.out"))
+	require.NoError(t, err)
+
+	result, err := Load(filepath.Join("testdata", "count"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func writeFiles(t *testing.T, files map[string][]byte) string {
+	dir := t.TempDir()
+	for name, data := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0o600))
+	}
+	return dir
+}
+
+func TestLoadSynthetic(t *testing.T) {
+	dir := writeFiles(t, map[string][]byte{
+		"covmeta." + testHashHex: encMeta(1, 2,
+			encPkg("example.com/pkg/b.go", [][]unit{{{stLine: 1, numStmt: 1}, {stLine: 2, numStmt: 2}}}),
+			encPkg("example.com/pkg/a.go", [][]unit{{{stLine: 3, numStmt: 3}}}),
+		),
+		"covmeta.zz": encMeta(1, 2),
+		"covcounters." + testHashHex + ".1.1": encCounters(flavorULeb128, false, []payload{
+			{key: funcKey{pkg: 0, fn: 0}, counters: []int{1, 0}},
+		}),
+		"covcounters." + testHashHex + ".2.2": encCounters(flavorULeb128, false, []payload{
+			{key: funcKey{pkg: 0, fn: 0}, counters: []int{1, 1}},
+		}),
+		"other": []byte("not a coverage file"),
+	})
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "covmeta.dir"), 0o700))
+
+	result, err := Load(dir)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*cover.Profile{
+		{
+			FileName: "example.com/pkg/a.go",
+			Mode:     "count",
+			Blocks: []cover.ProfileBlock{
+				{StartLine: 3, NumStmt: 3},
+			},
+		},
+		{
+			FileName: "example.com/pkg/b.go",
+			Mode:     "count",
+			Blocks: []cover.ProfileBlock{
+				{StartLine: 1, NumStmt: 1, Count: 2},
+				{StartLine: 2, NumStmt: 2, Count: 1},
+			},
+		},
+	}, result)
+}
+
+func TestLoadOrphanCounters(t *testing.T) {
+	counters := encCounters(flavorULeb128, false, []payload{
+		{key: funcKey{pkg: 0, fn: 0}, counters: []int{1}},
+	})
+	counters[8] = 0 // alter the hash
+	dir := writeFiles(t, map[string][]byte{
+		"covmeta." + testHashHex: encMeta(1, 1,
+			encPkg("example.com/pkg/a.go", [][]unit{{{stLine: 3, numStmt: 3}}}),
+		),
+		"covcounters.0.1.1": counters,
+	})
+
+	result, err := Load(dir)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*cover.Profile{
+		{
+			FileName: "example.com/pkg/a.go",
+			Mode:     "set",
+			Blocks: []cover.ProfileBlock{
+				{StartLine: 3, NumStmt: 3},
+			},
+		},
+	}, result)
+}
+
+func TestLoadReadDirError(t *testing.T) {
+	defer patcher.SetVar(&readDir, func(dir string) ([]os.DirEntry, error) {
+		assert.Equal(t, "covdir", dir)
+		return nil, assert.AnError
+	}).Install().Restore()
+
+	result, err := Load("covdir")
+
+	assert.Same(t, assert.AnError, err)
+	assert.Nil(t, result)
+}
+
+func TestLoadNoData(t *testing.T) {
+	dir := writeFiles(t, map[string][]byte{
+		"other": []byte("not a coverage file"),
+	})
+
+	result, err := Load(dir)
+
+	assert.Same(t, ErrNoData, err)
+	assert.Nil(t, result)
+}
+
+func TestLoadReadMetaError(t *testing.T) {
+	dir := writeFiles(t, map[string][]byte{
+		"covmeta." + testHashHex: encMeta(1, 1),
+	})
+	defer patcher.SetVar(&readFile, func(_ string) ([]byte, error) {
+		return nil, assert.AnError
+	}).Install().Restore()
+
+	result, err := Load(dir)
+
+	assert.Same(t, assert.AnError, err)
+	assert.Nil(t, result)
+}
+
+func TestLoadBadMeta(t *testing.T) {
+	dir := writeFiles(t, map[string][]byte{
+		"covmeta." + testHashHex: []byte("bad"),
+	})
+
+	result, err := Load(dir)
+
+	assert.ErrorIs(t, err, ErrTruncated)
+	assert.Contains(t, err.Error(), "covmeta."+testHashHex+": ")
+	assert.Nil(t, result)
+}
+
+func TestLoadReadCountersError(t *testing.T) {
+	dir := writeFiles(t, map[string][]byte{
+		"covmeta." + testHashHex:              encMeta(1, 1),
+		"covcounters." + testHashHex + ".1.1": encCounters(flavorRaw, false, nil),
+	})
+	defer patcher.SetVar(&readFile, func(fname string) ([]byte, error) {
+		if filepath.Base(fname) == "covmeta."+testHashHex {
+			return encMeta(1, 1), nil
+		}
+		return nil, assert.AnError
+	}).Install().Restore()
+
+	result, err := Load(dir)
+
+	assert.Same(t, assert.AnError, err)
+	assert.Nil(t, result)
+}
+
+func TestLoadBadCounters(t *testing.T) {
+	dir := writeFiles(t, map[string][]byte{
+		"covmeta." + testHashHex:              encMeta(1, 1),
+		"covcounters." + testHashHex + ".1.1": []byte("bad"),
+	})
+
+	result, err := Load(dir)
+
+	assert.ErrorIs(t, err, ErrTruncated)
+	assert.Contains(t, err.Error(), "covcounters."+testHashHex+".1.1: ")
+	assert.Nil(t, result)
+}
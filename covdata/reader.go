@@ -0,0 +1,131 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package covdata
+
+import "encoding/binary"
+
+// reader is a simple helper for decoding the binary coverage data
+// files.  It reads from a byte slice; any attempt to read beyond the
+// end of the slice sets a sticky error and returns zero values, so
+// that callers need only check the error once they've finished
+// decoding a structure.
+type reader struct {
+	data []byte // The data being decoded
+	off  int    // The current offset
+	err  error  // Sticky error
+}
+
+// newReader constructs a reader for the specified data.
+func newReader(data []byte) *reader {
+	return &reader{
+		data: data,
+	}
+}
+
+// seek sets the offset of the reader.
+func (r *reader) seek(off int) {
+	if off < 0 || off > len(r.data) {
+		r.fail()
+		return
+	}
+
+	r.off = off
+}
+
+// align advances the offset of the reader to the next multiple of
+// the specified size.
+func (r *reader) align(size int) {
+	if rem := r.off % size; rem != 0 {
+		r.seek(r.off + size - rem)
+	}
+}
+
+// fail records a truncation error.
+func (r *reader) fail() {
+	if r.err == nil {
+		r.err = ErrTruncated
+	}
+	r.off = len(r.data)
+}
+
+// bytes reads the specified number of bytes.
+func (r *reader) bytes(n int) []byte {
+	if n < 0 || r.off+n > len(r.data) {
+		r.fail()
+		if n < 0 {
+			return nil
+		}
+
+		// Enough zeros for the fixed-size readers
+		return make([]byte, min(n, 16))
+	}
+
+	result := r.data[r.off : r.off+n]
+	r.off += n
+	return result
+}
+
+// u8 reads a single byte.
+func (r *reader) u8() uint8 {
+	return r.bytes(1)[0]
+}
+
+// u32 reads a fixed-size 32-bit unsigned integer in the specified
+// byte order.
+func (r *reader) u32(order binary.ByteOrder) uint32 {
+	return order.Uint32(r.bytes(4))
+}
+
+// u64 reads a fixed-size 64-bit unsigned integer in the specified
+// byte order.
+func (r *reader) u64(order binary.ByteOrder) uint64 {
+	return order.Uint64(r.bytes(8))
+}
+
+// uleb reads an unsigned LEB128-encoded integer.
+func (r *reader) uleb() uint64 {
+	var value uint64
+	var shift uint
+	for {
+		b := r.u8()
+		if r.err != nil {
+			return 0
+		}
+		value |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return value
+		}
+		shift += 7
+
+		// Guard against corrupt data
+		if shift >= 64 {
+			r.fail()
+			return 0
+		}
+	}
+}
+
+// strtab reads a string table, which consists of a LEB128-encoded
+// count of strings, followed by each string, encoded as a
+// LEB128-encoded length followed by the string data.
+func (r *reader) strtab() []string {
+	n := r.uleb()
+	var result []string
+	for i := uint64(0); i < n && r.err == nil; i++ {
+		result = append(result, string(r.bytes(int(r.uleb()))))
+	}
+
+	return result
+}
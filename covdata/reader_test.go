@@ -0,0 +1,198 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package covdata
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewReader(t *testing.T) {
+	data := []byte{1, 2, 3}
+
+	result := newReader(data)
+
+	assert.Equal(t, &reader{data: data}, result)
+}
+
+func TestReaderSeekBase(t *testing.T) {
+	obj := newReader([]byte{1, 2, 3})
+
+	obj.seek(2)
+
+	assert.Equal(t, 2, obj.off)
+	assert.NoError(t, obj.err)
+}
+
+func TestReaderSeekEnd(t *testing.T) {
+	obj := newReader([]byte{1, 2, 3})
+
+	obj.seek(3)
+
+	assert.Equal(t, 3, obj.off)
+	assert.NoError(t, obj.err)
+}
+
+func TestReaderSeekBeyond(t *testing.T) {
+	obj := newReader([]byte{1, 2, 3})
+
+	obj.seek(4)
+
+	assert.Equal(t, 3, obj.off)
+	assert.Same(t, ErrTruncated, obj.err)
+}
+
+func TestReaderSeekNegative(t *testing.T) {
+	obj := newReader([]byte{1, 2, 3})
+
+	obj.seek(-1)
+
+	assert.Equal(t, 3, obj.off)
+	assert.Same(t, ErrTruncated, obj.err)
+}
+
+func TestReaderAlignAligned(t *testing.T) {
+	obj := newReader(make([]byte, 8))
+	obj.off = 4
+
+	obj.align(4)
+
+	assert.Equal(t, 4, obj.off)
+	assert.NoError(t, obj.err)
+}
+
+func TestReaderAlignUnaligned(t *testing.T) {
+	obj := newReader(make([]byte, 8))
+	obj.off = 5
+
+	obj.align(4)
+
+	assert.Equal(t, 8, obj.off)
+	assert.NoError(t, obj.err)
+}
+
+func TestReaderFailKeepsFirst(t *testing.T) {
+	obj := newReader([]byte{1, 2, 3})
+	obj.err = assert.AnError
+
+	obj.fail()
+
+	assert.Same(t, assert.AnError, obj.err)
+	assert.Equal(t, 3, obj.off)
+}
+
+func TestReaderBytesBase(t *testing.T) {
+	obj := newReader([]byte{1, 2, 3})
+
+	result := obj.bytes(2)
+
+	assert.Equal(t, []byte{1, 2}, result)
+	assert.Equal(t, 2, obj.off)
+	assert.NoError(t, obj.err)
+}
+
+func TestReaderBytesShort(t *testing.T) {
+	obj := newReader([]byte{1, 2, 3})
+
+	result := obj.bytes(4)
+
+	assert.Equal(t, []byte{0, 0, 0, 0}, result)
+	assert.Equal(t, 3, obj.off)
+	assert.Same(t, ErrTruncated, obj.err)
+}
+
+func TestReaderBytesNegative(t *testing.T) {
+	obj := newReader([]byte{1, 2, 3})
+
+	result := obj.bytes(-1)
+
+	assert.Nil(t, result)
+	assert.Same(t, ErrTruncated, obj.err)
+}
+
+func TestReaderU8(t *testing.T) {
+	obj := newReader([]byte{42})
+
+	result := obj.u8()
+
+	assert.Equal(t, uint8(42), result)
+	assert.NoError(t, obj.err)
+}
+
+func TestReaderU32(t *testing.T) {
+	obj := newReader([]byte{1, 2, 3, 4, 1, 2, 3, 4})
+
+	le := obj.u32(binary.LittleEndian)
+	be := obj.u32(binary.BigEndian)
+
+	assert.Equal(t, uint32(0x04030201), le)
+	assert.Equal(t, uint32(0x01020304), be)
+	assert.NoError(t, obj.err)
+}
+
+func TestReaderU64(t *testing.T) {
+	obj := newReader([]byte{1, 0, 0, 0, 0, 0, 0, 2})
+
+	result := obj.u64(binary.LittleEndian)
+
+	assert.Equal(t, uint64(0x0200000000000001), result)
+	assert.NoError(t, obj.err)
+}
+
+func TestReaderUlebBase(t *testing.T) {
+	obj := newReader([]byte{0xe5, 0x8e, 0x26})
+
+	result := obj.uleb()
+
+	assert.Equal(t, uint64(624485), result)
+	assert.NoError(t, obj.err)
+}
+
+func TestReaderUlebTruncated(t *testing.T) {
+	obj := newReader([]byte{0xe5, 0x8e})
+
+	result := obj.uleb()
+
+	assert.Equal(t, uint64(0), result)
+	assert.Same(t, ErrTruncated, obj.err)
+}
+
+func TestReaderUlebOverflow(t *testing.T) {
+	obj := newReader([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01})
+
+	result := obj.uleb()
+
+	assert.Equal(t, uint64(0), result)
+	assert.Same(t, ErrTruncated, obj.err)
+}
+
+func TestReaderStrtabBase(t *testing.T) {
+	obj := newReader([]byte{2, 3, 'f', 'o', 'o', 0})
+
+	result := obj.strtab()
+
+	assert.Equal(t, []string{"foo", ""}, result)
+	assert.NoError(t, obj.err)
+}
+
+func TestReaderStrtabTruncated(t *testing.T) {
+	obj := newReader([]byte{2, 3, 'f', 'o'})
+
+	obj.strtab()
+
+	assert.Same(t, ErrTruncated, obj.err)
+}
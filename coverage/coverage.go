@@ -15,49 +15,234 @@
 package coverage
 
 import (
+	"fmt"
 	"path"
+	"path/filepath"
+	"strings"
 
 	"golang.org/x/tools/cover"
 
 	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/covdata"
+	"github.com/klmitch/overcover/lcov"
+	"github.com/klmitch/overcover/modules"
 )
 
 // Patch points for top-level functions called by functions in this
 // file.
 var (
 	parseProfiles func(string) ([]*cover.Profile, error) = cover.ParseProfiles
+	glob          func(string) ([]string, error)         = filepath.Glob
+	loadDir       func(string) ([]*cover.Profile, error) = covdata.Load
+	loadLCOV      func(string) ([]*cover.Profile, error) = lcov.Load
 )
 
-// Load loads a coverage profile file and returns a list of FileData
-// instances.
-func Load(profile string) (common.DataSet, error) {
-	// Begin by loading the profile file
-	profs, err := parseProfiles(profile)
-	if err != nil {
-		return nil, err
+// expand expands a list of coverage profile file names, any of which
+// may be glob patterns, into a list of file names.  A pattern that
+// matches no files is passed through unchanged, so that the attempt
+// to read it reports a sensible error.  Duplicates are omitted.
+func expand(patterns []string) ([]string, error) {
+	seen := map[string]bool{}
+	var result []string
+	for _, pattern := range patterns {
+		matches, err := glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			matches = []string{pattern}
+		}
+
+		// Add the matches to the result
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				result = append(result, match)
+			}
+		}
 	}
 
-	// Next, process each profile
-	var data []common.FileData
-	for _, prof := range profs {
-		fd := common.FileData{
-			Package: path.Dir(prof.FileName),
-			Name:    path.Base(prof.FileName),
+	return result, nil
+}
+
+// sameLayout reports whether two profiles describe the same blocks;
+// that is, whether every block has the same position and statement
+// count in both profiles.
+func sameLayout(a, b *cover.Profile) bool {
+	if len(a.Blocks) != len(b.Blocks) {
+		return false
+	}
+
+	for i, blk := range a.Blocks {
+		other := b.Blocks[i]
+		if blk.StartLine != other.StartLine || blk.StartCol != other.StartCol || blk.EndLine != other.EndLine || blk.EndCol != other.EndCol || blk.NumStmt != other.NumStmt {
+			return false
 		}
+	}
 
-		// Process each block
-		for _, blk := range prof.Blocks {
-			fd.Count += int64(blk.NumStmt)
+	return true
+}
 
-			// Has it been executed?
+// mergeBlocks merges the counts from one profile into another with
+// the same layout.  A block is considered executed if it was
+// executed in either profile.
+func mergeBlocks(dst, src *cover.Profile) {
+	for i, blk := range src.Blocks {
+		if dst.Mode == "set" || src.Mode == "set" {
 			if blk.Count > 0 {
-				fd.Exec += int64(blk.NumStmt)
+				dst.Blocks[i].Count = 1
 			}
+		} else {
+			dst.Blocks[i].Count += blk.Count
 		}
+	}
+}
+
+// summarize converts a profile into a FileData instance containing
+// the statement counts and the blocks.
+func summarize(prof *cover.Profile) common.FileData {
+	fd := common.FileData{
+		Package: path.Dir(prof.FileName),
+		Name:    path.Base(prof.FileName),
+		Blocks:  make([]common.Block, 0, len(prof.Blocks)),
+	}
+
+	// Process each block
+	for _, blk := range prof.Blocks {
+		fd.Blocks = append(fd.Blocks, common.Block{
+			StartLine: blk.StartLine,
+			StartCol:  blk.StartCol,
+			EndLine:   blk.EndLine,
+			EndCol:    blk.EndCol,
+			NumStmt:   blk.NumStmt,
+			Count:     blk.Count,
+		})
+		fd.Count += int64(blk.NumStmt)
+
+		// Has it been executed?
+		if blk.Count > 0 {
+			fd.Exec += int64(blk.NumStmt)
+		}
+	}
+
+	return fd
+}
+
+// merger merges profiles for the same source file.
+type merger struct {
+	idx      map[string]*cover.Profile // Profiles by source file name
+	merged   []*cover.Profile          // Merged profiles, in order
+	conflict common.DataSet            // Profiles that could not be merged
+	names    *modules.Resolver         // Resolver for file names
+}
+
+// newMerger constructs a new merger.
+func newMerger() *merger {
+	return &merger{
+		idx:   map[string]*cover.Profile{},
+		names: modules.NewResolver(),
+	}
+}
 
-		// Append it to our results
-		data = append(data, fd)
+// importPath converts the file name of a profile into the import path
+// of the file, so that it identifies the file in the same way as the
+// source loader.  Profiles usually name files by import path, but may
+// name them by relative or absolute path, or by absolute path with a
+// "_" prefix for packages outside of GOPATH and of any module; such
+// names are resolved using the module containing the file, and are
+// left unchanged if there is no such module.
+func (m *merger) importPath(name string) string {
+	fname := name
+	switch {
+	case strings.HasPrefix(name, "_/"):
+		fname = name[1:]
+	case strings.HasPrefix(name, "./"), strings.HasPrefix(name, "../"), path.IsAbs(name):
+	default:
+		return name
+	}
+
+	if result := m.names.ImportPath(fname); result != fname {
+		return result
+	}
+	return name
+}
+
+// add adds a list of profiles to the merger.  If the blocks for a
+// source file differ from those of a previously added profile, the
+// profile is summarized into the conflict list.
+func (m *merger) add(profs []*cover.Profile) {
+	for _, prof := range profs {
+		prof.FileName = m.importPath(prof.FileName)
+
+		// Have we seen it?
+		if prev, ok := m.idx[prof.FileName]; ok {
+			if sameLayout(prev, prof) {
+				mergeBlocks(prev, prof)
+			} else {
+				m.conflict = append(m.conflict, summarize(prof))
+			}
+			continue
+		}
+
+		m.idx[prof.FileName] = prof
+		m.merged = append(m.merged, prof)
+	}
+}
+
+// Load loads one or more coverage profile files, along with the
+// binary coverage data in zero or more GOCOVERDIR directories and
+// zero or more LCOV tracefiles, and returns a list of FileData
+// instances.  The profile and tracefile names may be glob patterns.
+// Files named by path rather than import path are identified by their
+// import paths.  Profiles for the same source file are merged block
+// by block, with a block counted as executed if any profile executed
+// it.  If the blocks for a source file differ between
+// profiles, the later profile is ignored and its FileData is
+// returned in the second list, in the same fashion as DataSet.Merge.
+func Load(profiles, dirs, tracefiles []string) (common.DataSet, common.DataSet, error) {
+	// Begin by expanding the list of profile files
+	files, err := expand(profiles)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Load and merge each profile file
+	m := newMerger()
+	for _, file := range files {
+		profs, err := parseProfiles(file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", file, err)
+		}
+		m.add(profs)
+	}
+
+	// Load and merge each coverage data directory
+	for _, dir := range dirs {
+		profs, err := loadDir(dir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", dir, err)
+		}
+		m.add(profs)
+	}
+
+	// Load and merge each LCOV tracefile
+	files, err = expand(tracefiles)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, file := range files {
+		profs, err := loadLCOV(file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", file, err)
+		}
+		m.add(profs)
+	}
+
+	// Next, process each profile
+	var data []common.FileData
+	for _, prof := range m.merged {
+		data = append(data, summarize(prof))
 	}
 
-	return data, nil
+	return data, m.conflict, nil
 }
@@ -15,6 +15,7 @@
 package coverage
 
 import (
+	"os"
 	"testing"
 
 	"github.com/klmitch/patcher"
@@ -24,6 +25,267 @@ import (
 	"github.com/klmitch/overcover/common"
 )
 
+func TestExpandBase(t *testing.T) {
+	defer patcher.SetVar(&glob, func(pattern string) ([]string, error) {
+		switch pattern {
+		case "*.out":
+			return []string{"a.out", "b.out"}, nil
+		case "c.out":
+			return []string{"c.out"}, nil
+		}
+		return nil, nil
+	}).Install().Restore()
+
+	result, err := expand([]string{"*.out", "c.out"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.out", "b.out", "c.out"}, result)
+}
+
+func TestExpandNoMatch(t *testing.T) {
+	defer patcher.SetVar(&glob, func(_ string) ([]string, error) {
+		return nil, nil
+	}).Install().Restore()
+
+	result, err := expand([]string{"missing.out"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"missing.out"}, result)
+}
+
+func TestExpandDuplicates(t *testing.T) {
+	defer patcher.SetVar(&glob, func(pattern string) ([]string, error) {
+		switch pattern {
+		case "*.out":
+			return []string{"a.out", "b.out"}, nil
+		case "a*.out":
+			return []string{"a.out"}, nil
+		}
+		return nil, nil
+	}).Install().Restore()
+
+	result, err := expand([]string{"*.out", "a*.out"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.out", "b.out"}, result)
+}
+
+func TestExpandError(t *testing.T) {
+	defer patcher.SetVar(&glob, func(_ string) ([]string, error) {
+		return nil, assert.AnError
+	}).Install().Restore()
+
+	result, err := expand([]string{"[bad"})
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, "[bad: "+assert.AnError.Error(), err.Error())
+	assert.Nil(t, result)
+}
+
+func TestSameLayoutTrue(t *testing.T) {
+	a := &cover.Profile{
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 1, StartCol: 2, EndLine: 3, EndCol: 4, NumStmt: 5, Count: 0},
+			{StartLine: 6, StartCol: 7, EndLine: 8, EndCol: 9, NumStmt: 1, Count: 1},
+		},
+	}
+	b := &cover.Profile{
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 1, StartCol: 2, EndLine: 3, EndCol: 4, NumStmt: 5, Count: 3},
+			{StartLine: 6, StartCol: 7, EndLine: 8, EndCol: 9, NumStmt: 1, Count: 0},
+		},
+	}
+
+	result := sameLayout(a, b)
+
+	assert.True(t, result)
+}
+
+func TestSameLayoutLength(t *testing.T) {
+	a := &cover.Profile{
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 1, StartCol: 2, EndLine: 3, EndCol: 4, NumStmt: 5, Count: 0},
+			{StartLine: 6, StartCol: 7, EndLine: 8, EndCol: 9, NumStmt: 1, Count: 1},
+		},
+	}
+	b := &cover.Profile{
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 1, StartCol: 2, EndLine: 3, EndCol: 4, NumStmt: 5, Count: 3},
+		},
+	}
+
+	result := sameLayout(a, b)
+
+	assert.False(t, result)
+}
+
+func TestSameLayoutPosition(t *testing.T) {
+	a := &cover.Profile{
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 1, StartCol: 2, EndLine: 3, EndCol: 4, NumStmt: 5, Count: 0},
+		},
+	}
+	b := &cover.Profile{
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 1, StartCol: 2, EndLine: 3, EndCol: 5, NumStmt: 5, Count: 0},
+		},
+	}
+
+	result := sameLayout(a, b)
+
+	assert.False(t, result)
+}
+
+func TestSameLayoutNumStmt(t *testing.T) {
+	a := &cover.Profile{
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 1, StartCol: 2, EndLine: 3, EndCol: 4, NumStmt: 5, Count: 0},
+		},
+	}
+	b := &cover.Profile{
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 1, StartCol: 2, EndLine: 3, EndCol: 4, NumStmt: 4, Count: 0},
+		},
+	}
+
+	result := sameLayout(a, b)
+
+	assert.False(t, result)
+}
+
+func TestMergeBlocksCount(t *testing.T) {
+	dst := &cover.Profile{
+		Mode: "count",
+		Blocks: []cover.ProfileBlock{
+			{NumStmt: 1, Count: 0},
+			{NumStmt: 1, Count: 2},
+			{NumStmt: 1, Count: 0},
+		},
+	}
+	src := &cover.Profile{
+		Mode: "count",
+		Blocks: []cover.ProfileBlock{
+			{NumStmt: 1, Count: 3},
+			{NumStmt: 1, Count: 2},
+			{NumStmt: 1, Count: 0},
+		},
+	}
+
+	mergeBlocks(dst, src)
+
+	assert.Equal(t, []cover.ProfileBlock{
+		{NumStmt: 1, Count: 3},
+		{NumStmt: 1, Count: 4},
+		{NumStmt: 1, Count: 0},
+	}, dst.Blocks)
+}
+
+func TestMergeBlocksSet(t *testing.T) {
+	dst := &cover.Profile{
+		Mode: "set",
+		Blocks: []cover.ProfileBlock{
+			{NumStmt: 1, Count: 0},
+			{NumStmt: 1, Count: 1},
+			{NumStmt: 1, Count: 0},
+		},
+	}
+	src := &cover.Profile{
+		Mode: "set",
+		Blocks: []cover.ProfileBlock{
+			{NumStmt: 1, Count: 1},
+			{NumStmt: 1, Count: 1},
+			{NumStmt: 1, Count: 0},
+		},
+	}
+
+	mergeBlocks(dst, src)
+
+	assert.Equal(t, []cover.ProfileBlock{
+		{NumStmt: 1, Count: 1},
+		{NumStmt: 1, Count: 1},
+		{NumStmt: 1, Count: 0},
+	}, dst.Blocks)
+}
+
+func TestSummarize(t *testing.T) {
+	prof := &cover.Profile{
+		FileName: "example.com/some/package/file1.go",
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 1, StartCol: 2, EndLine: 3, EndCol: 4, NumStmt: 5, Count: 0},
+			{StartLine: 5, StartCol: 6, EndLine: 7, EndCol: 8, NumStmt: 10, Count: 1},
+			{StartLine: 9, StartCol: 1, EndLine: 10, EndCol: 2, NumStmt: 4, Count: 0},
+			{StartLine: 11, StartCol: 3, EndLine: 12, EndCol: 4, NumStmt: 1, Count: 3},
+		},
+	}
+
+	result := summarize(prof)
+
+	assert.Equal(t, common.FileData{
+		Package: "example.com/some/package",
+		Name:    "file1.go",
+		Count:   20,
+		Exec:    11,
+		Blocks: []common.Block{
+			{StartLine: 1, StartCol: 2, EndLine: 3, EndCol: 4, NumStmt: 5, Count: 0},
+			{StartLine: 5, StartCol: 6, EndLine: 7, EndCol: 8, NumStmt: 10, Count: 1},
+			{StartLine: 9, StartCol: 1, EndLine: 10, EndCol: 2, NumStmt: 4, Count: 0},
+			{StartLine: 11, StartCol: 3, EndLine: 12, EndCol: 4, NumStmt: 1, Count: 3},
+		},
+	}, result)
+}
+
+func TestMergerImportPathBase(t *testing.T) {
+	obj := newMerger()
+
+	result := obj.importPath("example.com/some/package/file1.go")
+
+	assert.Equal(t, "example.com/some/package/file1.go", result)
+}
+
+func TestMergerImportPathRelative(t *testing.T) {
+	obj := newMerger()
+
+	result := obj.importPath("./file1.go")
+
+	assert.Equal(t, "github.com/klmitch/overcover/coverage/file1.go", result)
+}
+
+func TestMergerImportPathParent(t *testing.T) {
+	obj := newMerger()
+
+	result := obj.importPath("../coverage/file1.go")
+
+	assert.Equal(t, "github.com/klmitch/overcover/coverage/file1.go", result)
+}
+
+func TestMergerImportPathAbsolute(t *testing.T) {
+	cwd, err := os.Getwd()
+	assert.NoError(t, err)
+	obj := newMerger()
+
+	result := obj.importPath(cwd + "/file1.go")
+
+	assert.Equal(t, "github.com/klmitch/overcover/coverage/file1.go", result)
+}
+
+func TestMergerImportPathOutsideGOPATH(t *testing.T) {
+	cwd, err := os.Getwd()
+	assert.NoError(t, err)
+	obj := newMerger()
+
+	result := obj.importPath("_" + cwd + "/file1.go")
+
+	assert.Equal(t, "github.com/klmitch/overcover/coverage/file1.go", result)
+}
+
+func TestMergerImportPathNoModule(t *testing.T) {
+	obj := newMerger()
+
+	result := obj.importPath("_/nonexistent/path/file1.go")
+
+	assert.Equal(t, "_/nonexistent/path/file1.go", result)
+}
+
 func TestLoadBase(t *testing.T) {
 	profs := []*cover.Profile{
 		{
@@ -46,13 +308,18 @@ func TestLoadBase(t *testing.T) {
 		},
 	}
 	parseProfilesCalled := false
-	defer patcher.SetVar(&parseProfiles, func(profile string) ([]*cover.Profile, error) {
-		assert.Equal(t, "coverage.out", profile)
-		parseProfilesCalled = true
-		return profs, nil
-	}).Install().Restore()
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&glob, func(pattern string) ([]string, error) {
+			return []string{pattern}, nil
+		}),
+		patcher.SetVar(&parseProfiles, func(profile string) ([]*cover.Profile, error) {
+			assert.Equal(t, "coverage.out", profile)
+			parseProfilesCalled = true
+			return profs, nil
+		}),
+	).Install().Restore()
 
-	result, err := Load("coverage.out")
+	result, conflict, err := Load([]string{"coverage.out"}, nil, nil)
 
 	assert.NoError(t, err)
 	assert.Equal(t, common.DataSet{
@@ -61,28 +328,410 @@ func TestLoadBase(t *testing.T) {
 			Name:    "file1.go",
 			Count:   20,
 			Exec:    11,
+			Blocks: []common.Block{
+				{NumStmt: 5, Count: 0},
+				{NumStmt: 10, Count: 1},
+				{NumStmt: 4, Count: 0},
+				{NumStmt: 1, Count: 1},
+			},
 		},
 		common.FileData{
 			Package: "example.com/some/package",
 			Name:    "file2.go",
 			Count:   20,
 			Exec:    9,
+			Blocks: []common.Block{
+				{NumStmt: 10, Count: 0},
+				{NumStmt: 5, Count: 1},
+				{NumStmt: 1, Count: 0},
+				{NumStmt: 4, Count: 1},
+			},
 		},
 	}, result)
+	assert.Nil(t, conflict)
 	assert.True(t, parseProfilesCalled)
 }
 
+func TestLoadMerge(t *testing.T) {
+	profs := map[string][]*cover.Profile{
+		"unit.out": {
+			{
+				FileName: "example.com/some/package/file1.go",
+				Mode:     "count",
+				Blocks: []cover.ProfileBlock{
+					{StartLine: 1, EndLine: 2, NumStmt: 5, Count: 0},
+					{StartLine: 3, EndLine: 4, NumStmt: 10, Count: 1},
+				},
+			},
+		},
+		"e2e.out": {
+			{
+				FileName: "example.com/some/package/file1.go",
+				Mode:     "count",
+				Blocks: []cover.ProfileBlock{
+					{StartLine: 1, EndLine: 2, NumStmt: 5, Count: 2},
+					{StartLine: 3, EndLine: 4, NumStmt: 10, Count: 0},
+				},
+			},
+			{
+				FileName: "example.com/some/package/file2.go",
+				Mode:     "count",
+				Blocks: []cover.ProfileBlock{
+					{StartLine: 1, EndLine: 2, NumStmt: 3, Count: 0},
+				},
+			},
+		},
+	}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&glob, func(pattern string) ([]string, error) {
+			assert.Equal(t, "*.out", pattern)
+			return []string{"unit.out", "e2e.out"}, nil
+		}),
+		patcher.SetVar(&parseProfiles, func(profile string) ([]*cover.Profile, error) {
+			return profs[profile], nil
+		}),
+	).Install().Restore()
+
+	result, conflict, err := Load([]string{"*.out"}, nil, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, common.DataSet{
+		common.FileData{
+			Package: "example.com/some/package",
+			Name:    "file1.go",
+			Count:   15,
+			Exec:    15,
+			Blocks: []common.Block{
+				{StartLine: 1, EndLine: 2, NumStmt: 5, Count: 2},
+				{StartLine: 3, EndLine: 4, NumStmt: 10, Count: 1},
+			},
+		},
+		common.FileData{
+			Package: "example.com/some/package",
+			Name:    "file2.go",
+			Count:   3,
+			Blocks: []common.Block{
+				{StartLine: 1, EndLine: 2, NumStmt: 3, Count: 0},
+			},
+		},
+	}, result)
+	assert.Nil(t, conflict)
+}
+
+func TestLoadRelative(t *testing.T) {
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&glob, func(pattern string) ([]string, error) {
+			return []string{pattern}, nil
+		}),
+		patcher.SetVar(&parseProfiles, func(profile string) ([]*cover.Profile, error) {
+			if profile == "unit.out" {
+				return []*cover.Profile{
+					{
+						FileName: "github.com/klmitch/overcover/coverage/file1.go",
+						Mode:     "set",
+						Blocks: []cover.ProfileBlock{
+							{StartLine: 1, EndLine: 2, NumStmt: 5, Count: 0},
+						},
+					},
+				}, nil
+			}
+			return []*cover.Profile{
+				{
+					FileName: "./file1.go",
+					Mode:     "set",
+					Blocks: []cover.ProfileBlock{
+						{StartLine: 1, EndLine: 2, NumStmt: 5, Count: 1},
+					},
+				},
+			}, nil
+		}),
+	).Install().Restore()
+
+	result, conflict, err := Load([]string{"unit.out", "local.out"}, nil, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, common.DataSet{
+		common.FileData{
+			Package: "github.com/klmitch/overcover/coverage",
+			Name:    "file1.go",
+			Count:   5,
+			Exec:    5,
+			Blocks: []common.Block{
+				{StartLine: 1, EndLine: 2, NumStmt: 5, Count: 1},
+			},
+		},
+	}, result)
+	assert.Nil(t, conflict)
+}
+
+func TestLoadConflict(t *testing.T) {
+	profs := map[string][]*cover.Profile{
+		"unit.out": {
+			{
+				FileName: "example.com/some/package/file1.go",
+				Mode:     "count",
+				Blocks: []cover.ProfileBlock{
+					{StartLine: 1, EndLine: 2, NumStmt: 5, Count: 0},
+					{StartLine: 3, EndLine: 4, NumStmt: 10, Count: 1},
+				},
+			},
+		},
+		"e2e.out": {
+			{
+				FileName: "example.com/some/package/file1.go",
+				Mode:     "count",
+				Blocks: []cover.ProfileBlock{
+					{StartLine: 1, EndLine: 2, NumStmt: 6, Count: 2},
+					{StartLine: 3, EndLine: 4, NumStmt: 10, Count: 0},
+				},
+			},
+		},
+	}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&glob, func(pattern string) ([]string, error) {
+			return []string{pattern}, nil
+		}),
+		patcher.SetVar(&parseProfiles, func(profile string) ([]*cover.Profile, error) {
+			return profs[profile], nil
+		}),
+	).Install().Restore()
+
+	result, conflict, err := Load([]string{"unit.out", "e2e.out"}, nil, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, common.DataSet{
+		common.FileData{
+			Package: "example.com/some/package",
+			Name:    "file1.go",
+			Count:   15,
+			Exec:    10,
+			Blocks: []common.Block{
+				{StartLine: 1, EndLine: 2, NumStmt: 5, Count: 0},
+				{StartLine: 3, EndLine: 4, NumStmt: 10, Count: 1},
+			},
+		},
+	}, result)
+	assert.Equal(t, common.DataSet{
+		common.FileData{
+			Package: "example.com/some/package",
+			Name:    "file1.go",
+			Count:   16,
+			Exec:    6,
+			Blocks: []common.Block{
+				{StartLine: 1, EndLine: 2, NumStmt: 6, Count: 2},
+				{StartLine: 3, EndLine: 4, NumStmt: 10, Count: 0},
+			},
+		},
+	}, conflict)
+}
+
+func TestLoadExpandError(t *testing.T) {
+	parseProfilesCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&glob, func(_ string) ([]string, error) {
+			return nil, assert.AnError
+		}),
+		patcher.SetVar(&parseProfiles, func(_ string) ([]*cover.Profile, error) {
+			parseProfilesCalled = true
+			return nil, nil
+		}),
+	).Install().Restore()
+
+	result, conflict, err := Load([]string{"[bad"}, nil, nil)
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Nil(t, result)
+	assert.Nil(t, conflict)
+	assert.False(t, parseProfilesCalled)
+}
+
 func TestLoadError(t *testing.T) {
 	parseProfilesCalled := false
-	defer patcher.SetVar(&parseProfiles, func(profile string) ([]*cover.Profile, error) {
-		assert.Equal(t, "coverage.out", profile)
-		parseProfilesCalled = true
-		return nil, assert.AnError
-	}).Install().Restore()
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&glob, func(pattern string) ([]string, error) {
+			return []string{pattern}, nil
+		}),
+		patcher.SetVar(&parseProfiles, func(profile string) ([]*cover.Profile, error) {
+			assert.Equal(t, "coverage.out", profile)
+			parseProfilesCalled = true
+			return nil, assert.AnError
+		}),
+	).Install().Restore()
 
-	result, err := Load("coverage.out")
+	result, conflict, err := Load([]string{"coverage.out"}, nil, nil)
 
-	assert.Same(t, assert.AnError, err)
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, "coverage.out: "+assert.AnError.Error(), err.Error())
 	assert.Nil(t, result)
+	assert.Nil(t, conflict)
 	assert.True(t, parseProfilesCalled)
 }
+
+func TestLoadDirs(t *testing.T) {
+	profs := map[string][]*cover.Profile{
+		"coverage.out": {
+			{
+				FileName: "example.com/some/package/file1.go",
+				Mode:     "set",
+				Blocks: []cover.ProfileBlock{
+					{StartLine: 1, EndLine: 2, NumStmt: 5, Count: 0},
+					{StartLine: 3, EndLine: 4, NumStmt: 10, Count: 1},
+				},
+			},
+		},
+		"covdir": {
+			{
+				FileName: "example.com/some/package/file1.go",
+				Mode:     "set",
+				Blocks: []cover.ProfileBlock{
+					{StartLine: 1, EndLine: 2, NumStmt: 5, Count: 1},
+					{StartLine: 3, EndLine: 4, NumStmt: 10, Count: 0},
+				},
+			},
+			{
+				FileName: "example.com/some/package/file2.go",
+				Mode:     "set",
+				Blocks: []cover.ProfileBlock{
+					{StartLine: 1, EndLine: 2, NumStmt: 3, Count: 1},
+				},
+			},
+		},
+	}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&glob, func(pattern string) ([]string, error) {
+			return []string{pattern}, nil
+		}),
+		patcher.SetVar(&parseProfiles, func(profile string) ([]*cover.Profile, error) {
+			return profs[profile], nil
+		}),
+		patcher.SetVar(&loadDir, func(dir string) ([]*cover.Profile, error) {
+			assert.Equal(t, "covdir", dir)
+			return profs[dir], nil
+		}),
+	).Install().Restore()
+
+	result, conflict, err := Load([]string{"coverage.out"}, []string{"covdir"}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, common.DataSet{
+		common.FileData{
+			Package: "example.com/some/package",
+			Name:    "file1.go",
+			Count:   15,
+			Exec:    15,
+			Blocks: []common.Block{
+				{StartLine: 1, EndLine: 2, NumStmt: 5, Count: 1},
+				{StartLine: 3, EndLine: 4, NumStmt: 10, Count: 1},
+			},
+		},
+		common.FileData{
+			Package: "example.com/some/package",
+			Name:    "file2.go",
+			Count:   3,
+			Exec:    3,
+			Blocks: []common.Block{
+				{StartLine: 1, EndLine: 2, NumStmt: 3, Count: 1},
+			},
+		},
+	}, result)
+	assert.Nil(t, conflict)
+}
+
+func TestLoadDirError(t *testing.T) {
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&glob, func(pattern string) ([]string, error) {
+			return []string{pattern}, nil
+		}),
+		patcher.SetVar(&parseProfiles, func(_ string) ([]*cover.Profile, error) {
+			return nil, nil
+		}),
+		patcher.SetVar(&loadDir, func(dir string) ([]*cover.Profile, error) {
+			assert.Equal(t, "covdir", dir)
+			return nil, assert.AnError
+		}),
+	).Install().Restore()
+
+	result, conflict, err := Load(nil, []string{"covdir"}, nil)
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, "covdir: "+assert.AnError.Error(), err.Error())
+	assert.Nil(t, result)
+	assert.Nil(t, conflict)
+}
+
+func TestLoadTracefiles(t *testing.T) {
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&glob, func(pattern string) ([]string, error) {
+			assert.Equal(t, "*.dat", pattern)
+			return []string{"a.dat", "b.dat"}, nil
+		}),
+		patcher.SetVar(&loadLCOV, func(fname string) ([]*cover.Profile, error) {
+			return []*cover.Profile{
+				{
+					FileName: "example.com/some/package/file1.go",
+					Mode:     "count",
+					Blocks: []cover.ProfileBlock{
+						{StartLine: 1, StartCol: 1, EndLine: 1, EndCol: 1, NumStmt: 1, Count: map[string]int{"a.dat": 0, "b.dat": 2}[fname]},
+						{StartLine: 2, StartCol: 1, EndLine: 2, EndCol: 1, NumStmt: 1, Count: 1},
+					},
+				},
+			}, nil
+		}),
+	).Install().Restore()
+
+	result, conflict, err := Load(nil, nil, []string{"*.dat"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, common.DataSet{
+		common.FileData{
+			Package: "example.com/some/package",
+			Name:    "file1.go",
+			Count:   2,
+			Exec:    2,
+			Blocks: []common.Block{
+				{StartLine: 1, StartCol: 1, EndLine: 1, EndCol: 1, NumStmt: 1, Count: 2},
+				{StartLine: 2, StartCol: 1, EndLine: 2, EndCol: 1, NumStmt: 1, Count: 2},
+			},
+		},
+	}, result)
+	assert.Nil(t, conflict)
+}
+
+func TestLoadTracefileExpandError(t *testing.T) {
+	loadLCOVCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&glob, func(_ string) ([]string, error) {
+			return nil, assert.AnError
+		}),
+		patcher.SetVar(&loadLCOV, func(_ string) ([]*cover.Profile, error) {
+			loadLCOVCalled = true
+			return nil, nil
+		}),
+	).Install().Restore()
+
+	result, conflict, err := Load(nil, nil, []string{"[bad"})
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Nil(t, result)
+	assert.Nil(t, conflict)
+	assert.False(t, loadLCOVCalled)
+}
+
+func TestLoadTracefileError(t *testing.T) {
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&glob, func(pattern string) ([]string, error) {
+			return []string{pattern}, nil
+		}),
+		patcher.SetVar(&loadLCOV, func(fname string) ([]*cover.Profile, error) {
+			assert.Equal(t, "coverage.dat", fname)
+			return nil, assert.AnError
+		}),
+	).Install().Restore()
+
+	result, conflict, err := Load(nil, nil, []string{"coverage.dat"})
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, "coverage.dat: "+assert.AnError.Error(), err.Error())
+	assert.Nil(t, result)
+	assert.Nil(t, conflict)
+}
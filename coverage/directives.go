@@ -0,0 +1,79 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package coverage
+
+import (
+	"go/parser"
+	"go/token"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/ignore"
+)
+
+// findDirectives locates the directives in the source of a file.
+// Nil is returned if the file has no directives, or if its source
+// cannot be located or read.
+func findDirectives(fset *token.FileSet, fd common.FileData, modRoot, modPath string) *ignore.Directives {
+	fname, ok := sourceFile(fd, modRoot, modPath)
+	if !ok {
+		return nil
+	}
+
+	file, err := parseFile(fset, fname, nil, parser.ParseComments)
+	if err != nil {
+		return nil
+	}
+
+	return ignore.Find(fset, file)
+}
+
+// ApplyDirectives applies the "//overcover:ignore" directives in the
+// source of the files in a data set to their coverage blocks, so that
+// the statement counts agree with those computed from the source.
+// Ignored statements are removed from the blocks and the counts are
+// recomputed; files ignored by an "//overcover:ignore-file" directive
+// are omitted.  Only files with blocks within the module with the
+// specified root directory and path are checked; other files, and
+// files whose source cannot be read, are left unchanged.  A new data
+// set is returned.
+func ApplyDirectives(ds common.DataSet, modRoot, modPath string) common.DataSet {
+	result := make(common.DataSet, 0, len(ds))
+	fset := token.NewFileSet()
+	for _, fd := range ds {
+		if fd.Blocks == nil {
+			result = append(result, fd)
+			continue
+		}
+
+		d := findDirectives(fset, fd, modRoot, modPath)
+		switch {
+		case d == nil:
+		case d.File:
+			continue
+		default:
+			fd.Blocks = d.Apply(fd.Blocks)
+			fd.Count, fd.Exec = 0, 0
+			for _, blk := range fd.Blocks {
+				fd.Count += int64(blk.NumStmt)
+				if blk.Count > 0 {
+					fd.Exec += int64(blk.NumStmt)
+				}
+			}
+		}
+		result = append(result, fd)
+	}
+
+	return result
+}
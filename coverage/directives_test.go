@@ -0,0 +1,154 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package coverage
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klmitch/patcher"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/klmitch/overcover/common"
+)
+
+var testDirectiveSources = map[string]string{
+	filepath.Join("/src/mod", "check.go"): `package mod
+
+func Check(x int) bool {
+	z := x + 1
+	if z < 0 { //overcover:ignore defensive
+		return false
+	}
+	return true
+}
+`,
+	filepath.Join("/src/mod", "plain.go"): `package mod
+
+func Plain() bool {
+	return true
+}
+`,
+	filepath.Join("/src/mod", "glue.go"): `//overcover:ignore-file
+
+package mod
+
+func Glue() bool {
+	return true
+}
+`,
+	filepath.Join("/src/mod", "bad.go"): "this is not go\n",
+}
+
+func patchDirectiveSources(t *testing.T) patcher.Patcher {
+	return patcher.SetVar(&parseFile, func(fset *token.FileSet, fname string, src any, mode parser.Mode) (*ast.File, error) {
+		assert.Nil(t, src)
+		assert.Equal(t, parser.ParseComments, mode)
+		text, ok := testDirectiveSources[fname]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return parser.ParseFile(fset, fname, text, mode)
+	})
+}
+
+func TestFindDirectivesBase(t *testing.T) {
+	defer patchDirectiveSources(t).Install().Restore()
+
+	result := findDirectives(token.NewFileSet(), common.FileData{Package: "example.com/mod", Name: "check.go"}, "/src/mod", "example.com/mod")
+
+	assert.NotNil(t, result)
+	assert.False(t, result.File)
+	assert.Len(t, result.Spans, 1)
+}
+
+func TestFindDirectivesOutsideModule(t *testing.T) {
+	defer patchDirectiveSources(t).Install().Restore()
+
+	result := findDirectives(token.NewFileSet(), common.FileData{Package: "example.com/other", Name: "check.go"}, "/src/mod", "example.com/mod")
+
+	assert.Nil(t, result)
+}
+
+func TestFindDirectivesParseError(t *testing.T) {
+	defer patchDirectiveSources(t).Install().Restore()
+
+	result := findDirectives(token.NewFileSet(), common.FileData{Package: "example.com/mod", Name: "bad.go"}, "/src/mod", "example.com/mod")
+
+	assert.Nil(t, result)
+}
+
+func TestApplyDirectives(t *testing.T) {
+	defer patchDirectiveSources(t).Install().Restore()
+	ds := common.DataSet{
+		{
+			Package: "example.com/mod",
+			Name:    "check.go",
+			Count:   4,
+			Exec:    3,
+			Blocks: []common.Block{
+				{StartLine: 4, StartCol: 2, EndLine: 5, EndCol: 11, NumStmt: 2, Count: 1},
+				{StartLine: 6, StartCol: 3, EndLine: 7, EndCol: 1, NumStmt: 1},
+				{StartLine: 8, StartCol: 2, EndLine: 8, EndCol: 13, NumStmt: 1, Count: 1},
+			},
+		},
+		{
+			Package: "example.com/mod",
+			Name:    "plain.go",
+			Count:   1,
+			Blocks: []common.Block{
+				{StartLine: 4, StartCol: 2, EndLine: 4, EndCol: 13, NumStmt: 1},
+			},
+		},
+		{
+			Package: "example.com/mod",
+			Name:    "glue.go",
+			Count:   1,
+			Blocks: []common.Block{
+				{StartLine: 6, StartCol: 2, EndLine: 6, EndCol: 13, NumStmt: 1},
+			},
+		},
+		{Package: "example.com/mod", Name: "source.go", Count: 3},
+	}
+
+	result := ApplyDirectives(ds, "/src/mod", "example.com/mod")
+
+	assert.Equal(t, common.DataSet{
+		{
+			Package: "example.com/mod",
+			Name:    "check.go",
+			Count:   2,
+			Exec:    2,
+			Blocks: []common.Block{
+				{StartLine: 4, StartCol: 2, EndLine: 5, EndCol: 11, NumStmt: 1, Count: 1},
+				{StartLine: 8, StartCol: 2, EndLine: 8, EndCol: 13, NumStmt: 1, Count: 1},
+			},
+		},
+		{
+			Package: "example.com/mod",
+			Name:    "plain.go",
+			Count:   1,
+			Blocks: []common.Block{
+				{StartLine: 4, StartCol: 2, EndLine: 4, EndCol: 13, NumStmt: 1},
+			},
+		},
+		{Package: "example.com/mod", Name: "source.go", Count: 3},
+	}, result)
+	assert.Equal(t, int64(4), ds[0].Count)
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package coverage
+
+import "github.com/klmitch/overcover/common"
+
+// fileFunctions attributes the blocks of a file to the functions
+// containing them.  Each block is attributed only to the innermost
+// function containing it, so that the statements of a function
+// literal are not counted again for the function containing the
+// literal.
+func fileFunctions(fd common.FileData) common.FuncSet {
+	// Attribute the blocks
+	data := make(common.FuncSet, len(fd.Funcs))
+	for _, blk := range fd.Blocks {
+		idx := -1
+		for i, fe := range fd.Funcs {
+			if fe.Contains(blk) {
+				idx = i
+			}
+		}
+		if idx < 0 {
+			continue
+		}
+		data[idx].Count += int64(blk.NumStmt)
+		if blk.Count > 0 {
+			data[idx].Exec += int64(blk.NumStmt)
+		}
+	}
+
+	// Build the result from the functions with statements
+	var result common.FuncSet
+	for i, fe := range fd.Funcs {
+		if data[i].Count == 0 {
+			continue
+		}
+		data[i].Package = fd.Package
+		data[i].File = fd.Name
+		data[i].Name = fe.Name
+		data[i].Line = fe.Line
+		data[i].Exported = fe.Exported
+		result = append(result, data[i])
+	}
+
+	return result
+}
+
+// Functions maps the coverage blocks of the files in a data set onto
+// the function declarations and function literals located in their
+// source, and returns the number of statements and executed
+// statements in each function.  Functions without statements, such
+// as those excluded by ignore directives, are omitted.  The files
+// with blocks whose source has not been read are also returned.
+func Functions(ds common.DataSet) (common.FuncSet, common.DataSet) {
+	var result common.FuncSet
+	var missing common.DataSet
+	for _, fd := range ds {
+		if fd.Funcs == nil && len(fd.Blocks) > 0 {
+			missing = append(missing, fd)
+			continue
+		}
+		result = append(result, fileFunctions(fd)...)
+	}
+
+	return result, missing
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package coverage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/klmitch/overcover/common"
+)
+
+var testFunctionExtents = []common.Extent{
+	{Name: "(*T).Get", Line: 5, StartLine: 5, StartCol: 24, EndLine: 7, EndCol: 1, Exported: true},
+	{Name: "T.Each", Line: 9, StartLine: 9, StartCol: 32, EndLine: 11, EndCol: 1, Exported: true},
+	{Name: "Outer", Line: 13, StartLine: 13, StartCol: 30, EndLine: 19, EndCol: 1, Exported: true},
+	{Name: "Outer.func1", Line: 15, StartLine: 15, StartCol: 20, EndLine: 18, EndCol: 2},
+	{Name: "Outer.func1.1", Line: 16, StartLine: 16, StartCol: 19, EndLine: 16, EndCol: 30},
+	{Name: "glob..func1", Line: 21, StartLine: 21, StartCol: 25, EndLine: 23, EndCol: 1},
+	{Name: "Empty", Line: 25, StartLine: 25, StartCol: 14, EndLine: 25, EndCol: 15, Exported: true},
+}
+
+func TestFileFunctions(t *testing.T) {
+	fd := common.FileData{
+		Package: "example.com/mod",
+		Name:    "funcs.go",
+		Blocks: []common.Block{
+			{StartLine: 2, StartCol: 1, EndLine: 2, EndCol: 5, NumStmt: 1},
+			{StartLine: 4, StartCol: 2, EndLine: 4, EndCol: 10, NumStmt: 2, Count: 1},
+			{StartLine: 5, StartCol: 3, EndLine: 5, EndCol: 10, NumStmt: 1},
+			{StartLine: 6, StartCol: 2, EndLine: 6, EndCol: 10, NumStmt: 1, Count: 3},
+			{StartLine: 9, StartCol: 15, EndLine: 9, EndCol: 15},
+		},
+		Funcs: []common.Extent{
+			{Name: "F", Line: 3, StartLine: 3, StartCol: 10, EndLine: 7, EndCol: 1},
+			{Name: "F.func1", Line: 5, StartLine: 5, StartCol: 2, EndLine: 5, EndCol: 12},
+			{Name: "Empty", Line: 9, StartLine: 9, StartCol: 14, EndLine: 9, EndCol: 15},
+		},
+	}
+
+	result := fileFunctions(fd)
+
+	assert.Equal(t, common.FuncSet{
+		{Package: "example.com/mod", File: "funcs.go", Name: "F", Line: 3, Count: 3, Exec: 3},
+		{Package: "example.com/mod", File: "funcs.go", Name: "F.func1", Line: 5, Count: 1},
+	}, result)
+}
+
+func TestFunctions(t *testing.T) {
+	ds := common.DataSet{
+		{
+			Package: "example.com/mod",
+			Name:    "funcs.go",
+			Blocks: []common.Block{
+				{StartLine: 6, StartCol: 2, EndLine: 6, EndCol: 12, NumStmt: 1, Count: 1},
+				{StartLine: 10, StartCol: 2, EndLine: 10, EndCol: 9, NumStmt: 1},
+				{StartLine: 14, StartCol: 2, EndLine: 15, EndCol: 19, NumStmt: 2, Count: 1},
+				{StartLine: 16, StartCol: 3, EndLine: 16, EndCol: 19, NumStmt: 1, Count: 1},
+				{StartLine: 16, StartCol: 21, EndLine: 16, EndCol: 29, NumStmt: 1},
+				{StartLine: 17, StartCol: 3, EndLine: 17, EndCol: 17, NumStmt: 1, Count: 1},
+				{StartLine: 22, StartCol: 2, EndLine: 22, EndCol: 10, NumStmt: 1},
+				{StartLine: 25, StartCol: 15, EndLine: 25, EndCol: 15},
+			},
+			Funcs: testFunctionExtents,
+		},
+		{Package: "example.com/mod", Name: "unread.go", Blocks: []common.Block{{StartLine: 3, NumStmt: 1}}},
+		{Package: "example.com/mod", Name: "none.go"},
+	}
+
+	result, missing := Functions(ds)
+
+	assert.Equal(t, common.FuncSet{
+		{Package: "example.com/mod", File: "funcs.go", Name: "(*T).Get", Line: 5, Count: 1, Exec: 1, Exported: true},
+		{Package: "example.com/mod", File: "funcs.go", Name: "T.Each", Line: 9, Count: 1, Exported: true},
+		{Package: "example.com/mod", File: "funcs.go", Name: "Outer", Line: 13, Count: 2, Exec: 2, Exported: true},
+		{Package: "example.com/mod", File: "funcs.go", Name: "Outer.func1", Line: 15, Count: 2, Exec: 2},
+		{Package: "example.com/mod", File: "funcs.go", Name: "Outer.func1.1", Line: 16, Count: 1},
+		{Package: "example.com/mod", File: "funcs.go", Name: "glob..func1", Line: 21, Count: 1},
+	}, result)
+	assert.Equal(t, common.DataSet{ds[1]}, missing)
+}
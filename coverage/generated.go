@@ -0,0 +1,73 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package coverage
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"github.com/klmitch/overcover/common"
+)
+
+// Patch points for top-level functions called by functions in this
+// file.
+var parseFile func(*token.FileSet, string, any, parser.Mode) (*ast.File, error) = parser.ParseFile
+
+// sourceFile computes the name of the source file described by a
+// FileData.  Only files within the module with the specified root
+// directory and path can be located; the boolean result is false for
+// any other file.
+func sourceFile(fd common.FileData, modRoot, modPath string) (string, bool) {
+	if modRoot == "" || modPath == "" {
+		return "", false
+	}
+
+	if fd.Package == modPath {
+		return filepath.Join(modRoot, fd.Name), true
+	}
+	if rel := strings.TrimPrefix(fd.Package, modPath+"/"); rel != fd.Package {
+		return filepath.Join(modRoot, filepath.FromSlash(rel), fd.Name), true
+	}
+
+	return "", false
+}
+
+// MarkGenerated marks the files in a data set whose source carries
+// the standard "Code generated ... DO NOT EDIT." comment, as
+// reported by ast.IsGenerated.  This is needed for files loaded from
+// coverage data, which--unlike files loaded from source--do not
+// record whether they were generated.  Only the source of files
+// within the module with the specified root directory and path can be
+// checked; other files, and files whose source cannot be read, are
+// left unmarked.  A new data set is returned.
+func MarkGenerated(ds common.DataSet, modRoot, modPath string) common.DataSet {
+	result := make(common.DataSet, 0, len(ds))
+	fset := token.NewFileSet()
+	for _, fd := range ds {
+		if !fd.Generated {
+			if fname, ok := sourceFile(fd, modRoot, modPath); ok {
+				if file, err := parseFile(fset, fname, nil, parser.PackageClauseOnly|parser.ParseComments); err == nil {
+					fd.Generated = ast.IsGenerated(file)
+				}
+			}
+		}
+		result = append(result, fd)
+	}
+
+	return result
+}
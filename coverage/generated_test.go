@@ -0,0 +1,102 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package coverage
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klmitch/patcher"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/klmitch/overcover/common"
+)
+
+func TestSourceFileModule(t *testing.T) {
+	result, ok := sourceFile(common.FileData{Package: "example.com/mod", Name: "a.go"}, "/src/mod", "example.com/mod")
+
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join("/src/mod", "a.go"), result)
+}
+
+func TestSourceFileSubPackage(t *testing.T) {
+	result, ok := sourceFile(common.FileData{Package: "example.com/mod/sub/pkg", Name: "a.go"}, "/src/mod", "example.com/mod")
+
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join("/src/mod", "sub", "pkg", "a.go"), result)
+}
+
+func TestSourceFileOther(t *testing.T) {
+	result, ok := sourceFile(common.FileData{Package: "example.com/modother", Name: "a.go"}, "/src/mod", "example.com/mod")
+
+	assert.False(t, ok)
+	assert.Equal(t, "", result)
+}
+
+func TestSourceFileNoModule(t *testing.T) {
+	result, ok := sourceFile(common.FileData{Package: "example.com/mod", Name: "a.go"}, "", "")
+
+	assert.False(t, ok)
+	assert.Equal(t, "", result)
+}
+
+func TestMarkGenerated(t *testing.T) {
+	sources := map[string]string{
+		filepath.Join("/src/mod", "gen.go"):   "// Code generated by stringer. DO NOT EDIT.\n\npackage mod\n",
+		filepath.Join("/src/mod", "plain.go"): "// Package mod is a module.\npackage mod\n",
+		filepath.Join("/src/mod", "bad.go"):   "this is not go\n",
+	}
+	parsed := []string{}
+	defer patcher.SetVar(&parseFile, func(fset *token.FileSet, fname string, src any, mode parser.Mode) (*ast.File, error) {
+		assert.Nil(t, src)
+		assert.Equal(t, parser.PackageClauseOnly|parser.ParseComments, mode)
+		parsed = append(parsed, fname)
+		text, ok := sources[fname]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return parser.ParseFile(fset, fname, text, mode)
+	}).Install().Restore()
+	ds := common.DataSet{
+		{Package: "example.com/mod", Name: "gen.go", Count: 2},
+		{Package: "example.com/mod", Name: "plain.go", Count: 2},
+		{Package: "example.com/mod", Name: "bad.go", Count: 2},
+		{Package: "example.com/mod", Name: "missing.go", Count: 2},
+		{Package: "example.com/mod", Name: "known.go", Count: 2, Generated: true},
+		{Package: "example.com/other", Name: "other.go", Count: 2},
+	}
+
+	result := MarkGenerated(ds, "/src/mod", "example.com/mod")
+
+	assert.Equal(t, common.DataSet{
+		{Package: "example.com/mod", Name: "gen.go", Count: 2, Generated: true},
+		{Package: "example.com/mod", Name: "plain.go", Count: 2},
+		{Package: "example.com/mod", Name: "bad.go", Count: 2},
+		{Package: "example.com/mod", Name: "missing.go", Count: 2},
+		{Package: "example.com/mod", Name: "known.go", Count: 2, Generated: true},
+		{Package: "example.com/other", Name: "other.go", Count: 2},
+	}, result)
+	assert.Equal(t, []string{
+		filepath.Join("/src/mod", "gen.go"),
+		filepath.Join("/src/mod", "plain.go"),
+		filepath.Join("/src/mod", "bad.go"),
+		filepath.Join("/src/mod", "missing.go"),
+	}, parsed)
+	assert.False(t, ds[0].Generated)
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/klmitch/overcover/common"
+)
+
+// profileMode selects the mode of a coverage profile containing the
+// blocks of a data set.  The "set" mode is used if no block was
+// executed more than once; otherwise, the "count" mode is used.
+func profileMode(ds common.DataSet) string {
+	for _, fd := range ds {
+		for _, blk := range fd.Blocks {
+			if blk.Count > 1 {
+				return "count"
+			}
+		}
+	}
+
+	return "set"
+}
+
+// WriteProfile writes the blocks of a data set to a stream as a
+// coverage profile, in the format written by the "-coverprofile"
+// option of "go test".  Files are written in order of their import
+// paths; files without blocks are omitted.
+func WriteProfile(w io.Writer, ds common.DataSet) error {
+	bw := bufio.NewWriter(w)
+
+	sorted := append(common.DataSet{}, ds...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Handle() < sorted[j].Handle()
+	})
+	fmt.Fprintf(bw, "mode: %s\n", profileMode(sorted))
+	for _, fd := range sorted {
+		for _, blk := range fd.Blocks {
+			fmt.Fprintf(bw, "%s:%d.%d,%d.%d %d %d\n", fd.Handle(), blk.StartLine, blk.StartCol, blk.EndLine, blk.EndCol, blk.NumStmt, blk.Count)
+		}
+	}
+
+	return bw.Flush()
+}
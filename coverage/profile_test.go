@@ -0,0 +1,99 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package coverage
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/klmitch/overcover/common"
+)
+
+type failWriter struct{}
+
+func (fw *failWriter) Write(p []byte) (int, error) {
+	return 0, assert.AnError
+}
+
+var testProfileData = common.DataSet{
+	{
+		Package: "example.com/mod/pkg",
+		Name:    "b.go",
+		Count:   3,
+		Exec:    2,
+		Blocks: []common.Block{
+			{StartLine: 3, StartCol: 19, EndLine: 5, EndCol: 3, NumStmt: 2, Count: 1},
+			{StartLine: 6, StartCol: 2, EndLine: 6, EndCol: 10, NumStmt: 1},
+		},
+	},
+	{
+		Package: "example.com/mod/pkg",
+		Name:    "a.go",
+		Count:   1,
+		Blocks: []common.Block{
+			{StartLine: 4, StartCol: 2, EndLine: 5, EndCol: 1, NumStmt: 1},
+		},
+		Synthetic: true,
+	},
+	{
+		Package: "example.com/mod/pkg",
+		Name:    "c.go",
+		Count:   1,
+	},
+}
+
+func TestProfileModeSet(t *testing.T) {
+	result := profileMode(testProfileData)
+
+	assert.Equal(t, "set", result)
+}
+
+func TestProfileModeCount(t *testing.T) {
+	ds := common.DataSet{
+		{
+			Package: "example.com/mod/pkg",
+			Name:    "a.go",
+			Blocks: []common.Block{
+				{StartLine: 4, StartCol: 2, EndLine: 5, EndCol: 1, NumStmt: 1, Count: 2},
+			},
+		},
+	}
+
+	result := profileMode(ds)
+
+	assert.Equal(t, "count", result)
+}
+
+func TestWriteProfileBase(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	err := WriteProfile(buf, testProfileData)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `mode: set
+example.com/mod/pkg/a.go:4.2,5.1 1 0
+example.com/mod/pkg/b.go:3.19,5.3 2 1
+example.com/mod/pkg/b.go:6.2,6.10 1 0
+`, buf.String())
+	assert.Equal(t, "b.go", testProfileData[0].Name)
+}
+
+func TestWriteProfileError(t *testing.T) {
+	err := WriteProfile(&failWriter{}, testProfileData)
+
+	assert.Same(t, assert.AnError, err)
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package diff
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/klmitch/overcover/common"
+)
+
+// resolve converts the file name from a coverage profile into an
+// absolute file name.  Profile file names are normally qualified by
+// import path; these are resolved using the root directory and path
+// of the main module.  The empty string is returned for files outside
+// the main module.
+func resolve(fileName, modRoot, modPath string) string {
+	if filepath.IsAbs(fileName) {
+		return fileName
+	}
+
+	if modPath != "" && strings.HasPrefix(fileName, modPath+"/") {
+		return filepath.Join(modRoot, filepath.FromSlash(strings.TrimPrefix(fileName, modPath+"/")))
+	}
+
+	return ""
+}
+
+// Coverage computes the coverage of the changed lines.  Each block of
+// statements containing a changed line is counted in full, and a
+// FileData is returned for each file containing such blocks; files
+// without block data are ignored.  Since a block is counted even if
+// the only lines changed within it are blank lines or comments, the
+// result is the coverage of the statements in the changed blocks, not
+// just of the changed statements.  The root directory and path of the
+// main module are used to map the file names in the data set to the
+// file names in the changes.
+func Coverage(changes Changes, ds common.DataSet, modRoot, modPath string) common.DataSet {
+	var result common.DataSet
+	for _, fd := range ds {
+		file := resolve(fd.Handle(), modRoot, modPath)
+		if _, ok := changes[file]; !ok {
+			continue
+		}
+
+		patch := common.FileData{
+			Package: fd.Package,
+			Name:    fd.Name,
+		}
+		for _, blk := range fd.Blocks {
+			if !changes.Touches(file, blk.StartLine, blk.EndLine) {
+				continue
+			}
+
+			patch.Count += int64(blk.NumStmt)
+			if blk.Count > 0 {
+				patch.Exec += int64(blk.NumStmt)
+			}
+		}
+
+		if patch.Count > 0 {
+			result = append(result, patch)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package diff
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/klmitch/overcover/common"
+)
+
+func TestResolveModule(t *testing.T) {
+	result := resolve("example.com/mod/pkg/file.go", filepath.FromSlash("/src/mod"), "example.com/mod")
+
+	assert.Equal(t, filepath.FromSlash("/src/mod/pkg/file.go"), result)
+}
+
+func TestResolveAbsolute(t *testing.T) {
+	fname := filepath.FromSlash("/src/other/file.go")
+
+	result := resolve(fname, filepath.FromSlash("/src/mod"), "example.com/mod")
+
+	assert.Equal(t, fname, result)
+}
+
+func TestResolveOutside(t *testing.T) {
+	result := resolve("example.com/modx/file.go", filepath.FromSlash("/src/mod"), "example.com/mod")
+
+	assert.Equal(t, "", result)
+}
+
+func TestResolveNoModule(t *testing.T) {
+	result := resolve("example.com/mod/file.go", "", "")
+
+	assert.Equal(t, "", result)
+}
+
+func TestCoverage(t *testing.T) {
+	changes := Changes{
+		filepath.FromSlash("/src/mod/pkg/file1.go"): {{Start: 3, End: 4}, {Start: 20, End: 20}},
+		filepath.FromSlash("/src/mod/pkg/file2.go"): {{Start: 100, End: 100}},
+	}
+	ds := common.DataSet{
+		{
+			Package: "example.com/mod/pkg",
+			Name:    "file1.go",
+			Blocks: []common.Block{
+				{StartLine: 1, EndLine: 3, NumStmt: 2, Count: 1},
+				{StartLine: 4, EndLine: 6, NumStmt: 3, Count: 0},
+				{StartLine: 7, EndLine: 10, NumStmt: 4, Count: 0},
+				{StartLine: 18, EndLine: 22, NumStmt: 5, Count: 2},
+			},
+		},
+		{
+			Package: "example.com/mod/pkg",
+			Name:    "file2.go",
+			Blocks: []common.Block{
+				{StartLine: 1, EndLine: 3, NumStmt: 2, Count: 1},
+			},
+		},
+		{
+			Package: "example.com/mod/pkg",
+			Name:    "file3.go",
+			Blocks: []common.Block{
+				{StartLine: 1, EndLine: 3, NumStmt: 2, Count: 1},
+			},
+		},
+	}
+
+	result := Coverage(changes, ds, filepath.FromSlash("/src/mod"), "example.com/mod")
+
+	assert.Equal(t, common.DataSet{
+		{Package: "example.com/mod/pkg", Name: "file1.go", Count: 10, Exec: 7},
+	}, result)
+}
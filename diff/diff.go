@@ -0,0 +1,125 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package diff computes coverage over the lines changed relative to
+// a git base revision.
+package diff
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrBadHunk is returned when a hunk header in a diff cannot be
+// parsed.
+var ErrBadHunk = errors.New("invalid hunk header in diff")
+
+// hunkRE matches the header of a hunk in a unified diff, extracting
+// the starting line number and line count in the new file.
+var hunkRE = regexp.MustCompile(`^@@+ (?:-\d+(?:,\d+)? )+\+(\d+)(?:,(\d+))? @@`)
+
+// Range describes an inclusive range of line numbers.
+type Range struct {
+	Start int // First line of the range
+	End   int // Last line of the range
+}
+
+// Changes maps the names of files to the ranges of lines that were
+// added or changed in those files.  The ranges are sorted and do not
+// overlap.
+type Changes map[string][]Range
+
+// add records a changed line in the named file.
+func (c Changes) add(file string, line int) {
+	ranges := c[file]
+	if n := len(ranges); n > 0 && ranges[n-1].End+1 == line {
+		ranges[n-1].End = line
+		return
+	}
+
+	c[file] = append(ranges, Range{Start: line, End: line})
+}
+
+// Touches reports whether any line in the specified range of the
+// named file was changed.
+func (c Changes) Touches(file string, start, end int) bool {
+	for _, r := range c[file] {
+		if r.Start <= end && start <= r.End {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fileName extracts the name of the new file from a "+++" line of a
+// unified diff.  It returns the empty string if the file was deleted.
+func fileName(name string) string {
+	if unquoted, err := strconv.Unquote(name); err == nil {
+		name = unquoted
+	}
+	if name == "/dev/null" {
+		return ""
+	}
+
+	return strings.TrimPrefix(name, "b/")
+}
+
+// Parse parses a unified diff, as generated by "git diff", and
+// returns the lines added or changed in each file.  Deleted lines are
+// not reported, since they cannot be covered.
+func Parse(data []byte) (Changes, error) {
+	result := Changes{}
+	file := ""
+	line := 0
+	remaining := 0 // lines of the new file left in the current hunk
+	for _, text := range strings.Split(string(data), "\n") {
+		// Process lines within a hunk
+		if remaining > 0 {
+			switch {
+			case strings.HasPrefix(text, "+"):
+				result.add(file, line)
+				line++
+				remaining--
+			case strings.HasPrefix(text, " ") || text == "":
+				line++
+				remaining--
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(text, "+++ "):
+			file = fileName(strings.TrimPrefix(text, "+++ "))
+
+		case strings.HasPrefix(text, "@@"):
+			m := hunkRE.FindStringSubmatch(text)
+			if m == nil {
+				return nil, ErrBadHunk
+			}
+			line, _ = strconv.Atoi(m[1])
+			remaining = 1
+			if m[2] != "" {
+				remaining, _ = strconv.Atoi(m[2])
+			}
+			if file == "" {
+				remaining = 0
+			}
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangesAddNew(t *testing.T) {
+	obj := Changes{}
+
+	obj.add("file.go", 5)
+
+	assert.Equal(t, Changes{
+		"file.go": {{Start: 5, End: 5}},
+	}, obj)
+}
+
+func TestChangesAddExtend(t *testing.T) {
+	obj := Changes{
+		"file.go": {{Start: 5, End: 5}},
+	}
+
+	obj.add("file.go", 6)
+
+	assert.Equal(t, Changes{
+		"file.go": {{Start: 5, End: 6}},
+	}, obj)
+}
+
+func TestChangesAddDisjoint(t *testing.T) {
+	obj := Changes{
+		"file.go": {{Start: 5, End: 5}},
+	}
+
+	obj.add("file.go", 7)
+
+	assert.Equal(t, Changes{
+		"file.go": {{Start: 5, End: 5}, {Start: 7, End: 7}},
+	}, obj)
+}
+
+func TestChangesTouches(t *testing.T) {
+	obj := Changes{
+		"file.go": {{Start: 5, End: 6}, {Start: 10, End: 12}},
+	}
+
+	assert.True(t, obj.Touches("file.go", 1, 5))
+	assert.True(t, obj.Touches("file.go", 6, 9))
+	assert.True(t, obj.Touches("file.go", 11, 11))
+	assert.True(t, obj.Touches("file.go", 1, 20))
+	assert.False(t, obj.Touches("file.go", 7, 9))
+	assert.False(t, obj.Touches("file.go", 13, 20))
+	assert.False(t, obj.Touches("other.go", 1, 20))
+}
+
+func TestFileNameBase(t *testing.T) {
+	result := fileName("b/pkg/file.go")
+
+	assert.Equal(t, "pkg/file.go", result)
+}
+
+func TestFileNameQuoted(t *testing.T) {
+	result := fileName(`"b/pkg/fi\tle.go"`)
+
+	assert.Equal(t, "pkg/fi\tle.go", result)
+}
+
+func TestFileNameDeleted(t *testing.T) {
+	result := fileName("/dev/null")
+
+	assert.Equal(t, "", result)
+}
+
+func TestParseBase(t *testing.T) {
+	data := `diff --git a/pkg/file.go b/pkg/file.go
+index 1111111..2222222 100644
+--- a/pkg/file.go
++++ b/pkg/file.go
+@@ -3,4 +3,5 @@ import "fmt"
+ context
+-removed
++added 1
++++ added 2
+ context
+
+@@ -20 +21 @@ func f() {
+-old
++new
+\ No newline at end of file
+diff --git a/gone.go b/gone.go
+deleted file mode 100644
+--- a/gone.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package gone
+-
+diff --git a/new.go b/new.go
+new file mode 100644
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1,2 @@
++package new
++
+`
+
+	result, err := Parse([]byte(data))
+
+	assert.NoError(t, err)
+	assert.Equal(t, Changes{
+		"pkg/file.go": {{Start: 4, End: 5}, {Start: 21, End: 21}},
+		"new.go":      {{Start: 1, End: 2}},
+	}, result)
+}
+
+func TestParseBadHunk(t *testing.T) {
+	data := `--- a/pkg/file.go
++++ b/pkg/file.go
+@@ bad @@
+`
+
+	result, err := Parse([]byte(data))
+
+	assert.Same(t, ErrBadHunk, err)
+	assert.Nil(t, result)
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package diff
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Patch points for top-level functions called by functions in this
+// file.
+var (
+	command  func(string, ...string) *exec.Cmd = exec.Command
+	output   func(*exec.Cmd) ([]byte, error)   = (*exec.Cmd).Output
+	readFile func(string) ([]byte, error)      = os.ReadFile
+)
+
+// git runs a git command and returns its output.  If the command
+// fails, the returned error includes any error output from git.
+func git(args ...string) ([]byte, error) {
+	data, err := output(command("git", args...))
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+
+	return data, nil
+}
+
+// untracked runs "git ls-files" to determine the Go source files in
+// the working tree that git does not track and does not ignore.  Since
+// "git diff" does not report them, every line of these files is
+// recorded as added.  The names of the files in the result are
+// relative to the top of the working tree.
+func untracked(root string) (Changes, error) {
+	data, err := git("ls-files", "--others", "--exclude-standard", "--full-name", "-z", "--", ":/")
+	if err != nil {
+		return nil, err
+	}
+
+	result := Changes{}
+	for _, file := range strings.Split(string(data), "\x00") {
+		if !strings.HasSuffix(file, ".go") {
+			continue
+		}
+		text, err := readFile(filepath.Join(root, filepath.FromSlash(file)))
+		if err != nil {
+			return nil, err
+		}
+		lines := bytes.Count(text, []byte("\n"))
+		if len(text) > 0 && text[len(text)-1] != '\n' {
+			lines++
+		}
+		if lines > 0 {
+			result[file] = []Range{{Start: 1, End: lines}}
+		}
+	}
+
+	return result, nil
+}
+
+// Load runs "git diff" to determine the lines that have been added or
+// changed in the working tree relative to the specified base
+// revision.  Untracked Go source files that are not ignored are
+// treated as entirely added.  The names of the files in the result
+// are absolute.
+func Load(base string) (Changes, error) {
+	top, err := git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	data, err := git("diff", "--no-color", "--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/", "-U0", base, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add the untracked files
+	root := strings.TrimSpace(string(top))
+	added, err := untracked(root)
+	if err != nil {
+		return nil, err
+	}
+	for file, ranges := range added {
+		changes[file] = ranges
+	}
+
+	// Make the file names absolute
+	result := Changes{}
+	for file, ranges := range changes {
+		result[filepath.Join(root, filepath.FromSlash(file))] = ranges
+	}
+
+	return result, nil
+}
+
+// Head runs "git rev-parse" to determine the commit checked out in the
+// working tree.
+func Head() (string, error) {
+	data, err := git("rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,229 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package diff
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/klmitch/patcher"
+	"github.com/stretchr/testify/assert"
+)
+
+func patchGit(t *testing.T, outputs map[string][]byte, errs map[string]error) patcher.Patcher {
+	return patcher.NewPatchMaster(
+		patcher.SetVar(&command, func(name string, args ...string) *exec.Cmd {
+			assert.Equal(t, "git", name)
+			return &exec.Cmd{Args: append([]string{name}, args...)}
+		}),
+		patcher.SetVar(&output, func(cmd *exec.Cmd) ([]byte, error) {
+			return outputs[cmd.Args[1]], errs[cmd.Args[1]]
+		}),
+	)
+}
+
+func TestGitBase(t *testing.T) {
+	defer patchGit(t, map[string][]byte{"status": []byte("output")}, nil).Install().Restore()
+
+	result, err := git("status")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("output"), result)
+}
+
+func TestGitError(t *testing.T) {
+	defer patchGit(t, nil, map[string]error{"status": assert.AnError}).Install().Restore()
+
+	result, err := git("status")
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, "git status: "+assert.AnError.Error(), err.Error())
+	assert.Nil(t, result)
+}
+
+func TestGitExitError(t *testing.T) {
+	exitErr := &exec.ExitError{Stderr: []byte("fatal: bad revision\n")}
+	defer patchGit(t, nil, map[string]error{"status": exitErr}).Install().Restore()
+
+	result, err := git("status")
+
+	assert.ErrorIs(t, err, exitErr)
+	assert.Equal(t, "git status: "+exitErr.Error()+": fatal: bad revision", err.Error())
+	assert.Nil(t, result)
+}
+
+func TestUntrackedBase(t *testing.T) {
+	var lsArgs []string
+	files := map[string][]byte{
+		filepath.FromSlash("/src/repo/new.go"):       []byte("package repo\n\nfunc f() {}\n"),
+		filepath.FromSlash("/src/repo/pkg/short.go"): []byte("package pkg"),
+		filepath.FromSlash("/src/repo/pkg/empty.go"): {},
+	}
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&command, func(name string, args ...string) *exec.Cmd {
+			return &exec.Cmd{Args: append([]string{name}, args...)}
+		}),
+		patcher.SetVar(&output, func(cmd *exec.Cmd) ([]byte, error) {
+			lsArgs = cmd.Args[1:]
+			return []byte("new.go\x00notes.txt\x00pkg/short.go\x00pkg/empty.go\x00"), nil
+		}),
+		patcher.SetVar(&readFile, func(name string) ([]byte, error) {
+			data, ok := files[name]
+			assert.True(t, ok, name)
+			return data, nil
+		}),
+	).Install().Restore()
+
+	result, err := untracked(filepath.FromSlash("/src/repo"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, Changes{
+		"new.go":       {{Start: 1, End: 3}},
+		"pkg/short.go": {{Start: 1, End: 1}},
+	}, result)
+	assert.Equal(t, []string{"ls-files", "--others", "--exclude-standard", "--full-name", "-z", "--", ":/"}, lsArgs)
+}
+
+func TestUntrackedGitError(t *testing.T) {
+	defer patchGit(t, nil, map[string]error{"ls-files": assert.AnError}).Install().Restore()
+
+	result, err := untracked("/src/repo")
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Nil(t, result)
+}
+
+func TestUntrackedReadError(t *testing.T) {
+	defer patcher.NewPatchMaster(
+		patchGit(t, map[string][]byte{"ls-files": []byte("new.go\x00")}, nil),
+		patcher.SetVar(&readFile, func(name string) ([]byte, error) {
+			return nil, assert.AnError
+		}),
+	).Install().Restore()
+
+	result, err := untracked("/src/repo")
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Nil(t, result)
+}
+
+func TestLoadBase(t *testing.T) {
+	var diffArgs []string
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&command, func(name string, args ...string) *exec.Cmd {
+			return &exec.Cmd{Args: append([]string{name}, args...)}
+		}),
+		patcher.SetVar(&output, func(cmd *exec.Cmd) ([]byte, error) {
+			switch cmd.Args[1] {
+			case "rev-parse":
+				return []byte(filepath.FromSlash("/src/repo") + "\n"), nil
+			case "ls-files":
+				return []byte{}, nil
+			}
+			diffArgs = cmd.Args[1:]
+			return []byte("+++ b/pkg/file.go\n@@ -1 +1 @@\n-old\n+new\n"), nil
+		}),
+	).Install().Restore()
+
+	result, err := Load("main")
+
+	assert.NoError(t, err)
+	assert.Equal(t, Changes{
+		filepath.FromSlash("/src/repo/pkg/file.go"): {{Start: 1, End: 1}},
+	}, result)
+	assert.Equal(t, []string{"diff", "--no-color", "--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/", "-U0", "main", "--"}, diffArgs)
+}
+
+func TestLoadUntracked(t *testing.T) {
+	defer patcher.NewPatchMaster(
+		patchGit(t, map[string][]byte{
+			"rev-parse": []byte(filepath.FromSlash("/src/repo") + "\n"),
+			"diff":      []byte("+++ b/pkg/file.go\n@@ -1 +1 @@\n-old\n+new\n"),
+			"ls-files":  []byte("pkg/new.go\x00"),
+		}, nil),
+		patcher.SetVar(&readFile, func(name string) ([]byte, error) {
+			return []byte("package pkg\n\nfunc f() {}\n"), nil
+		}),
+	).Install().Restore()
+
+	result, err := Load("main")
+
+	assert.NoError(t, err)
+	assert.Equal(t, Changes{
+		filepath.FromSlash("/src/repo/pkg/file.go"): {{Start: 1, End: 1}},
+		filepath.FromSlash("/src/repo/pkg/new.go"):  {{Start: 1, End: 3}},
+	}, result)
+}
+
+func TestLoadUntrackedError(t *testing.T) {
+	defer patchGit(t, map[string][]byte{
+		"rev-parse": []byte("/src/repo\n"),
+		"diff":      []byte("+++ b/pkg/file.go\n@@ -1 +1 @@\n-old\n+new\n"),
+	}, map[string]error{"ls-files": assert.AnError}).Install().Restore()
+
+	result, err := Load("main")
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Nil(t, result)
+}
+
+func TestLoadRevParseError(t *testing.T) {
+	defer patchGit(t, nil, map[string]error{"rev-parse": assert.AnError}).Install().Restore()
+
+	result, err := Load("main")
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Nil(t, result)
+}
+
+func TestLoadDiffError(t *testing.T) {
+	defer patchGit(t, map[string][]byte{"rev-parse": []byte("/src/repo\n")}, map[string]error{"diff": assert.AnError}).Install().Restore()
+
+	result, err := Load("main")
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Nil(t, result)
+}
+
+func TestLoadParseError(t *testing.T) {
+	defer patchGit(t, map[string][]byte{
+		"rev-parse": []byte("/src/repo\n"),
+		"diff":      []byte("+++ b/pkg/file.go\n@@ bad @@\n"),
+	}, nil).Install().Restore()
+
+	result, err := Load("main")
+
+	assert.Same(t, ErrBadHunk, err)
+	assert.Nil(t, result)
+}
+
+func TestHeadBase(t *testing.T) {
+	defer patchGit(t, map[string][]byte{"rev-parse": []byte("0123abcd\n")}, nil).Install().Restore()
+
+	result, err := Head()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "0123abcd", result)
+}
+
+func TestHeadError(t *testing.T) {
+	defer patchGit(t, nil, map[string]error{"rev-parse": assert.AnError}).Install().Restore()
+
+	result, err := Head()
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, "", result)
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package history maintains a record of the coverage of past runs,
+// stored as a file of JSON lines, and computes the trends in the
+// coverage over those runs.
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/report"
+)
+
+// Entry describes the coverage of a single run.
+type Entry struct {
+	Time     time.Time         `json:"time"`             // Time of the run
+	Commit   string            `json:"commit,omitempty"` // Commit checked out, if known
+	Overall  report.Coverage   `json:"overall"`          // Overall coverage
+	Packages []report.Coverage `json:"packages"`         // Per-package coverage
+}
+
+// NewEntry constructs an Entry describing the coverage of a run from
+// its data set.
+func NewEntry(t time.Time, commit string, ds common.DataSet) Entry {
+	return Entry{
+		Time:     t.UTC(),
+		Commit:   commit,
+		Overall:  report.NewCoverage(ds.Sum()),
+		Packages: report.NewCoverageList(ds.Reduce()),
+	}
+}
+
+// WriteJSON writes the entry to a stream as a single line of JSON,
+// suitable for appending to a history file.
+func (e Entry) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(e)
+}
+
+// Read reads the entries of a history file from a stream.  The
+// entries are returned in the order in which they were written.
+func Read(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	dec := json.NewDecoder(r)
+	for {
+		var e Entry
+		err := dec.Decode(&e)
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", len(entries)+1, err)
+		}
+		entries = append(entries, e)
+	}
+}
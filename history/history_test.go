@@ -0,0 +1,91 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package history
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/report"
+)
+
+var testTime = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+var testEntry = Entry{
+	Time:    testTime,
+	Commit:  "0123abcd",
+	Overall: report.Coverage{Statements: 20, Executed: 9, Coverage: 45.0},
+	Packages: []report.Coverage{
+		{Package: "pkg/a", Statements: 10, Executed: 4, Coverage: 40.0},
+		{Package: "pkg/b", Statements: 10, Executed: 5, Coverage: 50.0},
+	},
+}
+
+func TestNewEntry(t *testing.T) {
+	ds := common.DataSet{
+		{Package: "pkg/b", Name: "file1.go", Count: 10, Exec: 5},
+		{Package: "pkg/a", Name: "file2.go", Count: 4, Exec: 4},
+		{Package: "pkg/a", Name: "file1.go", Count: 6, Exec: 0},
+	}
+
+	result := NewEntry(testTime.In(time.FixedZone("EST", -5*60*60)), "0123abcd", ds)
+
+	assert.Equal(t, testEntry, result)
+}
+
+func TestEntryWriteJSON(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	err := testEntry.WriteJSON(buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"time":"2020-01-02T03:04:05Z","commit":"0123abcd","overall":{"statements":20,"executed":9,"coverage":45},"packages":[{"package":"pkg/a","statements":10,"executed":4,"coverage":40},{"package":"pkg/b","statements":10,"executed":5,"coverage":50}]}`+"\n", buf.String())
+}
+
+func TestRead(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, testEntry.WriteJSON(buf))
+	buf.WriteString("\n")
+	require.NoError(t, testEntry.WriteJSON(buf))
+
+	result, err := Read(buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Entry{testEntry, testEntry}, result)
+}
+
+func TestReadEmpty(t *testing.T) {
+	result, err := Read(strings.NewReader(""))
+
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func TestReadError(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, testEntry.WriteJSON(buf))
+	buf.WriteString("{bad\n")
+
+	result, err := Read(buf)
+
+	assert.ErrorContains(t, err, "entry 2: ")
+	assert.Nil(t, result)
+}
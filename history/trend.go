@@ -0,0 +1,143 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package history
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// sparkLevels are the characters used to draw a sparkline, from the
+// lowest value to the highest.
+const sparkLevels = ".:-=+*#%@"
+
+// Trend describes the coverage of a package, or the overall coverage,
+// over a series of runs.
+type Trend struct {
+	Package string    // Package import path; empty for the overall coverage
+	Values  []float64 // Coverage as a percentage in each run including the package
+}
+
+// First returns the coverage in the earliest run.
+func (t Trend) First() float64 {
+	return t.Values[0]
+}
+
+// Last returns the coverage in the latest run.
+func (t Trend) Last() float64 {
+	return t.Values[len(t.Values)-1]
+}
+
+// Change returns the change in coverage, in percentage points, from
+// the earliest run to the latest run.
+func (t Trend) Change() float64 {
+	return t.Last() - t.First()
+}
+
+// Trends computes the trend of the overall coverage and of the
+// coverage of each package over the entries.  A package appearing in
+// only some of the entries has a shorter trend.  The package trends
+// are sorted by package.
+func Trends(entries []Entry) (Trend, []Trend) {
+	overall := Trend{}
+	idx := map[string]int{}
+	var pkgs []Trend
+	for _, e := range entries {
+		overall.Values = append(overall.Values, e.Overall.Coverage)
+		for _, cov := range e.Packages {
+			i, ok := idx[cov.Package]
+			if !ok {
+				i = len(pkgs)
+				idx[cov.Package] = i
+				pkgs = append(pkgs, Trend{Package: cov.Package})
+			}
+			pkgs[i].Values = append(pkgs[i].Values, cov.Coverage)
+		}
+	}
+
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].Package < pkgs[j].Package
+	})
+
+	return overall, pkgs
+}
+
+// Movers selects the packages whose coverage improved the most and
+// those whose coverage dropped the most, up to n of each.  Packages
+// whose coverage did not change are not included.
+func Movers(trends []Trend, n int) ([]Trend, []Trend) {
+	sorted := make([]Trend, len(trends))
+	copy(sorted, trends)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Change() > sorted[j].Change()
+	})
+
+	var best, worst []Trend
+	for i := 0; i < len(sorted) && len(best) < n && sorted[i].Change() > 0; i++ {
+		best = append(best, sorted[i])
+	}
+	for i := len(sorted) - 1; i >= 0 && len(worst) < n && sorted[i].Change() < 0; i-- {
+		worst = append(worst, sorted[i])
+	}
+
+	return best, worst
+}
+
+// Sparkline draws the values as a line of ASCII characters, with
+// higher values drawn with denser characters.  The values are scaled
+// to the range between the lowest and the highest of them, so that
+// small changes remain visible; if all of the values are the same,
+// they are drawn with the least dense character.  If there are more
+// values than the width, adjacent values are averaged together so
+// that the line is no wider than the width.
+func Sparkline(values []float64, width int) string {
+	// Reduce the values to the width
+	if width > 0 && len(values) > width {
+		reduced := make([]float64, width)
+		for i := range reduced {
+			start := i * len(values) / width
+			end := (i + 1) * len(values) / width
+			sum := 0.0
+			for _, v := range values[start:end] {
+				sum += v
+			}
+			reduced[i] = sum / float64(end-start)
+		}
+		values = reduced
+	}
+
+	// Find the range of the values
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values[1:] {
+		lo = min(lo, v)
+		hi = max(hi, v)
+	}
+
+	// Draw the line
+	sb := &strings.Builder{}
+	for _, v := range values {
+		level := 0
+		if hi > lo {
+			level = int(math.Round((v - lo) / (hi - lo) * float64(len(sparkLevels)-1)))
+		}
+		sb.WriteByte(sparkLevels[level])
+	}
+
+	return sb.String()
+}
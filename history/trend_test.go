@@ -0,0 +1,113 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package history
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/klmitch/overcover/report"
+)
+
+func TestTrend(t *testing.T) {
+	obj := Trend{Values: []float64{40.0, 55.0, 50.0}}
+
+	assert.Equal(t, 40.0, obj.First())
+	assert.Equal(t, 50.0, obj.Last())
+	assert.Equal(t, 10.0, obj.Change())
+}
+
+func TestTrends(t *testing.T) {
+	entries := []Entry{
+		{
+			Overall: report.Coverage{Coverage: 45.0},
+			Packages: []report.Coverage{
+				{Package: "pkg/b", Coverage: 50.0},
+				{Package: "pkg/a", Coverage: 40.0},
+			},
+		},
+		{
+			Overall: report.Coverage{Coverage: 50.0},
+			Packages: []report.Coverage{
+				{Package: "pkg/a", Coverage: 45.0},
+				{Package: "pkg/b", Coverage: 50.0},
+				{Package: "pkg/c", Coverage: 60.0},
+			},
+		},
+	}
+
+	overall, pkgs := Trends(entries)
+
+	assert.Equal(t, Trend{Values: []float64{45.0, 50.0}}, overall)
+	assert.Equal(t, []Trend{
+		{Package: "pkg/a", Values: []float64{40.0, 45.0}},
+		{Package: "pkg/b", Values: []float64{50.0, 50.0}},
+		{Package: "pkg/c", Values: []float64{60.0}},
+	}, pkgs)
+}
+
+func TestMovers(t *testing.T) {
+	trends := []Trend{
+		{Package: "pkg/a", Values: []float64{40.0, 45.0}},
+		{Package: "pkg/b", Values: []float64{50.0, 50.0}},
+		{Package: "pkg/c", Values: []float64{60.0, 80.0}},
+		{Package: "pkg/d", Values: []float64{60.0, 50.0}},
+		{Package: "pkg/e", Values: []float64{60.0, 55.0}},
+		{Package: "pkg/f", Values: []float64{60.0, 59.0}},
+		{Package: "pkg/g", Values: []float64{10.0, 11.0}},
+	}
+
+	best, worst := Movers(trends, 2)
+
+	assert.Equal(t, []Trend{trends[2], trends[0]}, best)
+	assert.Equal(t, []Trend{trends[3], trends[4]}, worst)
+	assert.Equal(t, "pkg/a", trends[0].Package)
+}
+
+func TestMoversNone(t *testing.T) {
+	trends := []Trend{
+		{Package: "pkg/b", Values: []float64{50.0, 50.0}},
+	}
+
+	best, worst := Movers(trends, 2)
+
+	assert.Nil(t, best)
+	assert.Nil(t, worst)
+}
+
+func TestSparkline(t *testing.T) {
+	result := Sparkline([]float64{40.0, 50.0, 60.0, 45.0, 47.5}, 0)
+
+	assert.Equal(t, ".+@-=", result)
+}
+
+func TestSparklineFlat(t *testing.T) {
+	result := Sparkline([]float64{50.0, 50.0, 50.0}, 0)
+
+	assert.Equal(t, "...", result)
+}
+
+func TestSparklineEmpty(t *testing.T) {
+	result := Sparkline(nil, 10)
+
+	assert.Equal(t, "", result)
+}
+
+func TestSparklineReduced(t *testing.T) {
+	result := Sparkline([]float64{40.0, 40.0, 50.0, 50.0, 60.0, 60.0}, 3)
+
+	assert.Equal(t, ".+@", result)
+}
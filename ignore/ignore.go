@@ -0,0 +1,191 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package ignore locates the "//overcover:ignore" directives in a
+// source file, which exclude functions, blocks, and statements--or,
+// with "//overcover:ignore-file", the whole file--from the coverage.
+package ignore
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/klmitch/overcover/common"
+)
+
+// Directives recognized in comments.
+const (
+	DirectiveIgnore     = "//overcover:ignore"      // Ignore the following node
+	DirectiveIgnoreFile = "//overcover:ignore-file" // Ignore the whole file
+)
+
+// Span describes the region of a source file covered by an ignored
+// node.  Columns are byte offsets, counted from 1, as in coverage
+// profiles.
+type Span struct {
+	StartLine int  // Line on which the region starts
+	StartCol  int  // Column at which the region starts
+	EndLine   int  // Line on which the region ends
+	EndCol    int  // Column at which the region ends
+	Stmt      bool // Whether the node is a statement
+}
+
+// before reports whether the first position is before or the same as
+// the second position.
+func before(line1, col1, line2, col2 int) bool {
+	return line1 < line2 || (line1 == line2 && col1 <= col2)
+}
+
+// contains reports whether the span contains a block.
+func (s Span) contains(blk common.Block) bool {
+	return before(s.StartLine, s.StartCol, blk.StartLine, blk.StartCol) && before(blk.EndLine, blk.EndCol, s.EndLine, s.EndCol)
+}
+
+// startsIn reports whether the span starts within a block.
+func (s Span) startsIn(blk common.Block) bool {
+	return before(blk.StartLine, blk.StartCol, s.StartLine, s.StartCol) && !before(blk.EndLine, blk.EndCol, s.StartLine, s.StartCol)
+}
+
+// Directives describes the parts of a source file excluded by
+// directives.
+type Directives struct {
+	File  bool   // Whether the whole file is ignored
+	Spans []Span // The regions covered by the ignored nodes
+}
+
+// isDirective reports whether the text of a comment is the specified
+// directive.  The directive may be followed by an explanation,
+// separated from it by white space.
+func isDirective(text, directive string) bool {
+	rest := strings.TrimPrefix(text, directive)
+	return rest != text && (rest == "" || rest[0] == ' ' || rest[0] == '\t')
+}
+
+// target reports whether a node may be the target of an ignore
+// directive--a function declaration, a case clause, or a statement
+// other than a block--and whether it is a statement.
+func target(n ast.Node) (bool, bool) {
+	switch n.(type) {
+	case *ast.FuncDecl, *ast.CaseClause, *ast.CommClause:
+		return true, false
+	case *ast.BlockStmt:
+		return false, false
+	case ast.Stmt:
+		return true, true
+	}
+
+	return false, false
+}
+
+// Find locates the directives in a source file, which must have been
+// parsed with comments.  An "//overcover:ignore" directive at the end
+// of a line applies to the outermost function declaration, case
+// clause, or statement starting on that line; on a line by itself,
+// it applies to the one starting on the line following its comment
+// group, so that it may appear anywhere in a documentation comment.
+// An "//overcover:ignore-file" directive must appear before the
+// package clause.  Nil is returned if the file contains no
+// directives.
+func Find(fset *token.FileSet, file *ast.File) *Directives {
+	// Locate the directives, along with the line following the
+	// comment group containing each
+	var lines, follows []int
+	fileIgnored := false
+	for _, group := range file.Comments {
+		follow := fset.Position(group.End()).Line + 1
+		for _, c := range group.List {
+			switch {
+			case isDirective(c.Text, DirectiveIgnoreFile):
+				fileIgnored = fileIgnored || c.Pos() < file.Package
+			case isDirective(c.Text, DirectiveIgnore):
+				lines = append(lines, fset.Position(c.Pos()).Line)
+				follows = append(follows, follow)
+			}
+		}
+	}
+	if !fileIgnored && len(lines) == 0 {
+		return nil
+	}
+
+	// Find the outermost target starting on each line
+	starts := map[int]ast.Node{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if ok, _ := target(n); ok {
+			line := fset.Position(n.Pos()).Line
+			if _, seen := starts[line]; !seen {
+				starts[line] = n
+			}
+		}
+		return true
+	})
+
+	// Resolve each directive to its node
+	d := &Directives{File: fileIgnored}
+	ignored := map[ast.Node]bool{}
+	for i, line := range lines {
+		n, ok := starts[line]
+		if !ok {
+			n, ok = starts[follows[i]]
+		}
+		if !ok || ignored[n] {
+			continue
+		}
+
+		start := fset.Position(n.Pos())
+		end := fset.Position(n.End())
+		_, isStmt := target(n)
+		ignored[n] = true
+		d.Spans = append(d.Spans, Span{
+			StartLine: start.Line,
+			StartCol:  start.Column,
+			EndLine:   end.Line,
+			EndCol:    end.Column,
+			Stmt:      isStmt,
+		})
+	}
+
+	return d
+}
+
+// Apply removes the ignored statements from a list of coverage
+// blocks.  Blocks lying entirely within an ignored node are dropped.
+// An ignored statement starting within some other block--such as an
+// ignored "if" statement, whose condition belongs to the enclosing
+// block--is removed from that block's statement count, and the block
+// is dropped if no statements remain.  If the whole file is ignored,
+// no blocks are returned.  A new list of blocks is returned.
+func (d *Directives) Apply(blocks []common.Block) []common.Block {
+	result := []common.Block{}
+	if d.File {
+		return result
+	}
+
+	for _, blk := range blocks {
+		for _, span := range d.Spans {
+			if span.contains(blk) {
+				blk.NumStmt = 0
+				break
+			}
+			if span.Stmt && span.startsIn(blk) {
+				blk.NumStmt--
+			}
+		}
+		if blk.NumStmt > 0 {
+			result = append(result, blk)
+		}
+	}
+
+	return result
+}
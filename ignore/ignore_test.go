@@ -0,0 +1,290 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ignore
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/klmitch/overcover/common"
+)
+
+const testSource = `package test
+
+func Kind(x int) string {
+	y := x * 2
+	switch {
+	case y > 0:
+		return "positive"
+	default: //overcover:ignore unreachable
+		panic("unreachable")
+	}
+}
+
+func Check(x int) bool {
+	z := x + 1
+	if z < 0 { //overcover:ignore defensive
+		return false
+	}
+	_ = z //overcover:ignore
+	return true
+}
+
+//overcover:ignore
+func Unused() int {
+	return 0 //overcover:ignore already ignored
+}
+
+//overcover:ignored is not a directive
+
+//overcover:ignore
+
+func Orphaned() {}
+`
+
+func parse(t *testing.T, src string) (*token.FileSet, *ast.File) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "test.go", src, parser.ParseComments)
+	require.NoError(t, err)
+
+	return fset, file
+}
+
+func TestBeforeLine(t *testing.T) {
+	assert.True(t, before(1, 5, 2, 1))
+	assert.False(t, before(2, 1, 1, 5))
+}
+
+func TestBeforeColumn(t *testing.T) {
+	assert.True(t, before(1, 1, 1, 5))
+	assert.True(t, before(1, 5, 1, 5))
+	assert.False(t, before(1, 6, 1, 5))
+}
+
+func TestSpanContains(t *testing.T) {
+	obj := Span{StartLine: 2, StartCol: 2, EndLine: 5, EndCol: 3}
+
+	assert.True(t, obj.contains(common.Block{StartLine: 2, StartCol: 10, EndLine: 5, EndCol: 2}))
+	assert.True(t, obj.contains(common.Block{StartLine: 2, StartCol: 2, EndLine: 5, EndCol: 3}))
+	assert.False(t, obj.contains(common.Block{StartLine: 1, StartCol: 2, EndLine: 5, EndCol: 2}))
+	assert.False(t, obj.contains(common.Block{StartLine: 2, StartCol: 10, EndLine: 6, EndCol: 2}))
+}
+
+func TestSpanStartsIn(t *testing.T) {
+	obj := Span{StartLine: 2, StartCol: 2, EndLine: 5, EndCol: 3}
+
+	assert.True(t, obj.startsIn(common.Block{StartLine: 1, StartCol: 2, EndLine: 2, EndCol: 10}))
+	assert.True(t, obj.startsIn(common.Block{StartLine: 2, StartCol: 2, EndLine: 2, EndCol: 10}))
+	assert.False(t, obj.startsIn(common.Block{StartLine: 1, StartCol: 2, EndLine: 2, EndCol: 2}))
+	assert.False(t, obj.startsIn(common.Block{StartLine: 2, StartCol: 10, EndLine: 3, EndCol: 2}))
+}
+
+func TestIsDirectiveBare(t *testing.T) {
+	assert.True(t, isDirective("//overcover:ignore", DirectiveIgnore))
+}
+
+func TestIsDirectiveExplained(t *testing.T) {
+	assert.True(t, isDirective("//overcover:ignore because", DirectiveIgnore))
+	assert.True(t, isDirective("//overcover:ignore\tbecause", DirectiveIgnore))
+}
+
+func TestIsDirectiveOther(t *testing.T) {
+	assert.False(t, isDirective("//overcover:ignore-file", DirectiveIgnore))
+	assert.False(t, isDirective("// overcover:ignore", DirectiveIgnore))
+}
+
+func TestTarget(t *testing.T) {
+	ok, stmt := target(&ast.FuncDecl{})
+	assert.True(t, ok)
+	assert.False(t, stmt)
+
+	ok, stmt = target(&ast.CaseClause{})
+	assert.True(t, ok)
+	assert.False(t, stmt)
+
+	ok, stmt = target(&ast.CommClause{})
+	assert.True(t, ok)
+	assert.False(t, stmt)
+
+	ok, stmt = target(&ast.BlockStmt{})
+	assert.False(t, ok)
+	assert.False(t, stmt)
+
+	ok, stmt = target(&ast.IfStmt{})
+	assert.True(t, ok)
+	assert.True(t, stmt)
+
+	ok, stmt = target(&ast.Ident{})
+	assert.False(t, ok)
+	assert.False(t, stmt)
+}
+
+func TestFindBase(t *testing.T) {
+	fset, file := parse(t, testSource)
+
+	result := Find(fset, file)
+
+	require.NotNil(t, result)
+	assert.False(t, result.File)
+	assert.Equal(t, []Span{
+		{StartLine: 8, StartCol: 2, EndLine: 9, EndCol: 23},
+		{StartLine: 15, StartCol: 2, EndLine: 17, EndCol: 3, Stmt: true},
+		{StartLine: 18, StartCol: 2, EndLine: 18, EndCol: 7, Stmt: true},
+		{StartLine: 23, StartCol: 1, EndLine: 25, EndCol: 2},
+		{StartLine: 24, StartCol: 2, EndLine: 24, EndCol: 10, Stmt: true},
+	}, result.Spans)
+}
+
+func TestFindDuplicate(t *testing.T) {
+	fset, file := parse(t, `package test
+
+func Test() int {
+	//overcover:ignore
+	return 0 //overcover:ignore
+}
+`)
+
+	result := Find(fset, file)
+
+	require.NotNil(t, result)
+	assert.Equal(t, []Span{
+		{StartLine: 5, StartCol: 2, EndLine: 5, EndCol: 10, Stmt: true},
+	}, result.Spans)
+}
+
+func TestFindDocComment(t *testing.T) {
+	fset, file := parse(t, `package test
+
+//overcover:ignore
+// Test does nothing of interest.
+//
+// It is only called by hand.
+func Test() int {
+	return 0
+}
+`)
+
+	result := Find(fset, file)
+
+	require.NotNil(t, result)
+	assert.Equal(t, []Span{
+		{StartLine: 7, StartCol: 1, EndLine: 9, EndCol: 2},
+	}, result.Spans)
+}
+
+func TestFindFile(t *testing.T) {
+	fset, file := parse(t, `//overcover:ignore-file generated glue
+
+package test
+
+func Test() int {
+	return 0
+}
+`)
+
+	result := Find(fset, file)
+
+	assert.Equal(t, &Directives{
+		File: true,
+	}, result)
+}
+
+func TestFindFileMisplaced(t *testing.T) {
+	fset, file := parse(t, `package test
+
+//overcover:ignore-file
+
+func Test() int {
+	return 0
+}
+`)
+
+	result := Find(fset, file)
+
+	assert.Nil(t, result)
+}
+
+func TestFindNone(t *testing.T) {
+	fset, file := parse(t, `// Package test is a test.
+package test
+
+func Test() int {
+	return 0
+}
+`)
+
+	result := Find(fset, file)
+
+	assert.Nil(t, result)
+}
+
+func TestDirectivesApplyBase(t *testing.T) {
+	obj := &Directives{
+		Spans: []Span{
+			{StartLine: 8, StartCol: 2, EndLine: 9, EndCol: 23},
+			{StartLine: 15, StartCol: 2, EndLine: 17, EndCol: 3, Stmt: true},
+			{StartLine: 18, StartCol: 2, EndLine: 18, EndCol: 7, Stmt: true},
+		},
+	}
+	blocks := []common.Block{
+		{StartLine: 4, StartCol: 2, EndLine: 5, EndCol: 9, NumStmt: 2, Count: 1},
+		{StartLine: 7, StartCol: 3, EndLine: 7, EndCol: 20, NumStmt: 1, Count: 1},
+		{StartLine: 9, StartCol: 3, EndLine: 9, EndCol: 23, NumStmt: 1},
+		{StartLine: 14, StartCol: 2, EndLine: 15, EndCol: 11, NumStmt: 2, Count: 1},
+		{StartLine: 16, StartCol: 3, EndLine: 17, EndCol: 1, NumStmt: 1},
+		{StartLine: 18, StartCol: 2, EndLine: 19, EndCol: 13, NumStmt: 2, Count: 1},
+	}
+
+	result := obj.Apply(blocks)
+
+	assert.Equal(t, []common.Block{
+		{StartLine: 4, StartCol: 2, EndLine: 5, EndCol: 9, NumStmt: 2, Count: 1},
+		{StartLine: 7, StartCol: 3, EndLine: 7, EndCol: 20, NumStmt: 1, Count: 1},
+		{StartLine: 14, StartCol: 2, EndLine: 15, EndCol: 11, NumStmt: 1, Count: 1},
+		{StartLine: 18, StartCol: 2, EndLine: 19, EndCol: 13, NumStmt: 1, Count: 1},
+	}, result)
+	assert.Equal(t, 2, blocks[3].NumStmt)
+}
+
+func TestDirectivesApplyEmptied(t *testing.T) {
+	obj := &Directives{
+		Spans: []Span{
+			{StartLine: 3, StartCol: 2, EndLine: 5, EndCol: 3, Stmt: true},
+		},
+	}
+	blocks := []common.Block{
+		{StartLine: 3, StartCol: 2, EndLine: 3, EndCol: 11, NumStmt: 1, Count: 1},
+		{StartLine: 4, StartCol: 3, EndLine: 5, EndCol: 1, NumStmt: 1},
+	}
+
+	result := obj.Apply(blocks)
+
+	assert.Equal(t, []common.Block{}, result)
+}
+
+func TestDirectivesApplyFile(t *testing.T) {
+	obj := &Directives{File: true}
+
+	result := obj.Apply([]common.Block{
+		{StartLine: 3, StartCol: 2, EndLine: 3, EndCol: 11, NumStmt: 1, Count: 1},
+	})
+
+	assert.Equal(t, []common.Block{}, result)
+}
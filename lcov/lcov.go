@@ -0,0 +1,137 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package lcov reads LCOV tracefiles, such as the coverage.dat files
+// generated by Bazel, converting them into coverage profiles so that
+// they may be merged with the profiles generated by the go tools.
+package lcov
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/cover"
+
+	"github.com/klmitch/overcover/modules"
+)
+
+// ErrBadRecord is returned when a tracefile contains a malformed
+// record.
+var ErrBadRecord = errors.New("malformed LCOV record")
+
+// Patch points for top-level functions called by functions in this
+// file.
+var readFile func(string) ([]byte, error) = os.ReadFile
+
+// record describes the line execution counts for a single source
+// file.
+type record struct {
+	name string      // The source file name
+	hits map[int]int // Execution counts by line number
+}
+
+// parse parses the contents of an LCOV tracefile.  Only the source
+// file and line data records are interpreted; all others are
+// ignored.  Records for the same source file are combined by summing
+// the execution counts.  The records are returned in the order in
+// which the source files first appear.
+func parse(data []byte) ([]*record, error) {
+	idx := map[string]*record{}
+	var result []*record
+	var cur *record
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "SF:"):
+			name := strings.TrimPrefix(line, "SF:")
+			if cur = idx[name]; cur == nil {
+				cur = &record{name: name, hits: map[int]int{}}
+				idx[name] = cur
+				result = append(result, cur)
+			}
+
+		case strings.HasPrefix(line, "DA:"):
+			// Split the line number, count, and optional checksum
+			fields := strings.Split(strings.TrimPrefix(line, "DA:"), ",")
+			if cur == nil || len(fields) < 2 {
+				return nil, fmt.Errorf("%w at line %d", ErrBadRecord, lineno)
+			}
+			num, err := strconv.Atoi(fields[0])
+			if err != nil || num <= 0 {
+				return nil, fmt.Errorf("%w at line %d", ErrBadRecord, lineno)
+			}
+			count, err := strconv.Atoi(fields[1])
+			if err != nil || count < 0 {
+				return nil, fmt.Errorf("%w at line %d", ErrBadRecord, lineno)
+			}
+			cur.hits[num] += count
+
+		case line == "end_of_record":
+			cur = nil
+		}
+	}
+
+	return result, scanner.Err()
+}
+
+// Load loads an LCOV tracefile and converts it into coverage
+// profiles.  Since LCOV records the execution counts of lines rather
+// than of blocks of statements, each line is treated as a block
+// containing a single statement.
+func Load(fname string) ([]*cover.Profile, error) {
+	data, err := readFile(fname)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert the records
+	r := modules.NewResolver()
+	result := make([]*cover.Profile, 0, len(records))
+	for _, rec := range records {
+		prof := &cover.Profile{
+			FileName: r.ImportPath(rec.name),
+			Mode:     "count",
+			Blocks:   make([]cover.ProfileBlock, 0, len(rec.hits)),
+		}
+		for num, count := range rec.hits {
+			prof.Blocks = append(prof.Blocks, cover.ProfileBlock{
+				StartLine: num,
+				StartCol:  1,
+				EndLine:   num,
+				EndCol:    1,
+				NumStmt:   1,
+				Count:     count,
+			})
+		}
+		sort.Slice(prof.Blocks, func(i, j int) bool {
+			return prof.Blocks[i].StartLine < prof.Blocks[j].StartLine
+		})
+		result = append(result, prof)
+	}
+
+	return result, nil
+}
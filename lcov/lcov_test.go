@@ -0,0 +1,160 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lcov
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/klmitch/patcher"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/cover"
+)
+
+func TestParseBase(t *testing.T) {
+	data := []byte(`TN:
+SF:pkg/file1.go
+FN:3,Func
+FNDA:1,Func
+DA:3,1
+DA:4,0,checksum
+LF:2
+LH:1
+end_of_record
+TN:
+SF:pkg/file2.go
+DA:5,2
+end_of_record
+SF:pkg/file1.go
+DA:4,3
+end_of_record
+`)
+
+	result, err := parse(data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*record{
+		{name: "pkg/file1.go", hits: map[int]int{3: 1, 4: 3}},
+		{name: "pkg/file2.go", hits: map[int]int{5: 2}},
+	}, result)
+}
+
+func TestParseOutsideRecord(t *testing.T) {
+	data := []byte("SF:pkg/file1.go\nDA:3,1\nend_of_record\nDA:4,1\n")
+
+	result, err := parse(data)
+
+	assert.ErrorIs(t, err, ErrBadRecord)
+	assert.Equal(t, "malformed LCOV record at line 4", err.Error())
+	assert.Nil(t, result)
+}
+
+func TestParseMissingCount(t *testing.T) {
+	data := []byte("SF:pkg/file1.go\nDA:3\n")
+
+	result, err := parse(data)
+
+	assert.ErrorIs(t, err, ErrBadRecord)
+	assert.Equal(t, "malformed LCOV record at line 2", err.Error())
+	assert.Nil(t, result)
+}
+
+func TestParseBadLine(t *testing.T) {
+	data := []byte("SF:pkg/file1.go\nDA:x,1\n")
+
+	result, err := parse(data)
+
+	assert.ErrorIs(t, err, ErrBadRecord)
+	assert.Nil(t, result)
+}
+
+func TestParseZeroLine(t *testing.T) {
+	data := []byte("SF:pkg/file1.go\nDA:0,1\n")
+
+	result, err := parse(data)
+
+	assert.ErrorIs(t, err, ErrBadRecord)
+	assert.Nil(t, result)
+}
+
+func TestParseBadCount(t *testing.T) {
+	data := []byte("SF:pkg/file1.go\nDA:3,x\n")
+
+	result, err := parse(data)
+
+	assert.ErrorIs(t, err, ErrBadRecord)
+	assert.Nil(t, result)
+}
+
+func TestParseNegativeCount(t *testing.T) {
+	data := []byte("SF:pkg/file1.go\nDA:3,-1\n")
+
+	result, err := parse(data)
+
+	assert.ErrorIs(t, err, ErrBadRecord)
+	assert.Nil(t, result)
+}
+
+func TestParseScanError(t *testing.T) {
+	data := []byte("SF:" + strings.Repeat("x", bufio.MaxScanTokenSize) + "\n")
+
+	_, err := parse(data)
+
+	assert.ErrorIs(t, err, bufio.ErrTooLong)
+}
+
+func TestLoadBase(t *testing.T) {
+	defer patcher.SetVar(&readFile, func(fname string) ([]byte, error) {
+		assert.Equal(t, "coverage.dat", fname)
+		return []byte("SF:pkg/file1.go\nDA:4,0\nDA:3,2\nend_of_record\n"), nil
+	}).Install().Restore()
+
+	result, err := Load("coverage.dat")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*cover.Profile{
+		{
+			FileName: "github.com/klmitch/overcover/lcov/pkg/file1.go",
+			Mode:     "count",
+			Blocks: []cover.ProfileBlock{
+				{StartLine: 3, StartCol: 1, EndLine: 3, EndCol: 1, NumStmt: 1, Count: 2},
+				{StartLine: 4, StartCol: 1, EndLine: 4, EndCol: 1, NumStmt: 1, Count: 0},
+			},
+		},
+	}, result)
+}
+
+func TestLoadReadFails(t *testing.T) {
+	defer patcher.SetVar(&readFile, func(_ string) ([]byte, error) {
+		return nil, assert.AnError
+	}).Install().Restore()
+
+	result, err := Load("coverage.dat")
+
+	assert.Same(t, assert.AnError, err)
+	assert.Nil(t, result)
+}
+
+func TestLoadParseFails(t *testing.T) {
+	defer patcher.SetVar(&readFile, func(_ string) ([]byte, error) {
+		return []byte("DA:3,1\n"), nil
+	}).Install().Restore()
+
+	result, err := Load("coverage.dat")
+
+	assert.ErrorIs(t, err, ErrBadRecord)
+	assert.Nil(t, result)
+}
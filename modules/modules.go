@@ -0,0 +1,67 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package modules
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/mod/modfile"
+)
+
+// Errors that may be returned while locating the module or workspace.
+var (
+	ErrNoModule     = errors.New("no go.mod file found")
+	ErrNoModulePath = errors.New("go.mod file has no module directive")
+	ErrNoWorkspace  = errors.New("no go.work file found")
+)
+
+// Patch points for top-level functions called by functions in this
+// file.
+var (
+	abs      func(string) (string, error) = filepath.Abs
+	readFile func(string) ([]byte, error) = os.ReadFile
+)
+
+// Find locates the go.mod file for the module containing the
+// specified directory, searching the directory and its parents.  It
+// returns the module's root directory and module path.
+func Find(dir string) (string, string, error) {
+	dir, err := abs(dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	for {
+		data, err := readFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			modPath := modfile.ModulePath(data)
+			if modPath == "" {
+				return "", "", ErrNoModulePath
+			}
+			return dir, modPath, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", "", err
+		}
+
+		// Try the parent
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", ErrNoModule
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package modules
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klmitch/patcher"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindBase(t *testing.T) {
+	dir := filepath.FromSlash("/src/mod/sub/pkg")
+	files := map[string]string{
+		filepath.FromSlash("/src/mod/go.mod"): "module example.com/mod\n\ngo 1.21\n",
+	}
+	defer patcher.SetVar(&readFile, func(fname string) ([]byte, error) {
+		if data, ok := files[fname]; ok {
+			return []byte(data), nil
+		}
+		return nil, os.ErrNotExist
+	}).Install().Restore()
+
+	root, modPath, err := Find(dir)
+
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.FromSlash("/src/mod"), root)
+	assert.Equal(t, "example.com/mod", modPath)
+}
+
+func TestFindNoModule(t *testing.T) {
+	defer patcher.SetVar(&readFile, func(_ string) ([]byte, error) {
+		return nil, os.ErrNotExist
+	}).Install().Restore()
+
+	root, modPath, err := Find(filepath.FromSlash("/src/mod"))
+
+	assert.Same(t, ErrNoModule, err)
+	assert.Equal(t, "", root)
+	assert.Equal(t, "", modPath)
+}
+
+func TestFindNoModulePath(t *testing.T) {
+	defer patcher.SetVar(&readFile, func(_ string) ([]byte, error) {
+		return []byte("go 1.21\n"), nil
+	}).Install().Restore()
+
+	root, modPath, err := Find(filepath.FromSlash("/src/mod"))
+
+	assert.Same(t, ErrNoModulePath, err)
+	assert.Equal(t, "", root)
+	assert.Equal(t, "", modPath)
+}
+
+func TestFindReadError(t *testing.T) {
+	defer patcher.SetVar(&readFile, func(_ string) ([]byte, error) {
+		return nil, assert.AnError
+	}).Install().Restore()
+
+	root, modPath, err := Find(filepath.FromSlash("/src/mod"))
+
+	assert.Same(t, assert.AnError, err)
+	assert.Equal(t, "", root)
+	assert.Equal(t, "", modPath)
+}
+
+func TestFindAbsError(t *testing.T) {
+	defer patcher.SetVar(&abs, func(_ string) (string, error) {
+		return "", assert.AnError
+	}).Install().Restore()
+
+	root, modPath, err := Find("mod")
+
+	assert.Same(t, assert.AnError, err)
+	assert.Equal(t, "", root)
+	assert.Equal(t, "", modPath)
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package modules
+
+import (
+	"path"
+	"path/filepath"
+)
+
+// Patch points for top-level functions called by functions in this
+// file.
+var find = Find
+
+// Resolver maps source file names to the import paths of the files,
+// caching the modules containing each directory.
+type Resolver struct {
+	mods map[string]Module // Modules by directory
+}
+
+// NewResolver constructs a new Resolver.
+func NewResolver() *Resolver {
+	return &Resolver{
+		mods: map[string]Module{},
+	}
+}
+
+// ImportPath computes the import path of a source file, as used in
+// coverage profiles.  Relative file names are interpreted relative to
+// the current directory.  If the file is not within a module, its
+// name is returned unchanged.
+func (r *Resolver) ImportPath(name string) string {
+	fname, err := abs(filepath.FromSlash(name))
+	if err != nil {
+		return name
+	}
+
+	// Locate the module containing the file
+	dir := filepath.Dir(fname)
+	mod, ok := r.mods[dir]
+	if !ok {
+		root, modPath, err := find(dir)
+		if err == nil {
+			mod = Module{Root: root, Path: modPath}
+		}
+		r.mods[dir] = mod
+	}
+	if mod.Path == "" {
+		return name
+	}
+
+	// The file is beneath the module root, so this cannot fail
+	rel, _ := filepath.Rel(mod.Root, fname)
+	return path.Join(mod.Path, filepath.ToSlash(rel))
+}
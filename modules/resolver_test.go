@@ -0,0 +1,93 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package modules
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/klmitch/patcher"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewResolver(t *testing.T) {
+	result := NewResolver()
+
+	assert.Equal(t, &Resolver{
+		mods: map[string]Module{},
+	}, result)
+}
+
+func TestResolverImportPathBase(t *testing.T) {
+	calls := 0
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&abs, func(name string) (string, error) {
+			return filepath.Join(filepath.FromSlash("/src/mod"), name), nil
+		}),
+		patcher.SetVar(&find, func(dir string) (string, string, error) {
+			assert.Equal(t, filepath.FromSlash("/src/mod/pkg"), dir)
+			calls++
+			return filepath.FromSlash("/src/mod"), "example.com/mod", nil
+		}),
+	).Install().Restore()
+	obj := NewResolver()
+
+	result1 := obj.ImportPath("pkg/file1.go")
+	result2 := obj.ImportPath("pkg/file2.go")
+
+	assert.Equal(t, "example.com/mod/pkg/file1.go", result1)
+	assert.Equal(t, "example.com/mod/pkg/file2.go", result2)
+	assert.Equal(t, 1, calls)
+}
+
+func TestResolverImportPathNoModule(t *testing.T) {
+	calls := 0
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&abs, func(name string) (string, error) {
+			return filepath.Join(filepath.FromSlash("/src/mod"), name), nil
+		}),
+		patcher.SetVar(&find, func(_ string) (string, string, error) {
+			calls++
+			return "", "", assert.AnError
+		}),
+	).Install().Restore()
+	obj := NewResolver()
+
+	result1 := obj.ImportPath("pkg/file1.go")
+	result2 := obj.ImportPath("pkg/file2.go")
+
+	assert.Equal(t, "pkg/file1.go", result1)
+	assert.Equal(t, "pkg/file2.go", result2)
+	assert.Equal(t, 1, calls)
+}
+
+func TestResolverImportPathAbsFails(t *testing.T) {
+	findCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&abs, func(_ string) (string, error) {
+			return "", assert.AnError
+		}),
+		patcher.SetVar(&find, func(_ string) (string, string, error) {
+			findCalled = true
+			return "", "", nil
+		}),
+	).Install().Restore()
+	obj := NewResolver()
+
+	result := obj.ImportPath("pkg/file1.go")
+
+	assert.Equal(t, "pkg/file1.go", result)
+	assert.False(t, findCalled)
+}
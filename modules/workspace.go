@@ -0,0 +1,130 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package modules
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// Patch points for top-level functions called by functions in this
+// file.
+var getenv = os.Getenv
+
+// Module describes a module.
+type Module struct {
+	Root string // The module's root directory
+	Path string // The module path; empty if there is no module
+}
+
+// findWorkFile locates the go.work file for the workspace containing
+// the specified directory, searching the directory and its parents.
+// As with the go tools, the GOWORK environment variable may instead
+// name the file, or be "off" to disable the workspace.  It returns
+// the name and contents of the file.
+func findWorkFile(dir string) (string, []byte, error) {
+	switch gowork := getenv("GOWORK"); gowork {
+	case "off":
+		return "", nil, ErrNoWorkspace
+
+	case "":
+
+	default:
+		data, err := readFile(gowork)
+		return gowork, data, err
+	}
+
+	dir, err := abs(dir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	for {
+		fname := filepath.Join(dir, "go.work")
+		data, err := readFile(fname)
+		if err == nil {
+			return fname, data, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", nil, err
+		}
+
+		// Try the parent
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil, ErrNoWorkspace
+		}
+		dir = parent
+	}
+}
+
+// FindWorkspace locates the go.work file for the workspace containing
+// the specified directory, as the go tools do, and returns the
+// modules used by the workspace, in the order of the "use"
+// directives.
+func FindWorkspace(dir string) ([]Module, error) {
+	fname, data, err := findWorkFile(dir)
+	if err != nil {
+		return nil, err
+	}
+	wf, err := modfile.ParseWork(fname, data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read the module path of each module
+	mods := make([]Module, 0, len(wf.Use))
+	for _, use := range wf.Use {
+		root := filepath.FromSlash(use.Path)
+		if !filepath.IsAbs(root) {
+			root = filepath.Join(filepath.Dir(fname), root)
+		}
+		data, err := readFile(filepath.Join(root, "go.mod"))
+		if err != nil {
+			return nil, err
+		}
+		modPath := modfile.ModulePath(data)
+		if modPath == "" {
+			return nil, fmt.Errorf("%s: %w", root, ErrNoModulePath)
+		}
+		mods = append(mods, Module{Root: root, Path: modPath})
+	}
+
+	return mods, nil
+}
+
+// Owner returns the module containing the package with the specified
+// import path: the module with the longest module path that is a
+// prefix of the import path.  The boolean result is false if no
+// module contains the package.
+func Owner(mods []Module, pkg string) (Module, bool) {
+	var result Module
+	found := false
+	for _, mod := range mods {
+		if pkg != mod.Path && !strings.HasPrefix(pkg, mod.Path+"/") {
+			continue
+		}
+		if !found || len(mod.Path) > len(result.Path) {
+			result = mod
+			found = true
+		}
+	}
+
+	return result, found
+}
@@ -0,0 +1,258 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package modules
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klmitch/patcher"
+	"github.com/stretchr/testify/assert"
+)
+
+// patchFiles patches readFile to read from a map of files.
+func patchFiles(files map[string]string) patcher.Patcher {
+	return patcher.SetVar(&readFile, func(fname string) ([]byte, error) {
+		if data, ok := files[fname]; ok {
+			return []byte(data), nil
+		}
+		return nil, os.ErrNotExist
+	})
+}
+
+func TestFindWorkFileBase(t *testing.T) {
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&getenv, func(name string) string {
+			assert.Equal(t, "GOWORK", name)
+			return ""
+		}),
+		patchFiles(map[string]string{
+			filepath.FromSlash("/src/go.work"): "go 1.22\n",
+		}),
+	).Install().Restore()
+
+	fname, data, err := findWorkFile(filepath.FromSlash("/src/mod/pkg"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.FromSlash("/src/go.work"), fname)
+	assert.Equal(t, []byte("go 1.22\n"), data)
+}
+
+func TestFindWorkFileEnv(t *testing.T) {
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&getenv, func(_ string) string {
+			return filepath.FromSlash("/other/go.work")
+		}),
+		patchFiles(map[string]string{
+			filepath.FromSlash("/other/go.work"): "go 1.22\n",
+		}),
+	).Install().Restore()
+
+	fname, data, err := findWorkFile(filepath.FromSlash("/src/mod/pkg"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.FromSlash("/other/go.work"), fname)
+	assert.Equal(t, []byte("go 1.22\n"), data)
+}
+
+func TestFindWorkFileOff(t *testing.T) {
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&getenv, func(_ string) string {
+			return "off"
+		}),
+		patchFiles(map[string]string{
+			filepath.FromSlash("/src/go.work"): "go 1.22\n",
+		}),
+	).Install().Restore()
+
+	fname, data, err := findWorkFile(filepath.FromSlash("/src/mod/pkg"))
+
+	assert.Same(t, ErrNoWorkspace, err)
+	assert.Equal(t, "", fname)
+	assert.Nil(t, data)
+}
+
+func TestFindWorkFileMissing(t *testing.T) {
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&getenv, func(_ string) string {
+			return ""
+		}),
+		patchFiles(map[string]string{}),
+	).Install().Restore()
+
+	fname, data, err := findWorkFile(filepath.FromSlash("/src/mod/pkg"))
+
+	assert.Same(t, ErrNoWorkspace, err)
+	assert.Equal(t, "", fname)
+	assert.Nil(t, data)
+}
+
+func TestFindWorkFileReadError(t *testing.T) {
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&getenv, func(_ string) string {
+			return ""
+		}),
+		patcher.SetVar(&readFile, func(_ string) ([]byte, error) {
+			return nil, assert.AnError
+		}),
+	).Install().Restore()
+
+	fname, data, err := findWorkFile(filepath.FromSlash("/src/mod/pkg"))
+
+	assert.Same(t, assert.AnError, err)
+	assert.Equal(t, "", fname)
+	assert.Nil(t, data)
+}
+
+func TestFindWorkFileAbsError(t *testing.T) {
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&getenv, func(_ string) string {
+			return ""
+		}),
+		patcher.SetVar(&abs, func(_ string) (string, error) {
+			return "", assert.AnError
+		}),
+	).Install().Restore()
+
+	fname, data, err := findWorkFile("mod")
+
+	assert.Same(t, assert.AnError, err)
+	assert.Equal(t, "", fname)
+	assert.Nil(t, data)
+}
+
+func TestFindWorkspaceBase(t *testing.T) {
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&getenv, func(_ string) string {
+			return ""
+		}),
+		patchFiles(map[string]string{
+			filepath.FromSlash("/src/go.work"):         "go 1.22\n\nuse (\n\t./mod\n\t./tools\n\t/abs/other\n)\n",
+			filepath.FromSlash("/src/mod/go.mod"):      "module example.com/mod\n",
+			filepath.FromSlash("/src/tools/go.mod"):    "module example.com/tools\n",
+			filepath.FromSlash("/abs/other/go.mod"):    "module example.com/other\n",
+			filepath.FromSlash("/src/unused/go.mod"):   "module example.com/unused\n",
+			filepath.FromSlash("/src/mod/pkg/foo.txt"): "",
+		}),
+	).Install().Restore()
+
+	result, err := FindWorkspace(filepath.FromSlash("/src/mod/pkg"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Module{
+		{Root: filepath.FromSlash("/src/mod"), Path: "example.com/mod"},
+		{Root: filepath.FromSlash("/src/tools"), Path: "example.com/tools"},
+		{Root: filepath.FromSlash("/abs/other"), Path: "example.com/other"},
+	}, result)
+}
+
+func TestFindWorkspaceMissing(t *testing.T) {
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&getenv, func(_ string) string {
+			return "off"
+		}),
+	).Install().Restore()
+
+	result, err := FindWorkspace(filepath.FromSlash("/src/mod/pkg"))
+
+	assert.Same(t, ErrNoWorkspace, err)
+	assert.Nil(t, result)
+}
+
+func TestFindWorkspaceParseError(t *testing.T) {
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&getenv, func(_ string) string {
+			return ""
+		}),
+		patchFiles(map[string]string{
+			filepath.FromSlash("/src/go.work"): "bogus directive\n",
+		}),
+	).Install().Restore()
+
+	result, err := FindWorkspace(filepath.FromSlash("/src/mod/pkg"))
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+}
+
+func TestFindWorkspaceModuleMissing(t *testing.T) {
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&getenv, func(_ string) string {
+			return ""
+		}),
+		patchFiles(map[string]string{
+			filepath.FromSlash("/src/go.work"): "go 1.22\n\nuse ./mod\n",
+		}),
+	).Install().Restore()
+
+	result, err := FindWorkspace(filepath.FromSlash("/src/mod/pkg"))
+
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.Nil(t, result)
+}
+
+func TestFindWorkspaceModulePathMissing(t *testing.T) {
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&getenv, func(_ string) string {
+			return ""
+		}),
+		patchFiles(map[string]string{
+			filepath.FromSlash("/src/go.work"):    "go 1.22\n\nuse ./mod\n",
+			filepath.FromSlash("/src/mod/go.mod"): "go 1.22\n",
+		}),
+	).Install().Restore()
+
+	result, err := FindWorkspace(filepath.FromSlash("/src/mod/pkg"))
+
+	assert.ErrorIs(t, err, ErrNoModulePath)
+	assert.Nil(t, result)
+}
+
+func TestOwnerBase(t *testing.T) {
+	mods := []Module{
+		{Root: "/src", Path: "example.com/mod"},
+		{Root: "/src/sub", Path: "example.com/mod/sub"},
+		{Root: "/src/tools", Path: "example.com/tools"},
+	}
+
+	result, ok := Owner(mods, "example.com/mod/sub/pkg")
+
+	assert.True(t, ok)
+	assert.Equal(t, mods[1], result)
+}
+
+func TestOwnerRoot(t *testing.T) {
+	mods := []Module{
+		{Root: "/src/sub", Path: "example.com/mod/sub"},
+		{Root: "/src", Path: "example.com/mod"},
+	}
+
+	result, ok := Owner(mods, "example.com/mod")
+
+	assert.True(t, ok)
+	assert.Equal(t, mods[1], result)
+}
+
+func TestOwnerNone(t *testing.T) {
+	mods := []Module{
+		{Root: "/src", Path: "example.com/mod"},
+	}
+
+	result, ok := Owner(mods, "example.com/module")
+
+	assert.False(t, ok)
+	assert.Equal(t, Module{}, result)
+}
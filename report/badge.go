@@ -0,0 +1,169 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Badge colors, as named by shields.io.
+const (
+	BadgeGreen  = "brightgreen" // Coverage comfortably above the threshold
+	BadgeYellow = "yellow"      // Coverage close to the threshold
+	BadgeRed    = "red"         // Coverage below the threshold
+)
+
+// badgeLabel is the label displayed on the left side of the badge.
+const badgeLabel = "coverage"
+
+// badgeHex maps the badge colors to the colors used in the SVG.
+var badgeHex = map[string]string{
+	BadgeGreen:  "#4c1",
+	BadgeYellow: "#dfb317",
+	BadgeRed:    "#e05d44",
+}
+
+// charWidths contains the approximate widths, in pixels, of the
+// characters that appear on a badge, as rendered in 11px Verdana.
+var charWidths = map[rune]float64{
+	'0': 7.0, '1': 7.0, '2': 7.0, '3': 7.0, '4': 7.0,
+	'5': 7.0, '6': 7.0, '7': 7.0, '8': 7.0, '9': 7.0,
+	'.': 4.0, '%': 12.0,
+	'a': 6.7, 'c': 5.8, 'e': 6.6, 'g': 6.8, 'o': 6.7, 'r': 4.7, 'v': 6.5,
+}
+
+// badgeTemplate is the SVG of a badge, in the "flat" style of
+// shields.io.  The parameters are the total width, the label width,
+// the value width, the color, the label and value centers, the label,
+// and the value.
+const badgeTemplate = `<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[7]s: %[8]s">
+  <title>%[7]s: %[8]s</title>
+  <linearGradient id="s" x2="0" y2="100%%">
+    <stop offset="0" stop-color="#bbb" stop-opacity=".1"/>
+    <stop offset="1" stop-opacity=".1"/>
+  </linearGradient>
+  <clipPath id="r">
+    <rect width="%[1]d" height="20" rx="3" fill="#fff"/>
+  </clipPath>
+  <g clip-path="url(#r)">
+    <rect width="%[2]d" height="20" fill="#555"/>
+    <rect x="%[2]d" width="%[3]d" height="20" fill="%[4]s"/>
+    <rect width="%[1]d" height="20" fill="url(#s)"/>
+  </g>
+  <g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+    <text x="%.1[5]f" y="15" fill="#010101" fill-opacity=".3">%[7]s</text>
+    <text x="%.1[5]f" y="14">%[7]s</text>
+    <text x="%.1[6]f" y="15" fill="#010101" fill-opacity=".3">%[8]s</text>
+    <text x="%.1[6]f" y="14">%[8]s</text>
+  </g>
+</svg>
+`
+
+// Badge colors used when there is no threshold to compare against.
+const (
+	BadgeRedBelow    = 50.0 // Coverage below this is red
+	BadgeYellowBelow = 80.0 // Coverage below this is yellow
+)
+
+// Badge describes a coverage badge.
+type Badge struct {
+	Coverage float64 // The coverage, as a percentage
+	Color    string  // The color of the badge
+}
+
+// NewBadge constructs a Badge for a coverage, selecting its color
+// based on the threshold: coverage below the threshold is red,
+// coverage less than margin points above the threshold is yellow, and
+// any other coverage is green.  If there is no threshold, a fixed
+// scale is used instead: coverage below BadgeRedBelow is red,
+// coverage below BadgeYellowBelow is yellow, and any other coverage
+// is green.
+func NewBadge(coverage, threshold, margin float64) Badge {
+	if threshold <= 0 {
+		threshold = BadgeRedBelow
+		margin = BadgeYellowBelow - BadgeRedBelow
+	}
+
+	color := BadgeGreen
+	switch {
+	case coverage < threshold:
+		color = BadgeRed
+
+	case coverage < threshold+margin:
+		color = BadgeYellow
+	}
+
+	return Badge{
+		Coverage: coverage,
+		Color:    color,
+	}
+}
+
+// message returns the text displayed on the right side of the badge.
+func (b Badge) message() string {
+	return fmt.Sprintf("%.1f%%", b.Coverage)
+}
+
+// textWidth estimates the width of a section of the badge containing
+// the specified text, including the padding.
+func textWidth(text string) int {
+	width := 10.0
+	for _, c := range text {
+		if w, ok := charWidths[c]; ok {
+			width += w
+		} else {
+			width += 7.0
+		}
+	}
+
+	return int(width + 0.5)
+}
+
+// WriteSVG writes the badge to a stream as an SVG image.
+func (b Badge) WriteSVG(w io.Writer) error {
+	msg := b.message()
+	labelWidth := textWidth(badgeLabel)
+	msgWidth := textWidth(msg)
+	_, err := fmt.Fprintf(w, badgeTemplate,
+		labelWidth+msgWidth, labelWidth, msgWidth, badgeHex[b.Color],
+		float64(labelWidth)/2.0, float64(labelWidth)+float64(msgWidth)/2.0,
+		badgeLabel, msg,
+	)
+	return err
+}
+
+// badgeEndpoint is the JSON document consumed by the shields.io
+// "endpoint" badge.
+type badgeEndpoint struct {
+	SchemaVersion int    `json:"schemaVersion"` // Always 1
+	Label         string `json:"label"`         // The badge label
+	Message       string `json:"message"`       // The badge message
+	Color         string `json:"color"`         // The badge color
+}
+
+// WriteEndpoint writes the badge to a stream as a JSON document
+// suitable for the shields.io "endpoint" badge.
+func (b Badge) WriteEndpoint(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(badgeEndpoint{
+		SchemaVersion: 1,
+		Label:         badgeLabel,
+		Message:       b.message(),
+		Color:         b.Color,
+	})
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBadgeGreen(t *testing.T) {
+	result := NewBadge(90.0, 80.0, 5.0)
+
+	assert.Equal(t, Badge{Coverage: 90.0, Color: BadgeGreen}, result)
+}
+
+func TestNewBadgeYellow(t *testing.T) {
+	result := NewBadge(82.0, 80.0, 5.0)
+
+	assert.Equal(t, Badge{Coverage: 82.0, Color: BadgeYellow}, result)
+}
+
+func TestNewBadgeRed(t *testing.T) {
+	result := NewBadge(75.0, 80.0, 5.0)
+
+	assert.Equal(t, Badge{Coverage: 75.0, Color: BadgeRed}, result)
+}
+
+func TestNewBadgeNoThresholdGreen(t *testing.T) {
+	result := NewBadge(80.0, 0.0, 5.0)
+
+	assert.Equal(t, Badge{Coverage: 80.0, Color: BadgeGreen}, result)
+}
+
+func TestNewBadgeNoThresholdYellow(t *testing.T) {
+	result := NewBadge(79.9, 0.0, 5.0)
+
+	assert.Equal(t, Badge{Coverage: 79.9, Color: BadgeYellow}, result)
+}
+
+func TestNewBadgeNoThresholdYellowLow(t *testing.T) {
+	result := NewBadge(50.0, 0.0, 5.0)
+
+	assert.Equal(t, Badge{Coverage: 50.0, Color: BadgeYellow}, result)
+}
+
+func TestNewBadgeNoThresholdRed(t *testing.T) {
+	result := NewBadge(10.0, 0.0, 5.0)
+
+	assert.Equal(t, Badge{Coverage: 10.0, Color: BadgeRed}, result)
+}
+
+func TestBadgeMessage(t *testing.T) {
+	obj := Badge{Coverage: 85.25}
+
+	result := obj.message()
+
+	assert.Equal(t, "85.2%", result)
+}
+
+func TestTextWidthKnown(t *testing.T) {
+	result := textWidth("1.0%")
+
+	assert.Equal(t, 40, result)
+}
+
+func TestTextWidthUnknown(t *testing.T) {
+	result := textWidth("xy")
+
+	assert.Equal(t, 24, result)
+}
+
+func TestBadgeWriteSVGBase(t *testing.T) {
+	obj := Badge{Coverage: 85.25, Color: BadgeGreen}
+	buf := &bytes.Buffer{}
+
+	err := obj.WriteSVG(buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `<svg xmlns="http://www.w3.org/2000/svg" width="107" height="20" role="img" aria-label="coverage: 85.2%">
+  <title>coverage: 85.2%</title>
+  <linearGradient id="s" x2="0" y2="100%">
+    <stop offset="0" stop-color="#bbb" stop-opacity=".1"/>
+    <stop offset="1" stop-opacity=".1"/>
+  </linearGradient>
+  <clipPath id="r">
+    <rect width="107" height="20" rx="3" fill="#fff"/>
+  </clipPath>
+  <g clip-path="url(#r)">
+    <rect width="60" height="20" fill="#555"/>
+    <rect x="60" width="47" height="20" fill="#4c1"/>
+    <rect width="107" height="20" fill="url(#s)"/>
+  </g>
+  <g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+    <text x="30.0" y="15" fill="#010101" fill-opacity=".3">coverage</text>
+    <text x="30.0" y="14">coverage</text>
+    <text x="83.5" y="15" fill="#010101" fill-opacity=".3">85.2%</text>
+    <text x="83.5" y="14">85.2%</text>
+  </g>
+</svg>
+`, buf.String())
+}
+
+func TestBadgeWriteSVGError(t *testing.T) {
+	obj := Badge{Coverage: 85.25, Color: BadgeGreen}
+
+	err := obj.WriteSVG(&failWriter{})
+
+	assert.Error(t, err)
+}
+
+func TestBadgeWriteEndpointBase(t *testing.T) {
+	obj := Badge{Coverage: 85.25, Color: BadgeYellow}
+	buf := &bytes.Buffer{}
+
+	err := obj.WriteEndpoint(buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{
+  "schemaVersion": 1,
+  "label": "coverage",
+  "message": "85.2%",
+  "color": "yellow"
+}
+`, buf.String())
+}
+
+func TestBadgeWriteEndpointError(t *testing.T) {
+	obj := Badge{Coverage: 85.25, Color: BadgeYellow}
+
+	err := obj.WriteEndpoint(&failWriter{})
+
+	assert.Error(t, err)
+}
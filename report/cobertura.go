@@ -0,0 +1,173 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package report
+
+import (
+	"encoding/xml"
+	"io"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/modules"
+)
+
+// Patch points for top-level functions called by functions in this
+// file.
+var (
+	now = time.Now
+)
+
+// coberturaDoctype is the document type declaration for Cobertura XML
+// reports.
+const coberturaDoctype = `<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">`
+
+// coberturaLine describes the execution count of a single line.
+type coberturaLine struct {
+	Number int `xml:"number,attr"`
+	Hits   int `xml:"hits,attr"`
+}
+
+// coberturaLines is the list of lines in a class.
+type coberturaLines struct {
+	Lines []coberturaLine `xml:"line"`
+}
+
+// coberturaMethods is the list of methods in a class.  Overcover
+// does not report methods, so it is always empty.
+type coberturaMethods struct{}
+
+// coberturaClass describes the coverage of a single file.
+type coberturaClass struct {
+	Name       string           `xml:"name,attr"`
+	Filename   string           `xml:"filename,attr"`
+	LineRate   float64          `xml:"line-rate,attr"`
+	BranchRate float64          `xml:"branch-rate,attr"`
+	Complexity float64          `xml:"complexity,attr"`
+	Methods    coberturaMethods `xml:"methods"`
+	Lines      coberturaLines   `xml:"lines"`
+}
+
+// coberturaPackage describes the coverage of a package.
+type coberturaPackage struct {
+	Name       string           `xml:"name,attr"`
+	LineRate   float64          `xml:"line-rate,attr"`
+	BranchRate float64          `xml:"branch-rate,attr"`
+	Complexity float64          `xml:"complexity,attr"`
+	Classes    []coberturaClass `xml:"classes>class"`
+}
+
+// coberturaPackages is the list of packages in a report.
+type coberturaPackages struct {
+	Packages []coberturaPackage `xml:"package"`
+}
+
+// cobertura is the root element of a Cobertura XML report.
+type cobertura struct {
+	XMLName         xml.Name          `xml:"coverage"`
+	LineRate        float64           `xml:"line-rate,attr"`
+	BranchRate      float64           `xml:"branch-rate,attr"`
+	LinesCovered    int64             `xml:"lines-covered,attr"`
+	LinesValid      int64             `xml:"lines-valid,attr"`
+	BranchesCovered int64             `xml:"branches-covered,attr"`
+	BranchesValid   int64             `xml:"branches-valid,attr"`
+	Complexity      float64           `xml:"complexity,attr"`
+	Version         string            `xml:"version,attr"`
+	Timestamp       int64             `xml:"timestamp,attr"`
+	Sources         []string          `xml:"sources>source"`
+	Packages        coberturaPackages `xml:"packages"`
+}
+
+// coberturaLinesFor computes the line execution counts for a file
+// from its blocks.
+func coberturaLinesFor(blocks []common.Block) coberturaLines {
+	result := coberturaLines{Lines: []coberturaLine{}}
+	for _, lc := range lineCounts(blocks) {
+		result.Lines = append(result.Lines, coberturaLine{
+			Number: lc.Line,
+			Hits:   lc.Hits,
+		})
+	}
+
+	return result
+}
+
+// WriteCobertura writes the coverage data in a data set to a stream
+// as a Cobertura XML report.  The coverage rates and totals are
+// computed from the statement counts, so they agree with the
+// coverage Overcover enforces, including files for which only the
+// statement count is known; the per-line execution counts are derived
+// from the blocks of statements in the coverage profiles.  Files
+// within one of the modules are named relative to the root of the
+// module containing them; the module roots are reported as the source
+// directories.
+func WriteCobertura(w io.Writer, ds common.DataSet, mods []modules.Module) error {
+	overall := ds.Sum()
+	doc := cobertura{
+		LineRate:     overall.Coverage(),
+		LinesCovered: overall.Exec,
+		LinesValid:   overall.Count,
+		Timestamp:    now().UnixMilli(),
+		Sources:      []string{},
+	}
+	for _, mod := range mods {
+		if mod.Root != "" {
+			doc.Sources = append(doc.Sources, mod.Root)
+		}
+	}
+
+	// Construct the packages
+	sorted := append(common.DataSet{}, ds...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Package != sorted[j].Package {
+			return sorted[i].Package < sorted[j].Package
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	pkgs := map[string]common.FileData{}
+	for _, fd := range ds.Reduce() {
+		pkgs[fd.Package] = fd
+	}
+	var pkg *coberturaPackage
+	for _, fd := range sorted {
+		if pkg == nil || pkg.Name != fd.Package {
+			doc.Packages.Packages = append(doc.Packages.Packages, coberturaPackage{
+				Name:     fd.Package,
+				LineRate: pkgs[fd.Package].Coverage(),
+			})
+			pkg = &doc.Packages.Packages[len(doc.Packages.Packages)-1]
+		}
+		mod, _ := modules.Owner(mods, fd.Package)
+		pkg.Classes = append(pkg.Classes, coberturaClass{
+			Name:     fd.Name,
+			Filename: path.Join(relPath(fd.Package, mod.Path), fd.Name),
+			LineRate: fd.Coverage(),
+			Lines:    coberturaLinesFor(fd.Blocks),
+		})
+	}
+
+	// Write the report
+	if _, err := io.WriteString(w, xml.Header+coberturaDoctype+"\n"); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/klmitch/patcher"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/modules"
+)
+
+type failWriter struct {
+	limit int // Number of bytes to accept before failing
+}
+
+func (fw *failWriter) Write(p []byte) (int, error) {
+	if len(p) > fw.limit {
+		n := fw.limit
+		fw.limit = 0
+		return n, assert.AnError
+	}
+
+	fw.limit -= len(p)
+	return len(p), nil
+}
+
+var testCoberturaData = common.DataSet{
+	{
+		Package: "example.com/mod/pkg",
+		Name:    "file.go",
+		Count:   3,
+		Exec:    2,
+		Blocks: []common.Block{
+			{StartLine: 3, StartCol: 20, EndLine: 5, EndCol: 3, NumStmt: 2, Count: 1},
+			{StartLine: 5, StartCol: 3, EndLine: 6, EndCol: 1, NumStmt: 1, Count: 0},
+		},
+	},
+	{
+		Package: "example.com/mod",
+		Name:    "main.go",
+		Count:   2,
+	},
+	{
+		Package: "example.com/mod/pkg",
+		Name:    "alpha.go",
+		Count:   1,
+		Exec:    1,
+	},
+	{
+		Package: "example.com/other",
+		Name:    "other.go",
+		Count:   1,
+		Exec:    1,
+	},
+}
+
+func TestCoberturaLinesFor(t *testing.T) {
+	result := coberturaLinesFor(testCoberturaData[0].Blocks)
+
+	assert.Equal(t, coberturaLines{
+		Lines: []coberturaLine{
+			{Number: 3, Hits: 1},
+			{Number: 4, Hits: 1},
+			{Number: 5, Hits: 1},
+		},
+	}, result)
+}
+
+func TestCoberturaLinesForEmpty(t *testing.T) {
+	result := coberturaLinesFor(nil)
+
+	assert.Equal(t, coberturaLines{Lines: []coberturaLine{}}, result)
+}
+
+func TestWriteCoberturaBase(t *testing.T) {
+	defer patcher.SetVar(&now, func() time.Time {
+		return time.UnixMilli(1600000000123)
+	}).Install().Restore()
+	buf := &bytes.Buffer{}
+
+	err := WriteCobertura(buf, testCoberturaData, []modules.Module{{Root: "/src/mod", Path: "example.com/mod"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">
+<coverage line-rate="0.5714285714285714" branch-rate="0" lines-covered="4" lines-valid="7" branches-covered="0" branches-valid="0" complexity="0" version="" timestamp="1600000000123">
+  <sources>
+    <source>/src/mod</source>
+  </sources>
+  <packages>
+    <package name="example.com/mod" line-rate="0" branch-rate="0" complexity="0">
+      <classes>
+        <class name="main.go" filename="main.go" line-rate="0" branch-rate="0" complexity="0">
+          <methods></methods>
+          <lines></lines>
+        </class>
+      </classes>
+    </package>
+    <package name="example.com/mod/pkg" line-rate="0.75" branch-rate="0" complexity="0">
+      <classes>
+        <class name="alpha.go" filename="pkg/alpha.go" line-rate="1" branch-rate="0" complexity="0">
+          <methods></methods>
+          <lines></lines>
+        </class>
+        <class name="file.go" filename="pkg/file.go" line-rate="0.6666666666666666" branch-rate="0" complexity="0">
+          <methods></methods>
+          <lines>
+            <line number="3" hits="1"></line>
+            <line number="4" hits="1"></line>
+            <line number="5" hits="1"></line>
+          </lines>
+        </class>
+      </classes>
+    </package>
+    <package name="example.com/other" line-rate="1" branch-rate="0" complexity="0">
+      <classes>
+        <class name="other.go" filename="example.com/other/other.go" line-rate="1" branch-rate="0" complexity="0">
+          <methods></methods>
+          <lines></lines>
+        </class>
+      </classes>
+    </package>
+  </packages>
+</coverage>
+`, buf.String())
+}
+
+func TestWriteCoberturaWorkspace(t *testing.T) {
+	defer patcher.SetVar(&now, func() time.Time {
+		return time.UnixMilli(1600000000123)
+	}).Install().Restore()
+	buf := &bytes.Buffer{}
+
+	err := WriteCobertura(buf, testCoberturaData, []modules.Module{
+		{Root: "/src/mod", Path: "example.com/mod"},
+		{Root: "/src/other", Path: "example.com/other"},
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `
+  <sources>
+    <source>/src/mod</source>
+    <source>/src/other</source>
+  </sources>
+`)
+	assert.Contains(t, buf.String(), `<class name="file.go" filename="pkg/file.go"`)
+	assert.Contains(t, buf.String(), `<class name="other.go" filename="other.go"`)
+}
+
+func TestWriteCoberturaEmpty(t *testing.T) {
+	defer patcher.SetVar(&now, func() time.Time {
+		return time.UnixMilli(1600000000123)
+	}).Install().Restore()
+	buf := &bytes.Buffer{}
+
+	err := WriteCobertura(buf, nil, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">
+<coverage line-rate="1" branch-rate="0" lines-covered="0" lines-valid="0" branches-covered="0" branches-valid="0" complexity="0" version="" timestamp="1600000000123">
+  <sources></sources>
+  <packages></packages>
+</coverage>
+`, buf.String())
+}
+
+func TestWriteCoberturaHeaderFails(t *testing.T) {
+	err := WriteCobertura(&failWriter{}, testCoberturaData, []modules.Module{{Root: "/src/mod", Path: "example.com/mod"}})
+
+	assert.Same(t, assert.AnError, err)
+}
+
+func TestWriteCoberturaEncodeFails(t *testing.T) {
+	err := WriteCobertura(&failWriter{limit: 200}, testCoberturaData, []modules.Module{{Root: "/src/mod", Path: "example.com/mod"}})
+
+	assert.Same(t, assert.AnError, err)
+}
+
+func TestWriteCoberturaNewlineFails(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, WriteCobertura(buf, testCoberturaData, []modules.Module{{Root: "/src/mod", Path: "example.com/mod"}}))
+
+	err := WriteCobertura(&failWriter{limit: buf.Len() - 1}, testCoberturaData, []modules.Module{{Root: "/src/mod", Path: "example.com/mod"}})
+
+	assert.Same(t, assert.AnError, err)
+}
@@ -0,0 +1,257 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/modules"
+)
+
+// Patch points for top-level functions called by functions in this
+// file.
+var (
+	readFile = os.ReadFile
+)
+
+// CSS classes used to highlight source lines in the HTML report.
+const (
+	lineCovered   = "cov"     // All statements on the line executed
+	lineUncovered = "uncov"   // No statements on the line executed
+	linePartial   = "partial" // Some statements on the line executed
+)
+
+// htmlLine describes a single line of source in the HTML report.
+type htmlLine struct {
+	Number int    // The line number
+	Text   string // The text of the line
+	Class  string // The CSS class for highlighting the line
+}
+
+// htmlFile describes a single file in the HTML report.
+type htmlFile struct {
+	Stats    Coverage   // Statement counts for the file
+	ID       string     // Anchor for the file's page
+	Path     string     // Source file name
+	Untested bool       // Whether the file has no coverage data
+	Lines    []htmlLine // Source lines; nil if unavailable
+}
+
+// htmlPackage describes a single package in the HTML report.
+type htmlPackage struct {
+	Stats    Coverage   // Statement counts for the package
+	ID       string     // Anchor for the package's page
+	Untested bool       // Whether no file in the package has coverage data
+	Files    []htmlFile // Files in the package
+}
+
+// htmlPage describes the HTML report.
+type htmlPage struct {
+	Overall   Coverage      // Overall coverage
+	Threshold Threshold     // Result of checking the overall threshold
+	Packages  []htmlPackage // Per-package coverage
+}
+
+// htmlTemplate is the template for the HTML report.
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Coverage Report</title>
+<style>
+body { font-family: sans-serif; margin: 1em 2em; }
+table { border-collapse: collapse; }
+th, td { padding: 0.2em 0.8em; text-align: left; }
+th { cursor: pointer; border-bottom: 1px solid #888; }
+td.num { text-align: right; }
+.page { display: none; }
+.page:target { display: block; }
+.passed { color: #060; }
+.failed { color: #a00; }
+.untested { color: #a00; font-weight: bold; }
+pre { margin: 0; }
+.src td { padding: 0 0.5em; font-family: monospace; white-space: pre; }
+.src td.num { color: #888; }
+.cov { background: #cfc; }
+.uncov { background: #fcc; }
+.partial { background: #ffc; }
+</style>
+<script>
+function sortTable(th) {
+  var table = th.closest("table");
+  var body = table.tBodies[0];
+  var col = th.cellIndex;
+  var asc = th.dataset.order !== "asc";
+  th.dataset.order = asc ? "asc" : "desc";
+  var rows = Array.prototype.slice.call(body.rows);
+  rows.sort(function (a, b) {
+    var x = a.cells[col].dataset.value, y = b.cells[col].dataset.value;
+    var cmp = isNaN(x) || isNaN(y) ? x.localeCompare(y) : x - y;
+    return asc ? cmp : -cmp;
+  });
+  rows.forEach(function (row) { body.appendChild(row); });
+}
+</script>
+</head>
+<body>
+<h1>Coverage Report</h1>
+<p>{{.Overall.Executed}} statements out of {{.Overall.Statements}} covered; overall coverage: {{printf "%.1f" .Overall.Coverage}}%</p>
+{{- if gt .Threshold.Threshold 0.0}}
+<p class="{{if .Threshold.Passed}}passed{{else}}failed{{end}}">
+{{- if .Threshold.Passed}}Coverage threshold of {{printf "%.1f" .Threshold.Threshold}}% met{{else}}Failed to meet coverage threshold of {{printf "%.1f" .Threshold.Threshold}}%{{end}}</p>
+{{- end}}
+<h2>Packages</h2>
+<table>
+<thead><tr><th onclick="sortTable(this)">Package</th><th onclick="sortTable(this)">Executed</th><th onclick="sortTable(this)">Total</th><th onclick="sortTable(this)">Coverage</th></tr></thead>
+<tbody>
+{{- range .Packages}}
+<tr><td data-value="{{.Stats.Package}}"><a href="#{{.ID}}">{{.Stats.Package}}</a>{{if .Untested}} <span class="untested">(no coverage data)</span>{{end}}</td><td class="num" data-value="{{.Stats.Executed}}">{{.Stats.Executed}}</td><td class="num" data-value="{{.Stats.Statements}}">{{.Stats.Statements}}</td><td class="num" data-value="{{.Stats.Coverage}}">{{printf "%.1f" .Stats.Coverage}}%</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- range .Packages}}
+<div class="page" id="{{.ID}}">
+<h2>Package {{.Stats.Package}}</h2>
+<table>
+<thead><tr><th onclick="sortTable(this)">File</th><th onclick="sortTable(this)">Executed</th><th onclick="sortTable(this)">Total</th><th onclick="sortTable(this)">Coverage</th></tr></thead>
+<tbody>
+{{- range .Files}}
+<tr><td data-value="{{.Stats.File}}"><a href="#{{.ID}}">{{.Stats.File}}</a>{{if .Untested}} <span class="untested">(no coverage data)</span>{{end}}</td><td class="num" data-value="{{.Stats.Executed}}">{{.Stats.Executed}}</td><td class="num" data-value="{{.Stats.Statements}}">{{.Stats.Statements}}</td><td class="num" data-value="{{.Stats.Coverage}}">{{printf "%.1f" .Stats.Coverage}}%</td></tr>
+{{- end}}
+</tbody>
+</table>
+</div>
+{{- end}}
+{{- range .Packages}}
+{{- range .Files}}
+<div class="page" id="{{.ID}}">
+<h2>File {{.Path}}</h2>
+<p>{{.Stats.Executed}} statements out of {{.Stats.Statements}} covered; coverage: {{printf "%.1f" .Stats.Coverage}}%</p>
+{{- if .Untested}}
+<p class="untested">This file has no coverage data; none of its statements were executed.</p>
+{{- end}}
+{{- if .Lines}}
+<table class="src">
+{{- range .Lines}}
+<tr{{if .Class}} class="{{.Class}}"{{end}}><td class="num">{{.Number}}</td><td>{{.Text}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>Source unavailable.</p>
+{{- end}}
+</div>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+// lineClasses computes the CSS classes for highlighting the lines of
+// a file from its blocks.
+func lineClasses(blocks []common.Block) map[int]string {
+	result := map[int]string{}
+	for _, b := range blocks {
+		class := lineUncovered
+		if b.Count > 0 {
+			class = lineCovered
+		}
+
+		first, last := blockLines(b)
+		for line := first; line <= last; line++ {
+			if prev, ok := result[line]; ok && prev != class {
+				result[line] = linePartial
+			} else {
+				result[line] = class
+			}
+		}
+	}
+
+	return result
+}
+
+// htmlLines reads the source of a file and constructs the highlighted
+// lines for the HTML report.  It returns nil if the source cannot be
+// read.
+func htmlLines(fname string, blocks []common.Block) []htmlLine {
+	data, err := readFile(fname)
+	if err != nil {
+		return nil
+	}
+
+	classes := lineClasses(blocks)
+	text := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	result := make([]htmlLine, 0, len(text))
+	for i, line := range text {
+		result = append(result, htmlLine{
+			Number: i + 1,
+			Text:   line,
+			Class:  classes[i+1],
+		})
+	}
+
+	return result
+}
+
+// WriteHTML writes the coverage data in a data set to a stream as a
+// self-contained HTML report, containing a table of the packages,
+// with a page for each package and a page for each file showing its
+// source with the executed and unexecuted lines highlighted.  Files
+// with no coverage data, such as those in packages without tests, are
+// marked.  The source files are located using the roots and paths of
+// the modules.
+func WriteHTML(w io.Writer, ds common.DataSet, threshold Threshold, mods []modules.Module) error {
+	page := htmlPage{
+		Overall:   NewCoverage(ds.Sum()),
+		Threshold: threshold,
+	}
+
+	// Construct the packages
+	pkgIdx := map[string]int{}
+	for i, pkg := range NewCoverageList(ds.Reduce()) {
+		pkgIdx[pkg.Package] = i
+		page.Packages = append(page.Packages, htmlPackage{
+			Stats:    pkg,
+			ID:       fmt.Sprintf("pkg-%d", i),
+			Untested: true,
+		})
+	}
+
+	// Add the files to them
+	sorted := append(common.DataSet{}, ds...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Handle() < sorted[j].Handle()
+	})
+	for i, fd := range sorted {
+		fname := sourceFileName(fd, mods)
+		file := htmlFile{
+			Stats:    NewCoverage(fd),
+			ID:       fmt.Sprintf("file-%d", i),
+			Path:     fname,
+			Untested: fd.Synthetic && fd.Count > 0,
+			Lines:    htmlLines(fname, fd.Blocks),
+		}
+		pkg := &page.Packages[pkgIdx[fd.Package]]
+		pkg.Untested = pkg.Untested && file.Untested
+		pkg.Files = append(pkg.Files, file)
+	}
+
+	return htmlTemplate.Execute(w, page)
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klmitch/patcher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/modules"
+)
+
+var testHTMLData = common.DataSet{
+	{
+		Package: "example.com/mod/pkg",
+		Name:    "file.go",
+		Count:   3,
+		Exec:    2,
+		Blocks: []common.Block{
+			{StartLine: 3, StartCol: 20, EndLine: 5, EndCol: 3, NumStmt: 2, Count: 1},
+			{StartLine: 5, StartCol: 3, EndLine: 6, EndCol: 1, NumStmt: 1, Count: 0},
+			{StartLine: 7, StartCol: 2, EndLine: 7, EndCol: 10, NumStmt: 1, Count: 0},
+		},
+	},
+	{
+		Package: "example.com/mod/pkg",
+		Name:    "gone.go",
+		Count:   1,
+		Exec:    1,
+		Blocks: []common.Block{
+			{StartLine: 1, StartCol: 1, EndLine: 1, EndCol: 5, NumStmt: 1, Count: 1},
+		},
+	},
+	{
+		Package: "example.com/mod/untested",
+		Name:    "untested.go",
+		Count:   1,
+		Blocks: []common.Block{
+			{StartLine: 3, StartCol: 12, EndLine: 3, EndCol: 17, NumStmt: 1},
+		},
+		Synthetic: true,
+	},
+}
+
+func TestLineClassesBase(t *testing.T) {
+	result := lineClasses(testHTMLData[0].Blocks)
+
+	assert.Equal(t, map[int]string{
+		3: lineCovered,
+		4: lineCovered,
+		5: linePartial,
+		7: lineUncovered,
+	}, result)
+}
+
+func TestLineClassesEmpty(t *testing.T) {
+	result := lineClasses(nil)
+
+	assert.Equal(t, map[int]string{}, result)
+}
+
+func TestHTMLLinesBase(t *testing.T) {
+	defer patcher.SetVar(&readFile, func(fname string) ([]byte, error) {
+		assert.Equal(t, "file.go", fname)
+		return []byte("package pkg\n\nfunc F() {\n\tx()\n}\n"), nil
+	}).Install().Restore()
+
+	result := htmlLines("file.go", []common.Block{
+		{StartLine: 3, StartCol: 12, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 1},
+	})
+
+	assert.Equal(t, []htmlLine{
+		{Number: 1, Text: "package pkg"},
+		{Number: 2, Text: ""},
+		{Number: 3, Text: "func F() {", Class: lineCovered},
+		{Number: 4, Text: "\tx()", Class: lineCovered},
+		{Number: 5, Text: "}", Class: lineCovered},
+	}, result)
+}
+
+func TestHTMLLinesReadFails(t *testing.T) {
+	defer patcher.SetVar(&readFile, func(_ string) ([]byte, error) {
+		return nil, assert.AnError
+	}).Install().Restore()
+
+	result := htmlLines("file.go", nil)
+
+	assert.Nil(t, result)
+}
+
+func TestWriteHTML(t *testing.T) {
+	expected, err := os.ReadFile(filepath.Join("testdata", "report.html"))
+	require.NoError(t, err)
+	defer patcher.SetVar(&readFile, func(fname string) ([]byte, error) {
+		switch fname {
+		case filepath.FromSlash("/src/mod/pkg/file.go"):
+			return []byte("package pkg\n\nfunc F(x int) int {\n\tif x > 0 {\n\t\treturn 1\n\t}\n\treturn 0\n}\n"), nil
+		case filepath.FromSlash("/src/mod/untested/untested.go"):
+			return []byte("package untested\n\nfunc U() { u() }\n"), nil
+		}
+		return nil, os.ErrNotExist
+	}).Install().Restore()
+	buf := &bytes.Buffer{}
+
+	err = WriteHTML(buf, testHTMLData, Threshold{Threshold: 80.0}, []modules.Module{{Root: filepath.FromSlash("/src/mod"), Path: "example.com/mod"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), buf.String())
+}
+
+func TestWriteHTMLThresholdMet(t *testing.T) {
+	defer patcher.SetVar(&readFile, func(_ string) ([]byte, error) {
+		return nil, os.ErrNotExist
+	}).Install().Restore()
+	buf := &bytes.Buffer{}
+
+	err := WriteHTML(buf, testHTMLData[:1], Threshold{Threshold: 60.0, Passed: true}, nil)
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `<p class="passed">Coverage threshold of 60.0% met</p>`)
+}
+
+func TestWriteHTMLNoThreshold(t *testing.T) {
+	defer patcher.SetVar(&readFile, func(_ string) ([]byte, error) {
+		return nil, os.ErrNotExist
+	}).Install().Restore()
+	buf := &bytes.Buffer{}
+
+	err := WriteHTML(buf, testHTMLData[:1], Threshold{Passed: true}, nil)
+
+	assert.NoError(t, err)
+	assert.NotContains(t, buf.String(), "threshold")
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// junitFailure describes a failed threshold check.
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// junitSkipped describes a threshold check that could not be made.
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// junitTestCase describes a single threshold check.
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+// junitTestSuite describes the threshold checks.
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// junitTestSuites is the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// add adds a test case to the test suite.
+func (s *junitTestSuite) add(tc junitTestCase) {
+	tc.Time = "0"
+	s.Tests++
+	if tc.Failure != nil {
+		s.Failures++
+	}
+	if tc.Skipped != nil {
+		s.Skipped++
+	}
+	s.TestCases = append(s.TestCases, tc)
+}
+
+// junitCheck constructs a test case for a threshold check.
+func junitCheck(className, name string, cov Coverage, thr Threshold) junitTestCase {
+	tc := junitTestCase{
+		ClassName: className,
+		Name:      name,
+	}
+	if !thr.Passed {
+		msg := fmt.Sprintf("coverage %.1f%% is below the required %.1f%%", cov.Coverage, thr.Threshold)
+		tc.Failure = &junitFailure{
+			Message: msg,
+			Type:    "threshold",
+			Text:    fmt.Sprintf("%s: %d statements out of %d covered", msg, cov.Executed, cov.Statements),
+		}
+	}
+
+	return tc
+}
+
+// WriteJUnit writes the results of the threshold checks in the report
+// to a stream as a JUnit XML report, so that they may be displayed
+// alongside the results of the tests.  Each check--the overall
+// threshold, the patch threshold, the threshold of each rule for each
+// package (or, for an exported rule, each exported function) it
+// matches, and the threshold of each module of a workspace--is a test
+// case, and a failed check is reported as a failed test case.
+// Thresholds that are not set are omitted, and rules that match no
+// packages are reported as skipped.
+func (r *Report) WriteJUnit(w io.Writer) error {
+	suite := junitTestSuite{
+		Name:      "overcover",
+		Time:      "0",
+		Timestamp: now().UTC().Format("2006-01-02T15:04:05"),
+		TestCases: []junitTestCase{},
+	}
+
+	// Begin with the overall and patch thresholds
+	if r.Threshold.Threshold > 0.0 {
+		suite.add(junitCheck("overcover", "threshold", r.Overall, r.Threshold))
+	}
+	if r.Patch != nil && r.Patch.Threshold.Threshold > 0.0 {
+		suite.add(junitCheck("overcover", fmt.Sprintf("patch threshold (%s)", r.Patch.Base), r.Patch.Coverage, r.Patch.Threshold))
+	}
+
+	// Add the rules
+	for _, rule := range r.Rules {
+		className := fmt.Sprintf("overcover.rule(%s)", rule.Pattern)
+		if len(rule.Packages) == 0 {
+			suite.add(junitTestCase{
+				ClassName: className,
+				Name:      rule.Pattern,
+				Skipped: &junitSkipped{
+					Message: "rule matches no packages",
+				},
+			})
+			continue
+		}
+
+		if rule.Exported() {
+			for _, fn := range rule.Functions {
+				tc := junitCheck(className, fn.Package+"."+fn.Function.Function, fn.Coverage, Threshold{Threshold: rule.Threshold, Passed: fn.Passed})
+				if tc.Failure != nil && fn.Executed == 0 {
+					tc.Failure.Message = "no statements executed"
+					tc.Failure.Text = fmt.Sprintf("no statements executed: 0 statements out of %d covered", fn.Statements)
+				}
+				suite.add(tc)
+			}
+			continue
+		}
+		for _, pkg := range rule.Packages {
+			suite.add(junitCheck(className, pkg.Package, pkg, NewThreshold(pkg.Coverage, rule.Threshold)))
+		}
+	}
+
+	// Add the modules
+	for _, mod := range r.Modules {
+		if mod.Threshold.Threshold > 0.0 {
+			suite.add(junitCheck("overcover.module", mod.Module, mod.Coverage, mod.Threshold))
+		}
+	}
+
+	// Write the report
+	doc := junitTestSuites{
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Suites:   []junitTestSuite{suite},
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
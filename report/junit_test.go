@@ -0,0 +1,258 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/klmitch/patcher"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/klmitch/overcover/rules"
+)
+
+func TestJUnitTestSuiteAddPassed(t *testing.T) {
+	obj := &junitTestSuite{}
+
+	obj.add(junitTestCase{Name: "test"})
+
+	assert.Equal(t, &junitTestSuite{
+		Tests:     1,
+		TestCases: []junitTestCase{{Name: "test", Time: "0"}},
+	}, obj)
+}
+
+func TestJUnitTestSuiteAddFailed(t *testing.T) {
+	obj := &junitTestSuite{}
+
+	obj.add(junitTestCase{Name: "test", Failure: &junitFailure{}})
+
+	assert.Equal(t, &junitTestSuite{
+		Tests:     1,
+		Failures:  1,
+		TestCases: []junitTestCase{{Name: "test", Time: "0", Failure: &junitFailure{}}},
+	}, obj)
+}
+
+func TestJUnitTestSuiteAddSkipped(t *testing.T) {
+	obj := &junitTestSuite{}
+
+	obj.add(junitTestCase{Name: "test", Skipped: &junitSkipped{}})
+
+	assert.Equal(t, &junitTestSuite{
+		Tests:     1,
+		Skipped:   1,
+		TestCases: []junitTestCase{{Name: "test", Time: "0", Skipped: &junitSkipped{}}},
+	}, obj)
+}
+
+func TestJUnitCheckPassed(t *testing.T) {
+	result := junitCheck("class", "name", Coverage{Statements: 4, Executed: 3, Coverage: 75.0}, Threshold{Threshold: 70.0, Passed: true})
+
+	assert.Equal(t, junitTestCase{
+		ClassName: "class",
+		Name:      "name",
+	}, result)
+}
+
+func TestJUnitCheckFailed(t *testing.T) {
+	result := junitCheck("class", "name", Coverage{Statements: 4, Executed: 3, Coverage: 75.0}, Threshold{Threshold: 80.0})
+
+	assert.Equal(t, junitTestCase{
+		ClassName: "class",
+		Name:      "name",
+		Failure: &junitFailure{
+			Message: "coverage 75.0% is below the required 80.0%",
+			Type:    "threshold",
+			Text:    "coverage 75.0% is below the required 80.0%: 3 statements out of 4 covered",
+		},
+	}, result)
+}
+
+func TestReportWriteJUnitBase(t *testing.T) {
+	defer patcher.SetVar(&now, func() time.Time {
+		return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	}).Install().Restore()
+	obj := &Report{
+		Overall:   Coverage{Statements: 20, Executed: 9, Coverage: 45.0},
+		Threshold: Threshold{Threshold: 40.0, Passed: true},
+		Patch: &Patch{
+			Base:      "main",
+			Coverage:  Coverage{Statements: 4, Executed: 1, Coverage: 25.0},
+			Threshold: Threshold{Threshold: 50.0},
+		},
+		Rules: []Rule{
+			{
+				Rule: rules.Rule{Pattern: "pkg/...", Threshold: 45.0},
+				Packages: []Coverage{
+					{Package: "pkg/a", Statements: 10, Executed: 4, Coverage: 40.0},
+					{Package: "pkg/b", Statements: 10, Executed: 5, Coverage: 50.0},
+				},
+			},
+			{
+				Rule:   rules.Rule{Pattern: "other/...", Threshold: 90.0},
+				Passed: true,
+			},
+		},
+	}
+	buf := &bytes.Buffer{}
+
+	err := obj.WriteJUnit(buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="5" failures="2" errors="0" skipped="1">
+  <testsuite name="overcover" tests="5" failures="2" errors="0" skipped="1" time="0" timestamp="2020-01-02T03:04:05">
+    <testcase classname="overcover" name="threshold" time="0"></testcase>
+    <testcase classname="overcover" name="patch threshold (main)" time="0">
+      <failure message="coverage 25.0% is below the required 50.0%" type="threshold">coverage 25.0% is below the required 50.0%: 1 statements out of 4 covered</failure>
+    </testcase>
+    <testcase classname="overcover.rule(pkg/...)" name="pkg/a" time="0">
+      <failure message="coverage 40.0% is below the required 45.0%" type="threshold">coverage 40.0% is below the required 45.0%: 4 statements out of 10 covered</failure>
+    </testcase>
+    <testcase classname="overcover.rule(pkg/...)" name="pkg/b" time="0"></testcase>
+    <testcase classname="overcover.rule(other/...)" name="other/..." time="0">
+      <skipped message="rule matches no packages"></skipped>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, buf.String())
+}
+
+func TestReportWriteJUnitModules(t *testing.T) {
+	defer patcher.SetVar(&now, func() time.Time {
+		return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	}).Install().Restore()
+	obj := &Report{
+		Modules: []Module{
+			{
+				Module:    "example.com/mod",
+				Coverage:  Coverage{Statements: 10, Executed: 8, Coverage: 80.0},
+				Threshold: Threshold{Threshold: 75.0, Passed: true},
+			},
+			{
+				Module:    "example.com/other",
+				Coverage:  Coverage{Statements: 10, Executed: 4, Coverage: 40.0},
+				Threshold: Threshold{Threshold: 50.0},
+			},
+			{
+				Module:    "example.com/tools",
+				Coverage:  Coverage{Statements: 4, Executed: 1, Coverage: 25.0},
+				Threshold: Threshold{Passed: true},
+			},
+		},
+	}
+	buf := &bytes.Buffer{}
+
+	err := obj.WriteJUnit(buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="2" failures="1" errors="0" skipped="0">
+  <testsuite name="overcover" tests="2" failures="1" errors="0" skipped="0" time="0" timestamp="2020-01-02T03:04:05">
+    <testcase classname="overcover.module" name="example.com/mod" time="0"></testcase>
+    <testcase classname="overcover.module" name="example.com/other" time="0">
+      <failure message="coverage 40.0% is below the required 50.0%" type="threshold">coverage 40.0% is below the required 50.0%: 4 statements out of 10 covered</failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, buf.String())
+}
+
+func TestReportWriteJUnitExportedRule(t *testing.T) {
+	defer patcher.SetVar(&now, func() time.Time {
+		return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	}).Install().Restore()
+	obj := &Report{
+		Rules: []Rule{
+			{
+				Rule: rules.Rule{Pattern: "./api", Type: "exported", Threshold: 50.0},
+				Packages: []Coverage{
+					{Package: "pkg/api", Statements: 10, Executed: 6, Coverage: 60.0},
+				},
+				Functions: []RuleFunction{
+					{Function: Function{Function: "G", Line: 9, Coverage: Coverage{Package: "pkg/api", File: "a.go", Statements: 2}}, Passed: false},
+					{Function: Function{Function: "H", Line: 12, Coverage: Coverage{Package: "pkg/api", File: "a.go", Statements: 4, Executed: 1, Coverage: 25.0}}, Passed: false},
+					{Function: Function{Function: "F", Line: 3, Coverage: Coverage{Package: "pkg/api", File: "a.go", Statements: 4, Executed: 4, Coverage: 100.0}}, Passed: true},
+				},
+			},
+		},
+	}
+	buf := &bytes.Buffer{}
+
+	err := obj.WriteJUnit(buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="3" failures="2" errors="0" skipped="0">
+  <testsuite name="overcover" tests="3" failures="2" errors="0" skipped="0" time="0" timestamp="2020-01-02T03:04:05">
+    <testcase classname="overcover.rule(./api)" name="pkg/api.G" time="0">
+      <failure message="no statements executed" type="threshold">no statements executed: 0 statements out of 2 covered</failure>
+    </testcase>
+    <testcase classname="overcover.rule(./api)" name="pkg/api.H" time="0">
+      <failure message="coverage 25.0% is below the required 50.0%" type="threshold">coverage 25.0% is below the required 50.0%: 1 statements out of 4 covered</failure>
+    </testcase>
+    <testcase classname="overcover.rule(./api)" name="pkg/api.F" time="0"></testcase>
+  </testsuite>
+</testsuites>
+`, buf.String())
+}
+
+func TestReportWriteJUnitEmpty(t *testing.T) {
+	defer patcher.SetVar(&now, func() time.Time {
+		return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	}).Install().Restore()
+	obj := &Report{
+		Patch: &Patch{Base: "main"},
+	}
+	buf := &bytes.Buffer{}
+
+	err := obj.WriteJUnit(buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="0" failures="0" errors="0" skipped="0">
+  <testsuite name="overcover" tests="0" failures="0" errors="0" skipped="0" time="0" timestamp="2020-01-02T03:04:05"></testsuite>
+</testsuites>
+`, buf.String())
+}
+
+func TestReportWriteJUnitHeaderError(t *testing.T) {
+	obj := &Report{}
+
+	err := obj.WriteJUnit(&failWriter{})
+
+	assert.Error(t, err)
+}
+
+func TestReportWriteJUnitEncodeError(t *testing.T) {
+	obj := &Report{}
+
+	err := obj.WriteJUnit(&failWriter{limit: len(`<?xml version="1.0" encoding="UTF-8"?>`) + 1})
+
+	assert.Error(t, err)
+}
+
+func TestReportWriteJUnitNewlineError(t *testing.T) {
+	obj := &Report{}
+	buf := &bytes.Buffer{}
+	_ = obj.WriteJUnit(buf)
+
+	err := obj.WriteJUnit(&failWriter{limit: buf.Len() - 1})
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/modules"
+)
+
+// WriteLCOV writes the coverage data in a data set to a stream as an
+// LCOV tracefile, suitable for genhtml and editor plugins.  The
+// per-line execution counts are derived from the blocks of statements
+// in the coverage profiles; files for which only the statement count
+// is known are included with no lines.
+func WriteLCOV(w io.Writer, ds common.DataSet, mods []modules.Module) error {
+	sorted := append(common.DataSet{}, ds...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Handle() < sorted[j].Handle()
+	})
+
+	bw := bufio.NewWriter(w)
+	for _, fd := range sorted {
+		fmt.Fprintf(bw, "TN:\nSF:%s\n", sourceFileName(fd, mods))
+		lines := lineCounts(fd.Blocks)
+		hit := 0
+		for _, lc := range lines {
+			fmt.Fprintf(bw, "DA:%d,%d\n", lc.Line, lc.Hits)
+			if lc.Hits > 0 {
+				hit++
+			}
+		}
+		fmt.Fprintf(bw, "LF:%d\nLH:%d\nend_of_record\n", len(lines), hit)
+	}
+
+	return bw.Flush()
+}
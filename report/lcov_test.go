@@ -0,0 +1,83 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/modules"
+)
+
+func TestWriteLCOVBase(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	err := WriteLCOV(buf, testCoberturaData, []modules.Module{{Root: filepath.FromSlash("/src/mod"), Path: "example.com/mod"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "TN:\nSF:"+filepath.FromSlash("/src/mod/main.go")+`
+LF:0
+LH:0
+end_of_record
+TN:
+SF:`+filepath.FromSlash("/src/mod/pkg/alpha.go")+`
+LF:0
+LH:0
+end_of_record
+TN:
+SF:`+filepath.FromSlash("/src/mod/pkg/file.go")+`
+DA:3,1
+DA:4,1
+DA:5,1
+LF:3
+LH:3
+end_of_record
+TN:
+SF:example.com/other/other.go
+LF:0
+LH:0
+end_of_record
+`, buf.String())
+}
+
+func TestWriteLCOVUncovered(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	err := WriteLCOV(buf, common.DataSet{
+		{
+			Package: "example.com/mod",
+			Name:    "main.go",
+			Count:   2,
+			Exec:    1,
+			Blocks: []common.Block{
+				{StartLine: 3, EndLine: 3, NumStmt: 1, Count: 0},
+				{StartLine: 4, EndLine: 4, NumStmt: 1, Count: 2},
+			},
+		},
+	}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "TN:\nSF:example.com/mod/main.go\nDA:3,0\nDA:4,2\nLF:2\nLH:1\nend_of_record\n", buf.String())
+}
+
+func TestWriteLCOVFails(t *testing.T) {
+	err := WriteLCOV(&failWriter{}, testCoberturaData, []modules.Module{{Root: "/src/mod", Path: "example.com/mod"}})
+
+	assert.Same(t, assert.AnError, err)
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package report
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/modules"
+)
+
+// lineCount describes the execution count of a single line.
+type lineCount struct {
+	Line int // The line number
+	Hits int // The number of times the line was executed
+}
+
+// relPath computes the path of a package relative to the root of the
+// module with the specified path.  Packages outside the module are
+// left as import paths.
+func relPath(pkg, modPath string) string {
+	if modPath != "" {
+		if pkg == modPath {
+			return ""
+		}
+		if rel := strings.TrimPrefix(pkg, modPath+"/"); rel != pkg {
+			return rel
+		}
+	}
+
+	return pkg
+}
+
+// sourceFileName computes the name of a source file.  Files within
+// one of the modules are named by their absolute path beneath the
+// root of the module containing them; other files are named by
+// import path.
+func sourceFileName(fd common.FileData, mods []modules.Module) string {
+	mod, _ := modules.Owner(mods, fd.Package)
+	rel := relPath(fd.Package, mod.Path)
+	if mod.Root == "" || rel == fd.Package {
+		return path.Join(rel, fd.Name)
+	}
+
+	return filepath.Join(mod.Root, filepath.FromSlash(rel), fd.Name)
+}
+
+// blockLines computes the first and last lines containing statements
+// in a block.  The end position of a block is exclusive, so a block
+// ending at the first column of a line does not include that line.
+func blockLines(b common.Block) (int, int) {
+	if b.EndCol <= 1 && b.EndLine > b.StartLine {
+		return b.StartLine, b.EndLine - 1
+	}
+
+	return b.StartLine, b.EndLine
+}
+
+// lineCounts computes the line execution counts for a file from its
+// blocks.  A line shared by several blocks is reported with the
+// highest count among them.  The result is sorted by line number.
+func lineCounts(blocks []common.Block) []lineCount {
+	hits := map[int]int{}
+	for _, b := range blocks {
+		first, last := blockLines(b)
+		for line := first; line <= last; line++ {
+			if count, ok := hits[line]; !ok || b.Count > count {
+				hits[line] = b.Count
+			}
+		}
+	}
+
+	result := make([]lineCount, 0, len(hits))
+	for line, count := range hits {
+		result = append(result, lineCount{
+			Line: line,
+			Hits: count,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Line < result[j].Line
+	})
+
+	return result
+}
+
+// Uncovered lists the lines of a file containing statements that were
+// never executed, computed from its blocks, as a comma-separated list
+// of line ranges such as "12-18,40".  Lines without statements do not
+// interrupt a range; only lines that were executed do.  The empty
+// string is returned if every statement was executed.
+func Uncovered(blocks []common.Block) string {
+	// Only blocks with statements are of interest
+	stmts := make([]common.Block, 0, len(blocks))
+	for _, b := range blocks {
+		if b.NumStmt > 0 {
+			stmts = append(stmts, b)
+		}
+	}
+
+	// Collect the ranges of lines not executed
+	var ranges []string
+	first, last := 0, 0
+	emit := func() {
+		switch {
+		case first == 0:
+		case first == last:
+			ranges = append(ranges, strconv.Itoa(first))
+		default:
+			ranges = append(ranges, fmt.Sprintf("%d-%d", first, last))
+		}
+		first = 0
+	}
+	for _, lc := range lineCounts(stmts) {
+		if lc.Hits > 0 {
+			emit()
+			continue
+		}
+		if first == 0 {
+			first = lc.Line
+		}
+		last = lc.Line
+	}
+	emit()
+
+	return strings.Join(ranges, ",")
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package report
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/modules"
+)
+
+func TestRelPathModule(t *testing.T) {
+	result := relPath("example.com/mod", "example.com/mod")
+
+	assert.Equal(t, "", result)
+}
+
+func TestRelPathInModule(t *testing.T) {
+	result := relPath("example.com/mod/pkg", "example.com/mod")
+
+	assert.Equal(t, "pkg", result)
+}
+
+func TestRelPathOutsideModule(t *testing.T) {
+	result := relPath("example.com/module", "example.com/mod")
+
+	assert.Equal(t, "example.com/module", result)
+}
+
+func TestRelPathNoModule(t *testing.T) {
+	result := relPath("example.com/mod/pkg", "")
+
+	assert.Equal(t, "example.com/mod/pkg", result)
+}
+
+func TestSourceFileNameInModule(t *testing.T) {
+	result := sourceFileName(common.FileData{Package: "example.com/mod/pkg", Name: "file.go"}, []modules.Module{{Root: filepath.FromSlash("/src/mod"), Path: "example.com/mod"}})
+
+	assert.Equal(t, filepath.FromSlash("/src/mod/pkg/file.go"), result)
+}
+
+func TestSourceFileNameModuleRoot(t *testing.T) {
+	result := sourceFileName(common.FileData{Package: "example.com/mod", Name: "main.go"}, []modules.Module{{Root: filepath.FromSlash("/src/mod"), Path: "example.com/mod"}})
+
+	assert.Equal(t, filepath.FromSlash("/src/mod/main.go"), result)
+}
+
+func TestSourceFileNameOutsideModule(t *testing.T) {
+	result := sourceFileName(common.FileData{Package: "example.com/other", Name: "file.go"}, []modules.Module{{Root: filepath.FromSlash("/src/mod"), Path: "example.com/mod"}})
+
+	assert.Equal(t, "example.com/other/file.go", result)
+}
+
+func TestSourceFileNameWorkspace(t *testing.T) {
+	result := sourceFileName(common.FileData{Package: "example.com/other/pkg", Name: "file.go"}, []modules.Module{
+		{Root: filepath.FromSlash("/src/mod"), Path: "example.com/mod"},
+		{Root: filepath.FromSlash("/src/other"), Path: "example.com/other"},
+	})
+
+	assert.Equal(t, filepath.FromSlash("/src/other/pkg/file.go"), result)
+}
+
+func TestSourceFileNameNoRoot(t *testing.T) {
+	result := sourceFileName(common.FileData{Package: "example.com/mod/pkg", Name: "file.go"}, []modules.Module{{Path: "example.com/mod"}})
+
+	assert.Equal(t, "pkg/file.go", result)
+}
+
+func TestBlockLinesBase(t *testing.T) {
+	first, last := blockLines(common.Block{StartLine: 3, StartCol: 5, EndLine: 5, EndCol: 3})
+
+	assert.Equal(t, 3, first)
+	assert.Equal(t, 5, last)
+}
+
+func TestBlockLinesFirstColumn(t *testing.T) {
+	first, last := blockLines(common.Block{StartLine: 3, StartCol: 5, EndLine: 5, EndCol: 1})
+
+	assert.Equal(t, 3, first)
+	assert.Equal(t, 4, last)
+}
+
+func TestBlockLinesSingleLine(t *testing.T) {
+	first, last := blockLines(common.Block{StartLine: 3, StartCol: 1, EndLine: 3, EndCol: 1})
+
+	assert.Equal(t, 3, first)
+	assert.Equal(t, 3, last)
+}
+
+func TestLineCountsBase(t *testing.T) {
+	result := lineCounts([]common.Block{
+		{StartLine: 5, StartCol: 3, EndLine: 6, EndCol: 1, Count: 0},
+		{StartLine: 3, StartCol: 20, EndLine: 5, EndCol: 3, Count: 1},
+	})
+
+	assert.Equal(t, []lineCount{
+		{Line: 3, Hits: 1},
+		{Line: 4, Hits: 1},
+		{Line: 5, Hits: 1},
+	}, result)
+}
+
+func TestLineCountsShared(t *testing.T) {
+	result := lineCounts([]common.Block{
+		{StartLine: 3, EndLine: 3, Count: 0},
+		{StartLine: 3, EndLine: 3, Count: 2},
+		{StartLine: 3, EndLine: 3, Count: 1},
+	})
+
+	assert.Equal(t, []lineCount{
+		{Line: 3, Hits: 2},
+	}, result)
+}
+
+func TestLineCountsEmpty(t *testing.T) {
+	result := lineCounts(nil)
+
+	assert.Equal(t, []lineCount{}, result)
+}
+
+func TestUncoveredBase(t *testing.T) {
+	result := Uncovered([]common.Block{
+		{StartLine: 3, StartCol: 2, EndLine: 4, EndCol: 10, NumStmt: 2, Count: 1},
+		{StartLine: 5, StartCol: 3, EndLine: 7, EndCol: 1, NumStmt: 1},
+		{StartLine: 9, StartCol: 2, EndLine: 10, EndCol: 5, NumStmt: 2},
+		{StartLine: 12, StartCol: 2, EndLine: 12, EndCol: 10, NumStmt: 1, Count: 2},
+		{StartLine: 14, StartCol: 2, EndLine: 14, EndCol: 10, NumStmt: 1},
+		{StartLine: 15, StartCol: 12, EndLine: 15, EndCol: 12},
+	})
+
+	assert.Equal(t, "5-10,14", result)
+}
+
+func TestUncoveredShared(t *testing.T) {
+	result := Uncovered([]common.Block{
+		{StartLine: 3, StartCol: 2, EndLine: 4, EndCol: 10, NumStmt: 2},
+		{StartLine: 4, StartCol: 11, EndLine: 5, EndCol: 3, NumStmt: 1, Count: 1},
+		{StartLine: 6, StartCol: 2, EndLine: 6, EndCol: 10, NumStmt: 1},
+	})
+
+	assert.Equal(t, "3,6", result)
+}
+
+func TestUncoveredNone(t *testing.T) {
+	result := Uncovered([]common.Block{
+		{StartLine: 3, StartCol: 2, EndLine: 4, EndCol: 10, NumStmt: 2, Count: 1},
+		{StartLine: 5, StartCol: 12, EndLine: 5, EndCol: 12},
+	})
+
+	assert.Equal(t, "", result)
+}
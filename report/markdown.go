@@ -0,0 +1,156 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// worstCoverage returns at most limit entries from a list of
+// coverage, in order of increasing coverage.  If limit is not
+// positive, all entries are returned.
+func worstCoverage(list []Coverage, limit int) []Coverage {
+	result := append([]Coverage{}, list...)
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Coverage < result[j].Coverage
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result
+}
+
+// markdownThreshold formats the result of checking a threshold.  The
+// what parameter describes the threshold in lower case.
+func markdownThreshold(what string, thr Threshold) string {
+	if thr.Passed {
+		return fmt.Sprintf("%s%s threshold of %.1f%% met.", strings.ToUpper(what[:1]), what[1:], thr.Threshold)
+	}
+
+	return fmt.Sprintf("**Failed** to meet %s threshold of %.1f%%.", what, thr.Threshold)
+}
+
+// markdownTable writes a table of the entries with the lowest
+// coverage.
+func markdownTable(w io.Writer, title, column string, list []Coverage, limit int, name func(Coverage) string) {
+	worst := worstCoverage(list, limit)
+	fmt.Fprintf(w, "\n### %s\n\n", title)
+	if len(worst) < len(list) {
+		fmt.Fprintf(w, "Showing the %d of %d with the lowest coverage.\n\n", len(worst), len(list))
+	}
+	fmt.Fprintf(w, "| %s | Executed | Total | Coverage |\n| --- | ---: | ---: | ---: |\n", column)
+	for _, cov := range worst {
+		fmt.Fprintf(w, "| `%s` | %d | %d | %.1f%% |\n", name(cov), cov.Executed, cov.Statements, cov.Coverage)
+	}
+}
+
+// markdownFunctions writes a table of the functions with the lowest
+// coverage.  The functions are already sorted in order of increasing
+// coverage.
+func markdownFunctions(w io.Writer, list []Function, limit int) {
+	worst := list
+	if limit > 0 && len(worst) > limit {
+		worst = worst[:limit]
+	}
+	fmt.Fprintf(w, "\n### Lowest Coverage Functions\n\n")
+	if len(worst) < len(list) {
+		fmt.Fprintf(w, "Showing the %d of %d with the lowest coverage.\n\n", len(worst), len(list))
+	}
+	fmt.Fprintf(w, "| Function | File | Executed | Total | Coverage |\n| --- | --- | ---: | ---: | ---: |\n")
+	for _, fn := range worst {
+		fmt.Fprintf(w, "| `%s` | `%s/%s:%d` | %d | %d | %.1f%% |\n", fn.Function, fn.Package, fn.File, fn.Line, fn.Executed, fn.Statements, fn.Coverage.Coverage)
+	}
+}
+
+// WriteMarkdown writes the report to a stream as a Markdown document,
+// suitable for pull request comments.  The document contains the
+// overall coverage and the results of checking the thresholds,
+// followed by tables of the packages, files, and (if requested)
+// functions with the lowest coverage; at most limit entries are
+// included in each table, unless limit is not positive.
+func (r *Report) WriteMarkdown(w io.Writer, limit int) error {
+	bw := bufio.NewWriter(w)
+
+	// Start with the overall coverage
+	fmt.Fprintf(bw, "## Coverage Report\n\n**Overall coverage:** %.1f%% (%d of %d statements)\n", r.Overall.Coverage, r.Overall.Executed, r.Overall.Statements)
+	if r.Threshold.Threshold > 0.0 {
+		fmt.Fprintf(bw, "\n%s\n", markdownThreshold("coverage", r.Threshold))
+	}
+	if r.Excluded != nil {
+		fmt.Fprintf(bw, "\n%d statements (%d executed) were excluded.\n", r.Excluded.Statements, r.Excluded.Executed)
+	}
+
+	// Add the patch coverage
+	if r.Patch != nil {
+		fmt.Fprintf(bw, "\n**Patch coverage:** %.1f%% (%d of %d statements in changed blocks relative to `%s`)\n", r.Patch.Coverage.Coverage, r.Patch.Executed, r.Patch.Statements, r.Patch.Base)
+		if r.Patch.Threshold.Threshold > 0.0 {
+			fmt.Fprintf(bw, "\n%s\n", markdownThreshold("patch coverage", r.Patch.Threshold))
+		}
+	}
+
+	// Add the rules
+	if len(r.Rules) > 0 {
+		fmt.Fprintf(bw, "\n### Rules\n\n| Rule | Threshold | Lowest Coverage | Status |\n| --- | ---: | ---: | --- |\n")
+		for _, rule := range r.Rules {
+			lowest := "-"
+			if rule.Exported() {
+				if len(rule.Functions) > 0 {
+					lowest = fmt.Sprintf("%.1f%%", rule.Functions[0].Coverage.Coverage)
+				}
+			} else if worst := worstCoverage(rule.Packages, 1); len(worst) > 0 {
+				lowest = fmt.Sprintf("%.1f%%", worst[0].Coverage)
+			}
+			status := "Passed"
+			if !rule.Passed {
+				status = "**Failed**"
+			}
+			fmt.Fprintf(bw, "| `%s` | %.1f%% | %s | %s |\n", rule.Pattern, rule.Threshold, lowest, status)
+		}
+	}
+
+	// Add the modules of the workspace
+	if len(r.Modules) > 0 {
+		fmt.Fprintf(bw, "\n### Modules\n\n| Module | Executed | Total | Coverage | Threshold | Status |\n| --- | ---: | ---: | ---: | ---: | --- |\n")
+		for _, mod := range r.Modules {
+			threshold, status := "-", "-"
+			if mod.Threshold.Threshold > 0.0 {
+				threshold = fmt.Sprintf("%.1f%%", mod.Threshold.Threshold)
+				status = "Passed"
+				if !mod.Threshold.Passed {
+					status = "**Failed**"
+				}
+			}
+			fmt.Fprintf(bw, "| `%s` | %d | %d | %.1f%% | %s | %s |\n", mod.Module, mod.Executed, mod.Statements, mod.Coverage.Coverage, threshold, status)
+		}
+	}
+
+	// Add the packages and files with the lowest coverage
+	markdownTable(bw, "Lowest Coverage Packages", "Package", r.Packages, limit, func(cov Coverage) string {
+		return cov.Package
+	})
+	markdownTable(bw, "Lowest Coverage Files", "File", r.Files, limit, func(cov Coverage) string {
+		return cov.Package + "/" + cov.File
+	})
+	if len(r.Functions) > 0 {
+		markdownFunctions(bw, r.Functions, limit)
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,316 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/klmitch/overcover/rules"
+)
+
+func TestWorstCoverageBase(t *testing.T) {
+	list := []Coverage{
+		{Package: "a", Coverage: 50.0},
+		{Package: "b", Coverage: 25.0},
+		{Package: "c", Coverage: 50.0},
+		{Package: "d", Coverage: 75.0},
+	}
+
+	result := worstCoverage(list, 3)
+
+	assert.Equal(t, []Coverage{
+		{Package: "b", Coverage: 25.0},
+		{Package: "a", Coverage: 50.0},
+		{Package: "c", Coverage: 50.0},
+	}, result)
+	assert.Equal(t, "a", list[0].Package)
+}
+
+func TestWorstCoverageUnlimited(t *testing.T) {
+	list := []Coverage{
+		{Package: "a", Coverage: 50.0},
+		{Package: "b", Coverage: 25.0},
+	}
+
+	result := worstCoverage(list, 0)
+
+	assert.Equal(t, []Coverage{
+		{Package: "b", Coverage: 25.0},
+		{Package: "a", Coverage: 50.0},
+	}, result)
+}
+
+func TestMarkdownThresholdPassed(t *testing.T) {
+	result := markdownThreshold("coverage", Threshold{Threshold: 80.0, Passed: true})
+
+	assert.Equal(t, "Coverage threshold of 80.0% met.", result)
+}
+
+func TestMarkdownThresholdFailed(t *testing.T) {
+	result := markdownThreshold("coverage", Threshold{Threshold: 80.0})
+
+	assert.Equal(t, "**Failed** to meet coverage threshold of 80.0%.", result)
+}
+
+func TestReportWriteMarkdownBase(t *testing.T) {
+	obj := &Report{
+		Version: Version,
+		Overall: Coverage{Statements: 20, Executed: 9, Coverage: 45.0},
+		Packages: []Coverage{
+			{Package: "pkg/a", Statements: 10, Executed: 4, Coverage: 40.0},
+			{Package: "pkg/b", Statements: 10, Executed: 5, Coverage: 50.0},
+		},
+		Files: []Coverage{
+			{Package: "pkg/a", File: "file1.go", Statements: 6},
+			{Package: "pkg/a", File: "file2.go", Statements: 4, Executed: 4, Coverage: 100.0},
+			{Package: "pkg/b", File: "file1.go", Statements: 10, Executed: 5, Coverage: 50.0},
+		},
+		Threshold: Threshold{Threshold: 50.0},
+	}
+	buf := &bytes.Buffer{}
+
+	err := obj.WriteMarkdown(buf, 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "## Coverage Report\n"+
+		"\n"+
+		"**Overall coverage:** 45.0% (9 of 20 statements)\n"+
+		"\n"+
+		"**Failed** to meet coverage threshold of 50.0%.\n"+
+		"\n"+
+		"### Lowest Coverage Packages\n"+
+		"\n"+
+		"| Package | Executed | Total | Coverage |\n"+
+		"| --- | ---: | ---: | ---: |\n"+
+		"| `pkg/a` | 4 | 10 | 40.0% |\n"+
+		"| `pkg/b` | 5 | 10 | 50.0% |\n"+
+		"\n"+
+		"### Lowest Coverage Files\n"+
+		"\n"+
+		"Showing the 2 of 3 with the lowest coverage.\n"+
+		"\n"+
+		"| File | Executed | Total | Coverage |\n"+
+		"| --- | ---: | ---: | ---: |\n"+
+		"| `pkg/a/file1.go` | 0 | 6 | 0.0% |\n"+
+		"| `pkg/b/file1.go` | 5 | 10 | 50.0% |\n", buf.String())
+}
+
+func TestReportWriteMarkdownPatchAndRules(t *testing.T) {
+	obj := &Report{
+		Version:   Version,
+		Overall:   Coverage{Statements: 10, Executed: 5, Coverage: 50.0},
+		Threshold: Threshold{Passed: true},
+		Rules: []Rule{
+			{
+				Rule:   rules.Rule{Pattern: "./pkg/...", Threshold: 60.0},
+				Passed: false,
+				Packages: []Coverage{
+					{Package: "pkg/a", Statements: 10, Executed: 4, Coverage: 40.0},
+					{Package: "pkg/b", Statements: 10, Executed: 5, Coverage: 50.0},
+				},
+			},
+			{
+				Rule:     rules.Rule{Pattern: "./none", Threshold: 60.0},
+				Passed:   true,
+				Packages: []Coverage{},
+			},
+		},
+		Patch: &Patch{
+			Base:      "main",
+			Coverage:  Coverage{Statements: 4, Executed: 3, Coverage: 75.0},
+			Threshold: Threshold{Threshold: 70.0, Passed: true},
+		},
+	}
+	buf := &bytes.Buffer{}
+
+	err := obj.WriteMarkdown(buf, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "## Coverage Report\n"+
+		"\n"+
+		"**Overall coverage:** 50.0% (5 of 10 statements)\n"+
+		"\n"+
+		"**Patch coverage:** 75.0% (3 of 4 statements in changed blocks relative to `main`)\n"+
+		"\n"+
+		"Patch coverage threshold of 70.0% met.\n"+
+		"\n"+
+		"### Rules\n"+
+		"\n"+
+		"| Rule | Threshold | Lowest Coverage | Status |\n"+
+		"| --- | ---: | ---: | --- |\n"+
+		"| `./pkg/...` | 60.0% | 40.0% | **Failed** |\n"+
+		"| `./none` | 60.0% | - | Passed |\n"+
+		"\n"+
+		"### Lowest Coverage Packages\n"+
+		"\n"+
+		"| Package | Executed | Total | Coverage |\n"+
+		"| --- | ---: | ---: | ---: |\n"+
+		"\n"+
+		"### Lowest Coverage Files\n"+
+		"\n"+
+		"| File | Executed | Total | Coverage |\n"+
+		"| --- | ---: | ---: | ---: |\n", buf.String())
+}
+
+func TestReportWriteMarkdownModules(t *testing.T) {
+	obj := &Report{
+		Modules: []Module{
+			{
+				Module:    "example.com/mod",
+				Coverage:  Coverage{Statements: 10, Executed: 8, Coverage: 80.0},
+				Threshold: Threshold{Threshold: 75.0, Passed: true},
+			},
+			{
+				Module:    "example.com/other",
+				Coverage:  Coverage{Statements: 10, Executed: 4, Coverage: 40.0},
+				Threshold: Threshold{Threshold: 50.0},
+			},
+			{
+				Module:    "example.com/tools",
+				Coverage:  Coverage{Statements: 4, Executed: 1, Coverage: 25.0},
+				Threshold: Threshold{Passed: true},
+			},
+		},
+	}
+	buf := &bytes.Buffer{}
+
+	err := obj.WriteMarkdown(buf, 0)
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "### Modules\n"+
+		"\n"+
+		"| Module | Executed | Total | Coverage | Threshold | Status |\n"+
+		"| --- | ---: | ---: | ---: | ---: | --- |\n"+
+		"| `example.com/mod` | 8 | 10 | 80.0% | 75.0% | Passed |\n"+
+		"| `example.com/other` | 4 | 10 | 40.0% | 50.0% | **Failed** |\n"+
+		"| `example.com/tools` | 1 | 4 | 25.0% | - | - |\n"+
+		"\n"+
+		"### Lowest Coverage Packages\n")
+}
+
+func TestReportWriteMarkdownFunctions(t *testing.T) {
+	obj := &Report{
+		Functions: []Function{
+			{Function: "G", Line: 9, Coverage: Coverage{Package: "pkg/a", File: "file1.go", Statements: 2}},
+			{Function: "(*T).F", Line: 3, Coverage: Coverage{Package: "pkg/a", File: "file1.go", Statements: 4, Executed: 2, Coverage: 50.0}},
+			{Function: "H", Line: 12, Coverage: Coverage{Package: "pkg/a", File: "file1.go", Statements: 4, Executed: 4, Coverage: 100.0}},
+		},
+	}
+	buf := &bytes.Buffer{}
+
+	err := obj.WriteMarkdown(buf, 2)
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "\n### Lowest Coverage Functions\n"+
+		"\n"+
+		"Showing the 2 of 3 with the lowest coverage.\n"+
+		"\n"+
+		"| Function | File | Executed | Total | Coverage |\n"+
+		"| --- | --- | ---: | ---: | ---: |\n"+
+		"| `G` | `pkg/a/file1.go:9` | 0 | 2 | 0.0% |\n"+
+		"| `(*T).F` | `pkg/a/file1.go:3` | 2 | 4 | 50.0% |\n")
+}
+
+func TestReportWriteMarkdownFunctionsUnlimited(t *testing.T) {
+	obj := &Report{
+		Functions: []Function{
+			{Function: "G", Line: 9, Coverage: Coverage{Package: "pkg/a", File: "file1.go", Statements: 2}},
+			{Function: "(*T).F", Line: 3, Coverage: Coverage{Package: "pkg/a", File: "file1.go", Statements: 4, Executed: 2, Coverage: 50.0}},
+		},
+	}
+	buf := &bytes.Buffer{}
+
+	err := obj.WriteMarkdown(buf, 0)
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "\n### Lowest Coverage Functions\n"+
+		"\n"+
+		"| Function | File | Executed | Total | Coverage |\n"+
+		"| --- | --- | ---: | ---: | ---: |\n"+
+		"| `G` | `pkg/a/file1.go:9` | 0 | 2 | 0.0% |\n"+
+		"| `(*T).F` | `pkg/a/file1.go:3` | 2 | 4 | 50.0% |\n")
+}
+
+func TestReportWriteMarkdownExportedRules(t *testing.T) {
+	obj := &Report{
+		Rules: []Rule{
+			{
+				Rule:   rules.Rule{Pattern: "./api", Type: "exported", Threshold: 50.0},
+				Passed: false,
+				Packages: []Coverage{
+					{Package: "pkg/api", Statements: 10, Executed: 6, Coverage: 60.0},
+				},
+				Functions: []RuleFunction{
+					{Function: Function{Function: "G", Line: 9, Coverage: Coverage{Package: "pkg/api", File: "a.go", Statements: 2}}, Passed: false},
+					{Function: Function{Function: "F", Line: 3, Coverage: Coverage{Package: "pkg/api", File: "a.go", Statements: 4, Executed: 4, Coverage: 100.0}}, Passed: true},
+				},
+			},
+			{
+				Rule:     rules.Rule{Pattern: "./internal", Type: "exported"},
+				Passed:   true,
+				Packages: []Coverage{},
+			},
+		},
+	}
+	buf := &bytes.Buffer{}
+
+	err := obj.WriteMarkdown(buf, 0)
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "| `./api` | 50.0% | 0.0% | **Failed** |\n"+
+		"| `./internal` | 0.0% | - | Passed |\n")
+}
+
+func TestReportWriteMarkdownPatchNoThreshold(t *testing.T) {
+	obj := &Report{
+		Patch: &Patch{
+			Base:      "main",
+			Threshold: Threshold{Passed: true},
+		},
+	}
+	buf := &bytes.Buffer{}
+
+	err := obj.WriteMarkdown(buf, 0)
+
+	assert.NoError(t, err)
+	assert.NotContains(t, buf.String(), "threshold")
+}
+
+func TestReportWriteMarkdownExcluded(t *testing.T) {
+	obj := &Report{
+		Overall:  Coverage{Statements: 10, Executed: 5, Coverage: 50.0},
+		Excluded: &Coverage{Statements: 8, Executed: 2, Coverage: 25.0},
+	}
+	buf := &bytes.Buffer{}
+
+	err := obj.WriteMarkdown(buf, 0)
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `**Overall coverage:** 50.0% (5 of 10 statements)
+
+8 statements (2 executed) were excluded.
+`)
+}
+
+func TestReportWriteMarkdownFails(t *testing.T) {
+	obj := &Report{}
+
+	err := obj.WriteMarkdown(&failWriter{}, 0)
+
+	assert.Same(t, assert.AnError, err)
+}
@@ -0,0 +1,268 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package report contains the machine-readable representations of
+// the results of a coverage run, along with the writers that emit
+// them.
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/rules"
+)
+
+// Version is the version of the JSON report format.  It is
+// incremented whenever an incompatible change is made to the format.
+const Version = 1
+
+// ErrVersion is returned by ReadJSON if the report is of an
+// unsupported version.
+var ErrVersion = errors.New("unsupported report version")
+
+// Coverage describes the statement counts for a file, a package, or
+// the whole code base.
+type Coverage struct {
+	Package    string  `json:"package,omitempty"` // Package import path
+	File       string  `json:"file,omitempty"`    // File base name
+	Statements int64   `json:"statements"`        // Number of statements
+	Executed   int64   `json:"executed"`          // Number of executed statements
+	Coverage   float64 `json:"coverage"`          // Coverage as a percentage
+}
+
+// NewCoverage constructs a Coverage from a FileData.
+func NewCoverage(fd common.FileData) Coverage {
+	return Coverage{
+		Package:    fd.Package,
+		File:       fd.Name,
+		Statements: fd.Count,
+		Executed:   fd.Exec,
+		Coverage:   fd.Coverage() * 100.0,
+	}
+}
+
+// NewCoverageList constructs a list of Coverage from a data set.  The
+// list is sorted by package and file name.
+func NewCoverageList(ds common.DataSet) []Coverage {
+	result := make([]Coverage, 0, len(ds))
+	for _, fd := range ds {
+		result = append(result, NewCoverage(fd))
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Package != result[j].Package {
+			return result[i].Package < result[j].Package
+		}
+		return result[i].File < result[j].File
+	})
+
+	return result
+}
+
+// Function describes the coverage of a function.
+type Function struct {
+	Function string `json:"function"` // Function name
+	Line     int    `json:"line"`     // Line on which the function starts
+	Coverage
+}
+
+// NewFunction constructs a Function from a FuncData.
+func NewFunction(fd common.FuncData) Function {
+	return Function{
+		Function: fd.Name,
+		Line:     fd.Line,
+		Coverage: Coverage{
+			Package:    fd.Package,
+			File:       fd.File,
+			Statements: fd.Count,
+			Executed:   fd.Exec,
+			Coverage:   fd.Coverage() * 100.0,
+		},
+	}
+}
+
+// NewFunctions constructs a list of Function from a function set.
+// The list is sorted in order of increasing coverage.
+func NewFunctions(fs common.FuncSet) []Function {
+	sorted := append(common.FuncSet{}, fs...)
+	sort.Sort(sorted)
+	result := make([]Function, 0, len(sorted))
+	for _, fd := range sorted {
+		result = append(result, NewFunction(fd))
+	}
+
+	return result
+}
+
+// Threshold describes the result of checking a coverage threshold.
+type Threshold struct {
+	Threshold float64 `json:"threshold"` // The threshold, as a percentage
+	Passed    bool    `json:"passed"`    // Whether the threshold was met
+}
+
+// NewThreshold checks a coverage against a threshold.  A threshold
+// of zero is always met.
+func NewThreshold(coverage, threshold float64) Threshold {
+	return Threshold{
+		Threshold: threshold,
+		Passed:    threshold <= 0.0 || coverage >= threshold,
+	}
+}
+
+// RuleFunction describes the coverage of an exported function matched
+// by an exported rule, along with whether it met the rule's threshold.
+type RuleFunction struct {
+	Function
+	Passed bool `json:"passed"` // Whether the function met the threshold
+}
+
+// Rule describes the result of checking a per-package rule.  For an
+// exported rule, the threshold applies to each exported function.
+type Rule struct {
+	rules.Rule
+	Passed    bool           `json:"passed"`              // Whether all packages or functions met the threshold
+	Packages  []Coverage     `json:"packages"`            // The packages matched by the rule
+	Functions []RuleFunction `json:"functions,omitempty"` // The exported functions matched by an exported rule
+}
+
+// NewRules constructs a list of Rule from the results of evaluating
+// the rules.  The exported functions matched by an exported rule are
+// sorted in order of increasing coverage.
+func NewRules(results []rules.Result) []Rule {
+	var result []Rule
+	for _, res := range results {
+		rule := Rule{
+			Rule:     res.Rule,
+			Passed:   len(res.Failures()) == 0 && len(res.FunctionFailures()) == 0,
+			Packages: NewCoverageList(res.Packages),
+		}
+		if res.Rule.Exported() {
+			sorted := append(common.FuncSet{}, res.Functions...)
+			sort.Sort(sorted)
+			rule.Functions = make([]RuleFunction, 0, len(sorted))
+			for _, fd := range sorted {
+				rule.Functions = append(rule.Functions, RuleFunction{
+					Function: NewFunction(fd),
+					Passed:   res.Rule.FunctionPassed(fd),
+				})
+			}
+		}
+		result = append(result, rule)
+	}
+
+	return result
+}
+
+// Module describes the coverage of a module of a workspace, along
+// with the result of checking its threshold.
+type Module struct {
+	Module string `json:"module"` // The module path
+	Coverage
+	Threshold Threshold `json:"threshold"` // Result of checking the module threshold
+}
+
+// Patch describes the coverage of the lines changed relative to a
+// base revision.
+type Patch struct {
+	Base string `json:"base"` // The base revision
+	Coverage
+	Threshold Threshold `json:"threshold"` // Result of checking the patch threshold
+}
+
+// Update describes the threshold updates proposed because the
+// coverage exceeds the thresholds by more than the maximum headroom.
+type Update struct {
+	Threshold *float64     `json:"threshold,omitempty"` // New overall threshold
+	Rules     []rules.Rule `json:"rules,omitempty"`     // Rules with the new thresholds
+}
+
+// Conflicts describes the files for which data was ignored because
+// the sources of coverage data did not agree.
+type Conflicts struct {
+	Profiles []string `json:"profiles"` // Files with mismatched blocks in the profiles
+	Source   []string `json:"source"`   // Files whose profile data may not match the source
+}
+
+// Report describes the results of a coverage run.
+type Report struct {
+	Version   int        `json:"version"`             // Report format version
+	Overall   Coverage   `json:"overall"`             // Overall coverage
+	Packages  []Coverage `json:"packages"`            // Per-package coverage
+	Files     []Coverage `json:"files"`               // Per-file coverage
+	Functions []Function `json:"functions,omitempty"` // Per-function coverage, if requested
+	Threshold Threshold  `json:"threshold"`           // Result of checking the overall threshold
+	Rules     []Rule     `json:"rules,omitempty"`     // Results of checking the rules
+	Modules   []Module   `json:"modules,omitempty"`   // Per-module coverage of a workspace
+	Patch     *Patch     `json:"patch,omitempty"`     // Patch coverage, if requested
+	Update    *Update    `json:"update,omitempty"`    // Proposed threshold updates
+	Excluded  *Coverage  `json:"excluded,omitempty"`  // Coverage of the excluded files
+	Conflicts Conflicts  `json:"conflicts"`           // Ignored coverage data
+}
+
+// New constructs a Report containing the coverage data from a data
+// set.
+func New(ds common.DataSet) *Report {
+	return &Report{
+		Version:  Version,
+		Overall:  NewCoverage(ds.Sum()),
+		Packages: NewCoverageList(ds.Reduce()),
+		Files:    NewCoverageList(ds),
+		Conflicts: Conflicts{
+			Profiles: []string{},
+			Source:   []string{},
+		},
+	}
+}
+
+// WriteJSON writes the report to a stream as a JSON document.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// ReadJSON reads a report previously written by WriteJSON from a
+// stream.
+func ReadJSON(r io.Reader) (*Report, error) {
+	rep := &Report{}
+	if err := json.NewDecoder(r).Decode(rep); err != nil {
+		return nil, err
+	}
+	if rep.Version != Version {
+		return nil, fmt.Errorf("%w %d", ErrVersion, rep.Version)
+	}
+
+	return rep, nil
+}
+
+// DataSet reconstructs the data set from the per-file coverage of
+// the report.  The blocks of statements are not included in the
+// report, and so are not available.
+func (r *Report) DataSet() common.DataSet {
+	ds := make(common.DataSet, 0, len(r.Files))
+	for _, cov := range r.Files {
+		ds = append(ds, common.FileData{
+			Package: cov.Package,
+			Name:    cov.File,
+			Count:   cov.Statements,
+			Exec:    cov.Executed,
+		})
+	}
+
+	return ds
+}
@@ -0,0 +1,314 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/rules"
+)
+
+var testData = common.DataSet{
+	{Package: "pkg/b", Name: "file1.go", Count: 10, Exec: 5},
+	{Package: "pkg/a", Name: "file2.go", Count: 4, Exec: 4},
+	{Package: "pkg/a", Name: "file1.go", Count: 6, Exec: 0},
+}
+
+func TestNewCoverage(t *testing.T) {
+	result := NewCoverage(testData[0])
+
+	assert.Equal(t, Coverage{
+		Package:    "pkg/b",
+		File:       "file1.go",
+		Statements: 10,
+		Executed:   5,
+		Coverage:   50.0,
+	}, result)
+}
+
+func TestNewCoverageList(t *testing.T) {
+	result := NewCoverageList(testData)
+
+	assert.Equal(t, []Coverage{
+		{Package: "pkg/a", File: "file1.go", Statements: 6},
+		{Package: "pkg/a", File: "file2.go", Statements: 4, Executed: 4, Coverage: 100.0},
+		{Package: "pkg/b", File: "file1.go", Statements: 10, Executed: 5, Coverage: 50.0},
+	}, result)
+}
+
+func TestNewCoverageListEmpty(t *testing.T) {
+	result := NewCoverageList(nil)
+
+	assert.Equal(t, []Coverage{}, result)
+}
+
+func TestNewFunctions(t *testing.T) {
+	fs := common.FuncSet{
+		{Package: "pkg/a", File: "file1.go", Name: "F", Line: 3, Count: 4, Exec: 2},
+		{Package: "pkg/a", File: "file1.go", Name: "G", Line: 9, Count: 2},
+	}
+
+	result := NewFunctions(fs)
+
+	assert.Equal(t, []Function{
+		{Function: "G", Line: 9, Coverage: Coverage{Package: "pkg/a", File: "file1.go", Statements: 2}},
+		{Function: "F", Line: 3, Coverage: Coverage{Package: "pkg/a", File: "file1.go", Statements: 4, Executed: 2, Coverage: 50.0}},
+	}, result)
+	assert.Equal(t, "F", fs[0].Name)
+}
+
+func TestNewFunctionsEmpty(t *testing.T) {
+	result := NewFunctions(nil)
+
+	assert.Equal(t, []Function{}, result)
+}
+
+func TestNewThresholdPassed(t *testing.T) {
+	result := NewThreshold(80.0, 75.0)
+
+	assert.Equal(t, Threshold{Threshold: 75.0, Passed: true}, result)
+}
+
+func TestNewThresholdFailed(t *testing.T) {
+	result := NewThreshold(70.0, 75.0)
+
+	assert.Equal(t, Threshold{Threshold: 75.0, Passed: false}, result)
+}
+
+func TestNewThresholdUnset(t *testing.T) {
+	result := NewThreshold(0.0, 0.0)
+
+	assert.Equal(t, Threshold{Threshold: 0.0, Passed: true}, result)
+}
+
+func TestNewRules(t *testing.T) {
+	results := []rules.Result{
+		{
+			Rule:     rules.Rule{Pattern: "pkg/a", Threshold: 50.0},
+			Packages: common.DataSet{{Package: "pkg/a", Count: 10, Exec: 4}},
+		},
+		{
+			Rule:     rules.Rule{Pattern: "pkg/b", Threshold: 50.0},
+			Packages: common.DataSet{{Package: "pkg/b", Count: 10, Exec: 5}},
+		},
+	}
+
+	result := NewRules(results)
+
+	assert.Equal(t, []Rule{
+		{
+			Rule:   rules.Rule{Pattern: "pkg/a", Threshold: 50.0},
+			Passed: false,
+			Packages: []Coverage{
+				{Package: "pkg/a", Statements: 10, Executed: 4, Coverage: 40.0},
+			},
+		},
+		{
+			Rule:   rules.Rule{Pattern: "pkg/b", Threshold: 50.0},
+			Passed: true,
+			Packages: []Coverage{
+				{Package: "pkg/b", Statements: 10, Executed: 5, Coverage: 50.0},
+			},
+		},
+	}, result)
+}
+
+func TestNewRulesExported(t *testing.T) {
+	results := []rules.Result{
+		{
+			Rule:     rules.Rule{Pattern: "pkg/a", Type: rules.TypeExported},
+			Packages: common.DataSet{{Package: "pkg/a", Count: 10, Exec: 4}},
+			Functions: common.FuncSet{
+				{Package: "pkg/a", File: "a.go", Name: "F", Line: 3, Count: 4, Exec: 4, Exported: true},
+				{Package: "pkg/a", File: "a.go", Name: "G", Line: 9, Count: 2, Exported: true},
+			},
+		},
+	}
+
+	result := NewRules(results)
+
+	assert.Equal(t, []Rule{
+		{
+			Rule:   rules.Rule{Pattern: "pkg/a", Type: rules.TypeExported},
+			Passed: false,
+			Packages: []Coverage{
+				{Package: "pkg/a", Statements: 10, Executed: 4, Coverage: 40.0},
+			},
+			Functions: []RuleFunction{
+				{Function: Function{Function: "G", Line: 9, Coverage: Coverage{Package: "pkg/a", File: "a.go", Statements: 2}}, Passed: false},
+				{Function: Function{Function: "F", Line: 3, Coverage: Coverage{Package: "pkg/a", File: "a.go", Statements: 4, Executed: 4, Coverage: 100.0}}, Passed: true},
+			},
+		},
+	}, result)
+}
+
+func TestNewRulesEmpty(t *testing.T) {
+	result := NewRules(nil)
+
+	assert.Nil(t, result)
+}
+
+func TestNew(t *testing.T) {
+	result := New(testData)
+
+	assert.Equal(t, &Report{
+		Version: Version,
+		Overall: Coverage{Statements: 20, Executed: 9, Coverage: 45.0},
+		Packages: []Coverage{
+			{Package: "pkg/a", Statements: 10, Executed: 4, Coverage: 40.0},
+			{Package: "pkg/b", Statements: 10, Executed: 5, Coverage: 50.0},
+		},
+		Files: []Coverage{
+			{Package: "pkg/a", File: "file1.go", Statements: 6},
+			{Package: "pkg/a", File: "file2.go", Statements: 4, Executed: 4, Coverage: 100.0},
+			{Package: "pkg/b", File: "file1.go", Statements: 10, Executed: 5, Coverage: 50.0},
+		},
+		Conflicts: Conflicts{
+			Profiles: []string{},
+			Source:   []string{},
+		},
+	}, result)
+}
+
+func TestReportWriteJSON(t *testing.T) {
+	threshold := 40.0
+	obj := &Report{
+		Version: Version,
+		Overall: Coverage{Statements: 10, Executed: 4, Coverage: 40.0},
+		Packages: []Coverage{
+			{Package: "pkg/a", Statements: 10, Executed: 4, Coverage: 40.0},
+		},
+		Files: []Coverage{
+			{Package: "pkg/a", File: "file1.go", Statements: 10, Executed: 4, Coverage: 40.0},
+		},
+		Threshold: Threshold{Threshold: 30.0, Passed: true},
+		Patch: &Patch{
+			Base:      "main",
+			Coverage:  Coverage{Statements: 2, Executed: 1, Coverage: 50.0},
+			Threshold: Threshold{Passed: true},
+		},
+		Update: &Update{
+			Threshold: &threshold,
+		},
+		Excluded: &Coverage{Statements: 5, Executed: 1, Coverage: 20.0},
+		Conflicts: Conflicts{
+			Profiles: []string{},
+			Source:   []string{"pkg/a/file2.go"},
+		},
+	}
+	buf := &bytes.Buffer{}
+
+	err := obj.WriteJSON(buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{
+  "version": 1,
+  "overall": {
+    "statements": 10,
+    "executed": 4,
+    "coverage": 40
+  },
+  "packages": [
+    {
+      "package": "pkg/a",
+      "statements": 10,
+      "executed": 4,
+      "coverage": 40
+    }
+  ],
+  "files": [
+    {
+      "package": "pkg/a",
+      "file": "file1.go",
+      "statements": 10,
+      "executed": 4,
+      "coverage": 40
+    }
+  ],
+  "threshold": {
+    "threshold": 30,
+    "passed": true
+  },
+  "patch": {
+    "base": "main",
+    "statements": 2,
+    "executed": 1,
+    "coverage": 50,
+    "threshold": {
+      "threshold": 0,
+      "passed": true
+    }
+  },
+  "update": {
+    "threshold": 40
+  },
+  "excluded": {
+    "statements": 5,
+    "executed": 1,
+    "coverage": 20
+  },
+  "conflicts": {
+    "profiles": [],
+    "source": [
+      "pkg/a/file2.go"
+    ]
+  }
+}
+`, buf.String())
+}
+
+func TestReadJSON(t *testing.T) {
+	obj := New(testData)
+	buf := &bytes.Buffer{}
+	require.NoError(t, obj.WriteJSON(buf))
+
+	result, err := ReadJSON(buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, obj, result)
+}
+
+func TestReadJSONBadJSON(t *testing.T) {
+	result, err := ReadJSON(strings.NewReader("mode: set\n"))
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+}
+
+func TestReadJSONBadVersion(t *testing.T) {
+	result, err := ReadJSON(strings.NewReader(`{"version": 99}`))
+
+	assert.ErrorIs(t, err, ErrVersion)
+	assert.EqualError(t, err, "unsupported report version 99")
+	assert.Nil(t, result)
+}
+
+func TestReportDataSet(t *testing.T) {
+	obj := New(testData)
+
+	result := obj.DataSet()
+
+	assert.Equal(t, common.DataSet{
+		{Package: "pkg/a", Name: "file1.go", Count: 6, Exec: 0},
+		{Package: "pkg/a", Name: "file2.go", Count: 4, Exec: 4},
+		{Package: "pkg/b", Name: "file1.go", Count: 10, Exec: 5},
+	}, result)
+}
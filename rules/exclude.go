@@ -0,0 +1,66 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package rules
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/klmitch/overcover/common"
+)
+
+// Exclude removes the files matching any of the patterns from the
+// data set.  The patterns are the same as those of the rules, and are
+// matched against both the package import path and the handle of each
+// file, so that "example.com/mod/mocks/..." excludes a package while
+// "**/*_mock.go" excludes individual files.  Relative patterns are
+// resolved against the module path, which may be empty if no relative
+// patterns are used.  The remaining files and the excluded files are
+// returned.
+func Exclude(patterns []string, modPath string, ds common.DataSet) (common.DataSet, common.DataSet, error) {
+	// Compile the patterns
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := compile(pattern, modPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("exclude pattern %q: %w", pattern, err)
+		}
+		res = append(res, re)
+	}
+
+	// Partition the data set
+	var kept, excluded common.DataSet
+	for _, fd := range ds {
+		if excludes(res, fd) {
+			excluded = append(excluded, fd)
+		} else {
+			kept = append(kept, fd)
+		}
+	}
+
+	return kept, excluded, nil
+}
+
+// excludes reports whether any of the regular expressions matches
+// the package or the handle of a file.
+func excludes(res []*regexp.Regexp, fd common.FileData) bool {
+	for _, re := range res {
+		if re.MatchString(fd.Package) || re.MatchString(fd.Handle()) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package rules
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/klmitch/overcover/common"
+)
+
+func TestExcludeBase(t *testing.T) {
+	ds := common.DataSet{
+		{Package: "example.com/mod/a", Name: "a.go", Count: 4, Exec: 3},
+		{Package: "example.com/mod/a", Name: "a_mock.go", Count: 4},
+		{Package: "example.com/mod/mocks", Name: "mocks.go", Count: 4},
+		{Package: "example.com/mod/mocks/sub", Name: "sub.go", Count: 4},
+		{Package: "example.com/mod/b", Name: "b.go", Count: 4, Exec: 2},
+	}
+
+	kept, excluded, err := Exclude([]string{"./mocks/...", "**/*_mock.go"}, "example.com/mod", ds)
+
+	assert.NoError(t, err)
+	assert.Equal(t, common.DataSet{
+		{Package: "example.com/mod/a", Name: "a.go", Count: 4, Exec: 3},
+		{Package: "example.com/mod/b", Name: "b.go", Count: 4, Exec: 2},
+	}, kept)
+	assert.Equal(t, common.DataSet{
+		{Package: "example.com/mod/a", Name: "a_mock.go", Count: 4},
+		{Package: "example.com/mod/mocks", Name: "mocks.go", Count: 4},
+		{Package: "example.com/mod/mocks/sub", Name: "sub.go", Count: 4},
+	}, excluded)
+}
+
+func TestExcludeNoPatterns(t *testing.T) {
+	ds := common.DataSet{
+		{Package: "example.com/mod/a", Name: "a.go", Count: 4, Exec: 3},
+	}
+
+	kept, excluded, err := Exclude(nil, "", ds)
+
+	assert.NoError(t, err)
+	assert.Equal(t, ds, kept)
+	assert.Nil(t, excluded)
+}
+
+func TestExcludeBadPattern(t *testing.T) {
+	ds := common.DataSet{
+		{Package: "example.com/mod/a", Name: "a.go", Count: 4, Exec: 3},
+	}
+
+	kept, excluded, err := Exclude([]string{"./mocks/..."}, "", ds)
+
+	assert.ErrorIs(t, err, ErrRelativePattern)
+	assert.EqualError(t, err, `exclude pattern "./mocks/...": relative pattern requires a go.mod file`)
+	assert.Nil(t, kept)
+	assert.Nil(t, excluded)
+}
+
+func TestExcludesPackage(t *testing.T) {
+	res := []*regexp.Regexp{regexp.MustCompile(`^other$`), regexp.MustCompile(`^pkg$`)}
+
+	result := excludes(res, common.FileData{Package: "pkg", Name: "file.go"})
+
+	assert.True(t, result)
+}
+
+func TestExcludesHandle(t *testing.T) {
+	res := []*regexp.Regexp{regexp.MustCompile(`^pkg/file\.go$`)}
+
+	result := excludes(res, common.FileData{Package: "pkg", Name: "file.go"})
+
+	assert.True(t, result)
+}
+
+func TestExcludesNoMatch(t *testing.T) {
+	res := []*regexp.Regexp{regexp.MustCompile(`^other$`)}
+
+	result := excludes(res, common.FileData{Package: "pkg", Name: "file.go"})
+
+	assert.False(t, result)
+}
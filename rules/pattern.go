@@ -0,0 +1,82 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package rules
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Errors that may be returned while compiling patterns.
+var (
+	ErrEmptyPattern    = errors.New("empty pattern")
+	ErrRelativePattern = errors.New("relative pattern requires a go.mod file")
+)
+
+// compile compiles a package pattern into a regular expression.
+// Patterns beginning with "./" are interpreted relative to the
+// module path, which must not be empty.  Within the pattern, "..."
+// and "**" match any string, including the empty string and "/";
+// "*" and "?" match any string or any single character,
+// respectively, other than "/".  As with the go tool, a trailing
+// "/..." (or "/**") also matches the package itself, and a leading
+// "**/" matches any number of leading path elements, including none.
+func compile(pattern, modPath string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, ErrEmptyPattern
+	}
+
+	// Resolve relative patterns
+	if pattern == "." || strings.HasPrefix(pattern, "./") {
+		if modPath == "" {
+			return nil, ErrRelativePattern
+		}
+		pattern = modPath + pattern[1:]
+	}
+
+	// Translate the pattern into a regular expression
+	buf := &strings.Builder{}
+	buf.WriteString("^")
+	for i := 0; i < len(pattern); {
+		rest := pattern[i:]
+		switch {
+		case strings.HasPrefix(rest, "**/") && (i == 0 || pattern[i-1] == '/'):
+			buf.WriteString("(.*/)?")
+			i += 3
+		case rest == "/**" || rest == "/...":
+			buf.WriteString("(/.*)?")
+			i = len(pattern)
+		case strings.HasPrefix(rest, "**"):
+			buf.WriteString(".*")
+			i += 2
+		case strings.HasPrefix(rest, "..."):
+			buf.WriteString(".*")
+			i += 3
+		case rest[0] == '*':
+			buf.WriteString("[^/]*")
+			i++
+		case rest[0] == '?':
+			buf.WriteString("[^/]")
+			i++
+		default:
+			buf.WriteString(regexp.QuoteMeta(rest[:1]))
+			i++
+		}
+	}
+	buf.WriteString("$")
+
+	return regexp.MustCompile(buf.String()), nil
+}
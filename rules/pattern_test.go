@@ -0,0 +1,148 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func matches(t *testing.T, pattern, modPath string, candidates ...string) []bool {
+	re, err := compile(pattern, modPath)
+	require.NoError(t, err)
+
+	result := []bool{}
+	for _, cand := range candidates {
+		result = append(result, re.MatchString(cand))
+	}
+
+	return result
+}
+
+func TestCompileLiteral(t *testing.T) {
+	result := matches(t, "example.com/mod/pkg", "",
+		"example.com/mod/pkg",
+		"example.com/mod/pkg/sub",
+		"example.com/mod/pkgs",
+		"exampleXcom/mod/pkg",
+	)
+
+	assert.Equal(t, []bool{true, false, false, false}, result)
+}
+
+func TestCompileRelative(t *testing.T) {
+	result := matches(t, "./internal/...", "example.com/mod",
+		"example.com/mod/internal",
+		"example.com/mod/internal/auth",
+		"example.com/mod/internals",
+		"example.com/mod",
+	)
+
+	assert.Equal(t, []bool{true, true, false, false}, result)
+}
+
+func TestCompileRelativeRoot(t *testing.T) {
+	result := matches(t, ".", "example.com/mod",
+		"example.com/mod",
+		"example.com/mod/internal",
+	)
+
+	assert.Equal(t, []bool{true, false}, result)
+}
+
+func TestCompileRelativeNoModule(t *testing.T) {
+	result, err := compile("./internal/...", "")
+
+	assert.Same(t, ErrRelativePattern, err)
+	assert.Nil(t, result)
+}
+
+func TestCompileEmpty(t *testing.T) {
+	result, err := compile("", "example.com/mod")
+
+	assert.Same(t, ErrEmptyPattern, err)
+	assert.Nil(t, result)
+}
+
+func TestCompileLeadingDoubleStar(t *testing.T) {
+	result := matches(t, "**/handlers", "",
+		"handlers",
+		"example.com/mod/handlers",
+		"example.com/mod/handlers/sub",
+		"example.com/mod/myhandlers",
+	)
+
+	assert.Equal(t, []bool{true, true, false, false}, result)
+}
+
+func TestCompileMiddleDoubleStar(t *testing.T) {
+	result := matches(t, "example.com/**/gen", "",
+		"example.com/gen",
+		"example.com/mod/api/gen",
+		"example.com/mod/api/gen/sub",
+	)
+
+	assert.Equal(t, []bool{true, true, false}, result)
+}
+
+func TestCompileTrailingDoubleStar(t *testing.T) {
+	result := matches(t, "example.com/mod/**", "",
+		"example.com/mod",
+		"example.com/mod/a/b",
+		"example.com/modx",
+	)
+
+	assert.Equal(t, []bool{true, true, false}, result)
+}
+
+func TestCompileEmbeddedDoubleStar(t *testing.T) {
+	result := matches(t, "example.com/mod/gen**", "",
+		"example.com/mod/gen",
+		"example.com/mod/generated/sub",
+		"example.com/mod/other",
+	)
+
+	assert.Equal(t, []bool{true, true, false}, result)
+}
+
+func TestCompileEmbeddedDots(t *testing.T) {
+	result := matches(t, "example.com/.../gen", "",
+		"example.com/mod/api/gen",
+		"example.com/gen",
+	)
+
+	assert.Equal(t, []bool{true, false}, result)
+}
+
+func TestCompileStar(t *testing.T) {
+	result := matches(t, "example.com/*/pkg", "",
+		"example.com/mod/pkg",
+		"example.com/a/b/pkg",
+	)
+
+	assert.Equal(t, []bool{true, false}, result)
+}
+
+func TestCompileQuestion(t *testing.T) {
+	result := matches(t, "example.com/mod/v?", "",
+		"example.com/mod/v2",
+		"example.com/mod/v",
+		"example.com/mod/v/",
+	)
+
+	assert.Equal(t, []bool{true, false, false}, result)
+}
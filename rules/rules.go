@@ -0,0 +1,181 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/klmitch/overcover/common"
+)
+
+// The types of rule.  The threshold of a package rule applies to each
+// package matching its pattern; that of an exported rule applies to
+// each exported function and method in those packages, each of which
+// must also have at least one executed statement.
+const (
+	TypePackage  = "package"  // Threshold applies to each package
+	TypeExported = "exported" // Threshold applies to each exported function
+)
+
+// ErrBadType is returned by Evaluate if a rule has an unknown type.
+var ErrBadType = errors.New("unknown rule type")
+
+// Rule describes a coverage threshold which applies to each of the
+// packages matching a pattern, or to each of the exported functions
+// in those packages.  A rule with no type is a package rule.
+type Rule struct {
+	Pattern   string  `mapstructure:"pattern" yaml:"pattern" json:"pattern"`            // Pattern matching package import paths
+	Threshold float64 `mapstructure:"threshold" yaml:"threshold" json:"threshold"`      // Minimum coverage of each package or function
+	Type      string  `mapstructure:"type" yaml:"type,omitempty" json:"type,omitempty"` // Type of the rule
+}
+
+// Exported reports whether the rule applies to exported functions.
+func (r Rule) Exported() bool {
+	return r.Type == TypeExported
+}
+
+// FunctionPassed reports whether a function meets the rule's
+// threshold.  A function must also have at least one executed
+// statement, even if the threshold is zero.
+func (r Rule) FunctionPassed(fd common.FuncData) bool {
+	return fd.Exec > 0 && fd.Coverage()*100.0 >= r.Threshold
+}
+
+// Result describes the packages matched by a rule.
+type Result struct {
+	Rule      Rule           // The rule
+	Packages  common.DataSet // Per-package data for the matched packages
+	Functions common.FuncSet // Exported functions in the matched packages, for exported rules
+}
+
+// Failures returns the list of packages matched by a package rule
+// that do not meet the rule's threshold.
+func (r Result) Failures() common.DataSet {
+	if r.Rule.Exported() {
+		return nil
+	}
+
+	var result common.DataSet
+	for _, fd := range r.Packages {
+		if fd.Coverage()*100.0 < r.Rule.Threshold {
+			result = append(result, fd)
+		}
+	}
+
+	return result
+}
+
+// FunctionFailures returns the list of exported functions matched by
+// an exported rule that do not meet the rule's threshold.
+func (r Result) FunctionFailures() common.FuncSet {
+	var result common.FuncSet
+	for _, fd := range r.Functions {
+		if !r.Rule.FunctionPassed(fd) {
+			result = append(result, fd)
+		}
+	}
+
+	return result
+}
+
+// Coverage returns the coverage, as a percentage, of the least
+// covered package matched by the rule, or of the least covered
+// exported function for an exported rule.  The boolean result is
+// false if the rule matched no packages or functions.
+func (r Result) Coverage() (float64, bool) {
+	var coverages []float64
+	if r.Rule.Exported() {
+		for _, fd := range r.Functions {
+			coverages = append(coverages, fd.Coverage()*100.0)
+		}
+	} else {
+		for _, fd := range r.Packages {
+			coverages = append(coverages, fd.Coverage()*100.0)
+		}
+	}
+	if len(coverages) == 0 {
+		return 0.0, false
+	}
+
+	result := math.Inf(1)
+	for _, coverage := range coverages {
+		result = math.Min(result, coverage)
+	}
+
+	return result, true
+}
+
+// Ratchet computes a new threshold for the rule.  If the coverage of
+// every package matched by the rule exceeds the threshold plus the
+// maximum headroom, the new threshold is the coverage of the least
+// covered package minus the minimum headroom, and the boolean result
+// is true.
+func (r Result) Ratchet(minHeadroom, maxHeadroom float64) (float64, bool) {
+	coverage, ok := r.Coverage()
+	if !ok || coverage <= r.Rule.Threshold+maxHeadroom {
+		return r.Rule.Threshold, false
+	}
+
+	return math.Round(coverage*10.0)/10.0 - minHeadroom, true
+}
+
+// Evaluate matches each of the rules against the packages in the
+// data set.  The data set is reduced to per-package data, and a
+// Result is returned for each rule, in the same order as the rules.
+// The exported functions in the function set are also matched against
+// exported rules; the function set is only needed if there are any.
+// Relative patterns are resolved against the module path, which may
+// be empty if no relative patterns are used.
+func Evaluate(rules []Rule, modPath string, ds common.DataSet, fs common.FuncSet) ([]Result, error) {
+	// Reduce the data set to packages and sort it by package
+	pkgs := ds.Reduce()
+	sort.SliceStable(pkgs, func(i, j int) bool {
+		return pkgs[i].Package < pkgs[j].Package
+	})
+
+	// Evaluate each rule
+	results := make([]Result, 0, len(rules))
+	for _, rule := range rules {
+		switch rule.Type {
+		case "", TypePackage, TypeExported:
+		default:
+			return nil, fmt.Errorf("rule %q: %w %q", rule.Pattern, ErrBadType, rule.Type)
+		}
+		re, err := compile(rule.Pattern, modPath)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rule.Pattern, err)
+		}
+
+		result := Result{Rule: rule}
+		for _, fd := range pkgs {
+			if re.MatchString(fd.Package) {
+				result.Packages = append(result.Packages, fd)
+			}
+		}
+		if rule.Exported() {
+			for _, fd := range fs {
+				if fd.Exported && re.MatchString(fd.Package) {
+					result.Functions = append(result.Functions, fd)
+				}
+			}
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
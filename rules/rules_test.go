@@ -0,0 +1,302 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/klmitch/overcover/common"
+)
+
+func TestRuleExported(t *testing.T) {
+	assert.False(t, Rule{}.Exported())
+	assert.False(t, Rule{Type: TypePackage}.Exported())
+	assert.True(t, Rule{Type: TypeExported}.Exported())
+}
+
+func TestRuleFunctionPassed(t *testing.T) {
+	obj := Rule{Pattern: "./...", Type: TypeExported, Threshold: 50.0}
+
+	assert.True(t, obj.FunctionPassed(common.FuncData{Count: 4, Exec: 2}))
+	assert.False(t, obj.FunctionPassed(common.FuncData{Count: 4, Exec: 1}))
+}
+
+func TestRuleFunctionPassedNoThreshold(t *testing.T) {
+	obj := Rule{Pattern: "./...", Type: TypeExported}
+
+	assert.True(t, obj.FunctionPassed(common.FuncData{Count: 4, Exec: 1}))
+	assert.False(t, obj.FunctionPassed(common.FuncData{Count: 4}))
+}
+
+func TestResultFailures(t *testing.T) {
+	obj := Result{
+		Rule: Rule{Pattern: "./...", Threshold: 75.0},
+		Packages: common.DataSet{
+			{Package: "example.com/mod/a", Count: 4, Exec: 3},
+			{Package: "example.com/mod/b", Count: 4, Exec: 2},
+			{Package: "example.com/mod/c", Count: 4, Exec: 4},
+			{Package: "example.com/mod/d", Count: 0, Exec: 0},
+		},
+	}
+
+	result := obj.Failures()
+
+	assert.Equal(t, common.DataSet{
+		{Package: "example.com/mod/b", Count: 4, Exec: 2},
+	}, result)
+}
+
+func TestResultFailuresNone(t *testing.T) {
+	obj := Result{
+		Rule: Rule{Pattern: "./...", Threshold: 75.0},
+		Packages: common.DataSet{
+			{Package: "example.com/mod/a", Count: 4, Exec: 3},
+		},
+	}
+
+	result := obj.Failures()
+
+	assert.Nil(t, result)
+}
+
+func TestResultFailuresExported(t *testing.T) {
+	obj := Result{
+		Rule: Rule{Pattern: "./...", Threshold: 75.0, Type: TypeExported},
+		Packages: common.DataSet{
+			{Package: "example.com/mod/b", Count: 4, Exec: 2},
+		},
+	}
+
+	result := obj.Failures()
+
+	assert.Nil(t, result)
+}
+
+func TestResultFunctionFailures(t *testing.T) {
+	obj := Result{
+		Rule: Rule{Pattern: "./...", Threshold: 50.0, Type: TypeExported},
+		Functions: common.FuncSet{
+			{Package: "example.com/mod/a", Name: "A", Count: 4, Exec: 2, Exported: true},
+			{Package: "example.com/mod/a", Name: "B", Count: 4, Exec: 1, Exported: true},
+			{Package: "example.com/mod/a", Name: "C", Count: 4, Exported: true},
+		},
+	}
+
+	result := obj.FunctionFailures()
+
+	assert.Equal(t, common.FuncSet{
+		{Package: "example.com/mod/a", Name: "B", Count: 4, Exec: 1, Exported: true},
+		{Package: "example.com/mod/a", Name: "C", Count: 4, Exported: true},
+	}, result)
+}
+
+func TestResultCoverage(t *testing.T) {
+	obj := Result{
+		Rule: Rule{Pattern: "./...", Threshold: 75.0},
+		Packages: common.DataSet{
+			{Package: "example.com/mod/a", Count: 4, Exec: 3},
+			{Package: "example.com/mod/b", Count: 4, Exec: 2},
+			{Package: "example.com/mod/c", Count: 4, Exec: 4},
+		},
+	}
+
+	result, ok := obj.Coverage()
+
+	assert.True(t, ok)
+	assert.Equal(t, 50.0, result)
+}
+
+func TestResultCoverageNoPackages(t *testing.T) {
+	obj := Result{
+		Rule: Rule{Pattern: "./...", Threshold: 75.0},
+	}
+
+	result, ok := obj.Coverage()
+
+	assert.False(t, ok)
+	assert.Equal(t, 0.0, result)
+}
+
+func TestResultCoverageExported(t *testing.T) {
+	obj := Result{
+		Rule: Rule{Pattern: "./...", Threshold: 75.0, Type: TypeExported},
+		Packages: common.DataSet{
+			{Package: "example.com/mod/a", Count: 4, Exec: 1},
+		},
+		Functions: common.FuncSet{
+			{Package: "example.com/mod/a", Name: "A", Count: 4, Exec: 3, Exported: true},
+			{Package: "example.com/mod/a", Name: "B", Count: 2, Exec: 1, Exported: true},
+		},
+	}
+
+	result, ok := obj.Coverage()
+
+	assert.True(t, ok)
+	assert.Equal(t, 50.0, result)
+}
+
+func TestResultCoverageExportedNoFunctions(t *testing.T) {
+	obj := Result{
+		Rule: Rule{Pattern: "./...", Threshold: 75.0, Type: TypeExported},
+		Packages: common.DataSet{
+			{Package: "example.com/mod/a", Count: 4, Exec: 1},
+		},
+	}
+
+	result, ok := obj.Coverage()
+
+	assert.False(t, ok)
+	assert.Equal(t, 0.0, result)
+}
+
+func TestResultRatchetNeeded(t *testing.T) {
+	obj := Result{
+		Rule: Rule{Pattern: "./...", Threshold: 75.0},
+		Packages: common.DataSet{
+			{Package: "example.com/mod/a", Count: 3, Exec: 3},
+			{Package: "example.com/mod/b", Count: 6, Exec: 5},
+		},
+	}
+
+	result, ok := obj.Ratchet(1.0, 2.0)
+
+	assert.True(t, ok)
+	assert.InDelta(t, 82.3, result, 0.0001)
+}
+
+func TestResultRatchetUnneeded(t *testing.T) {
+	obj := Result{
+		Rule: Rule{Pattern: "./...", Threshold: 75.0},
+		Packages: common.DataSet{
+			{Package: "example.com/mod/a", Count: 3, Exec: 3},
+			{Package: "example.com/mod/b", Count: 100, Exec: 77},
+		},
+	}
+
+	result, ok := obj.Ratchet(1.0, 2.0)
+
+	assert.False(t, ok)
+	assert.Equal(t, 75.0, result)
+}
+
+func TestResultRatchetNoPackages(t *testing.T) {
+	obj := Result{
+		Rule: Rule{Pattern: "./...", Threshold: 75.0},
+	}
+
+	result, ok := obj.Ratchet(1.0, 2.0)
+
+	assert.False(t, ok)
+	assert.Equal(t, 75.0, result)
+}
+
+func TestEvaluateBase(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "./internal/...", Threshold: 90.0},
+		{Pattern: "**/gen", Threshold: 40.0},
+		{Pattern: "example.com/other/...", Threshold: 50.0},
+	}
+	ds := common.DataSet{
+		{Package: "example.com/mod/internal/gen", Name: "b.go", Count: 2, Exec: 1},
+		{Package: "example.com/mod/internal/auth", Name: "a.go", Count: 5, Exec: 4},
+		{Package: "example.com/mod/internal/gen", Name: "a.go", Count: 2, Exec: 0},
+		{Package: "example.com/mod/api", Name: "a.go", Count: 5, Exec: 5},
+	}
+
+	result, err := Evaluate(rules, "example.com/mod", ds, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Result{
+		{
+			Rule: rules[0],
+			Packages: common.DataSet{
+				{Package: "example.com/mod/internal/auth", Count: 5, Exec: 4},
+				{Package: "example.com/mod/internal/gen", Count: 4, Exec: 1},
+			},
+		},
+		{
+			Rule: rules[1],
+			Packages: common.DataSet{
+				{Package: "example.com/mod/internal/gen", Count: 4, Exec: 1},
+			},
+		},
+		{
+			Rule: rules[2],
+		},
+	}, result)
+}
+
+func TestEvaluateExported(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "./api/...", Threshold: 50.0, Type: TypeExported},
+		{Pattern: "./api/...", Threshold: 50.0, Type: TypePackage},
+	}
+	ds := common.DataSet{
+		{Package: "example.com/mod/api", Name: "a.go", Count: 5, Exec: 4},
+		{Package: "example.com/mod/internal/auth", Name: "a.go", Count: 5, Exec: 4},
+	}
+	fs := common.FuncSet{
+		{Package: "example.com/mod/api", File: "a.go", Name: "New", Count: 2, Exec: 2, Exported: true},
+		{Package: "example.com/mod/api", File: "a.go", Name: "helper", Count: 1},
+		{Package: "example.com/mod/internal/auth", File: "a.go", Name: "Check", Count: 2, Exec: 2, Exported: true},
+	}
+
+	result, err := Evaluate(rules, "example.com/mod", ds, fs)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Result{
+		{
+			Rule: rules[0],
+			Packages: common.DataSet{
+				{Package: "example.com/mod/api", Count: 5, Exec: 4},
+			},
+			Functions: common.FuncSet{
+				{Package: "example.com/mod/api", File: "a.go", Name: "New", Count: 2, Exec: 2, Exported: true},
+			},
+		},
+		{
+			Rule: rules[1],
+			Packages: common.DataSet{
+				{Package: "example.com/mod/api", Count: 5, Exec: 4},
+			},
+		},
+	}, result)
+}
+
+func TestEvaluateBadType(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "./...", Threshold: 90.0, Type: "bogus"},
+	}
+
+	result, err := Evaluate(rules, "example.com/mod", common.DataSet{}, nil)
+
+	assert.ErrorIs(t, err, ErrBadType)
+	assert.Equal(t, `rule "./...": unknown rule type "bogus"`, err.Error())
+	assert.Nil(t, result)
+}
+
+func TestEvaluateBadPattern(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "./internal/...", Threshold: 90.0},
+	}
+
+	result, err := Evaluate(rules, "", common.DataSet{}, nil)
+
+	assert.ErrorIs(t, err, ErrRelativePattern)
+	assert.Equal(t, `rule "./internal/...": `+ErrRelativePattern.Error(), err.Error())
+	assert.Nil(t, result)
+}
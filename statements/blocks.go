@@ -0,0 +1,472 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package statements
+
+import (
+	"bytes"
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"sort"
+
+	"github.com/klmitch/overcover/common"
+)
+
+// Patch points for top-level functions called by functions in this
+// file.
+var walk = ast.Walk
+
+// codeRange describes a contiguous range of executable code within a
+// basic block.
+type codeRange struct {
+	pos token.Pos // Start of the range
+	end token.Pos // End of the range
+}
+
+// blockVisitor is a type implementing the ast.Visitor interface.
+// This implementation computes the coverage blocks of a source file,
+// including the number of statements in each, in exactly the same
+// fashion as the cover tool instruments the file.  Each basic block
+// of statements becomes one or more coverage blocks, split at lines
+// containing only comments or white space; as with the cover tool,
+// each coverage block counts all the statements in its basic block.
+type blockVisitor struct {
+	fset    *token.FileSet  // The file set
+	content []byte          // The content of the file
+	seen    map[[4]int]bool // Positions of blocks already added
+	blocks  []common.Block  // The coverage blocks
+}
+
+// fileBlocks computes the coverage blocks of a source file, given its
+// syntax tree and content.  The blocks are sorted by position.
+func fileBlocks(fset *token.FileSet, file *ast.File, content []byte) []common.Block {
+	v := &blockVisitor{
+		fset:    fset,
+		content: content,
+		seen:    map[[4]int]bool{},
+	}
+	walk(v, file)
+	sort.SliceStable(v.blocks, func(i, j int) bool {
+		if v.blocks[i].StartLine != v.blocks[j].StartLine {
+			return v.blocks[i].StartLine < v.blocks[j].StartLine
+		}
+		return v.blocks[i].StartCol < v.blocks[j].StartCol
+	})
+
+	return v.blocks
+}
+
+// offset translates a position into a byte offset within the file.
+func (v *blockVisitor) offset(pos token.Pos) int {
+	return v.fset.PositionFor(pos, false).Offset
+}
+
+// Visit implements the ast.Visitor interface for blockVisitor.
+func (v *blockVisitor) Visit(node ast.Node) ast.Visitor {
+	switch n := node.(type) {
+	case *ast.BlockStmt:
+		// The body of a switch or select is a list of clauses
+		if len(n.List) > 0 {
+			switch n.List[0].(type) {
+			case *ast.CaseClause:
+				for _, stmt := range n.List {
+					clause := stmt.(*ast.CaseClause)
+					v.addBlocks(clause.Colon+1, clause.Colon+1, clause.End(), clause.Body, false)
+				}
+				return v
+			case *ast.CommClause:
+				for _, stmt := range n.List {
+					clause := stmt.(*ast.CommClause)
+					v.addBlocks(clause.Colon+1, clause.Colon+1, clause.End(), clause.Body, false)
+				}
+				return v
+			}
+		}
+		v.addBlocks(n.Lbrace, n.Lbrace+1, n.Rbrace+1, n.List, true)
+
+	case *ast.IfStmt:
+		if n.Init != nil {
+			walk(v, n.Init)
+		}
+		walk(v, n.Cond)
+		walk(v, n.Body)
+		if n.Else != nil {
+			v.visitElse(n)
+		}
+		return nil
+
+	case *ast.SelectStmt:
+		// Empty selects are not instrumented
+		if n.Body == nil || len(n.Body.List) == 0 {
+			return nil
+		}
+
+	case *ast.SwitchStmt:
+		// Empty switches are not instrumented
+		if n.Body == nil || len(n.Body.List) == 0 {
+			if n.Init != nil {
+				walk(v, n.Init)
+			}
+			if n.Tag != nil {
+				walk(v, n.Tag)
+			}
+			return nil
+		}
+
+	case *ast.TypeSwitchStmt:
+		// Empty type switches are not instrumented
+		if n.Body == nil || len(n.Body.List) == 0 {
+			if n.Init != nil {
+				walk(v, n.Init)
+			}
+			walk(v, n.Assign)
+			return nil
+		}
+
+	case *ast.FuncDecl:
+		// Functions with blank names or without bodies cannot be
+		// executed
+		if n.Name.Name == "_" || n.Body == nil {
+			return nil
+		}
+		walk(v, n.Body)
+		return nil
+	}
+
+	return v
+}
+
+// visitElse visits the else branch of an if statement.  The cover
+// tool wraps an "else if" in a block beginning just after the "else",
+// so that the inner if statement is covered, and likewise treats the
+// block of a plain "else" as beginning just after the "else".
+func (v *blockVisitor) visitElse(n *ast.IfStmt) {
+	elseOffset := v.findText(n.Body.End(), "else")
+	pos := v.fset.File(n.Body.End()).Pos(elseOffset + 4)
+	switch stmt := n.Else.(type) {
+	case *ast.IfStmt:
+		v.addBlocks(pos, pos+1, stmt.End()+1, []ast.Stmt{stmt}, true)
+		walk(v, stmt)
+
+	case *ast.BlockStmt:
+		v.addBlocks(pos, pos+1, stmt.Rbrace+1, stmt.List, true)
+		for _, s := range stmt.List {
+			walk(v, s)
+		}
+	}
+}
+
+// findText finds text in the source, starting at a position and
+// skipping comments.  It returns the byte offset of the text, or -1
+// if it is not found.
+func (v *blockVisitor) findText(pos token.Pos, text string) int {
+	b := []byte(text)
+	s := v.content
+	for i := v.offset(pos); i < len(s); {
+		switch {
+		case bytes.HasPrefix(s[i:], b):
+			return i
+
+		case bytes.HasPrefix(s[i:], []byte("//")):
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+
+		case bytes.HasPrefix(s[i:], []byte("/*")):
+			end := bytes.Index(s[i+2:], []byte("*/"))
+			if end < 0 {
+				return -1
+			}
+			i += end + 4
+
+		default:
+			i++
+		}
+	}
+
+	return -1
+}
+
+// addBlock adds a coverage block.  As with the cover tool, a block
+// with the same position as one already added has its end column
+// advanced until it is unique.
+func (v *blockVisitor) addBlock(start, end token.Pos, numStmt int) {
+	startPos := v.fset.PositionFor(start, false)
+	endPos := v.fset.PositionFor(end, false)
+	key := [4]int{startPos.Line, startPos.Column, endPos.Line, endPos.Column}
+	for v.seen[key] {
+		key[3]++
+	}
+	v.seen[key] = true
+
+	v.blocks = append(v.blocks, common.Block{
+		StartLine: key[0],
+		StartCol:  key[1],
+		EndLine:   key[2],
+		EndCol:    key[3],
+		NumStmt:   numStmt,
+	})
+}
+
+// addBlocks adds the coverage blocks for each basic block at the top
+// level of a list of statements.  The blocks of nested statements
+// are added as those statements are visited.
+func (v *blockVisitor) addBlocks(pos, insertPos, blockEnd token.Pos, list []ast.Stmt, extendToClosingBrace bool) {
+	// An empty list still gets a block
+	if len(list) == 0 {
+		r := v.codeRanges(insertPos, blockEnd)[0]
+		v.addBlock(r.pos, r.end, 0)
+		return
+	}
+
+	// Split the list into basic blocks
+	list = append([]ast.Stmt(nil), list...)
+	for {
+		// Find the statement ending the basic block
+		var last int
+		end := blockEnd
+		for last = 0; last < len(list); last++ {
+			stmt := list[last]
+			end = statementBoundary(stmt)
+			if endsBasicSourceBlock(stmt) {
+				// A label may be the target of a goto, so
+				// it ends the basic block, unless it labels
+				// a control statement
+				if label, isLabel := stmt.(*ast.LabeledStmt); isLabel && !isControl(label.Stmt) {
+					newLabel := *label
+					newLabel.Stmt = &ast.EmptyStmt{
+						Semicolon: label.Stmt.Pos(),
+						Implicit:  true,
+					}
+					end = label.Pos()
+					list[last] = &newLabel
+					list = append(list, nil)
+					copy(list[last+1:], list[last:])
+					list[last+1] = label.Stmt
+				}
+				last++
+				extendToClosingBrace = false
+				break
+			}
+		}
+		if extendToClosingBrace {
+			end = blockEnd
+		}
+
+		// Add blocks for the ranges containing code
+		if pos != end {
+			for _, r := range mergeRangesWithinStatements(v.codeRanges(pos, end), list[:last]) {
+				v.addBlock(r.pos, r.end, last)
+			}
+		}
+		list = list[last:]
+		if len(list) == 0 {
+			break
+		}
+		pos = list[0].Pos()
+	}
+}
+
+// codeRanges splits a range of the source into the sub-ranges
+// containing code, excluding lines containing only comments, white
+// space, or braces.  If there is no code, a single empty range at the
+// start is returned.
+func (v *blockVisitor) codeRanges(start, end token.Pos) []codeRange {
+	startOffset := v.offset(start)
+	src := v.content[startOffset:v.offset(end)]
+	origFile := v.fset.File(start)
+	scanFile := token.NewFileSet().AddFile("", -1, len(src))
+
+	var s scanner.Scanner
+	s.Init(scanFile, src, nil, 0)
+
+	// Scan the tokens, looking for gaps between lines of code
+	var ranges []codeRange
+	var codeStart token.Pos
+	prevEndLine := 0
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.LBRACE || tok == token.RBRACE || (tok == token.SEMICOLON && lit == "\n") {
+			continue
+		}
+
+		// Only string literals can span lines
+		startLine := scanFile.PositionFor(pos, false).Line
+		endLine := startLine
+		if tok == token.STRING {
+			endLine = scanFile.PositionFor(pos+token.Pos(len(lit)), false).Line
+		}
+
+		if prevEndLine == 0 {
+			codeStart = origFile.Pos(startOffset + scanFile.Offset(pos))
+		} else if startLine > prevEndLine+1 {
+			codeEnd := origFile.Pos(startOffset + scanFile.Offset(scanFile.LineStart(prevEndLine+1)))
+			ranges = append(ranges, codeRange{pos: codeStart, end: codeEnd})
+			codeStart = origFile.Pos(startOffset + scanFile.Offset(pos))
+		}
+		if endLine > prevEndLine {
+			prevEndLine = endLine
+		}
+	}
+
+	// Close the last range
+	switch {
+	case prevEndLine == 0:
+		return []codeRange{{pos: start, end: start}}
+
+	case prevEndLine < scanFile.LineCount():
+		codeEnd := origFile.Pos(startOffset + scanFile.Offset(scanFile.LineStart(prevEndLine+1)))
+		ranges = append(ranges, codeRange{pos: codeStart, end: codeEnd})
+
+	default:
+		ranges = append(ranges, codeRange{pos: codeStart, end: end})
+	}
+
+	return ranges
+}
+
+// insideStatement reports whether a position falls strictly inside a
+// statement in a list of statements sorted by position.
+func insideStatement(pos token.Pos, stmts []ast.Stmt) bool {
+	i := sort.Search(len(stmts), func(i int) bool {
+		return stmts[i].Pos() >= pos
+	})
+	return i > 0 && pos < stmts[i-1].End()
+}
+
+// mergeRangesWithinStatements merges a range with the preceding range
+// if it starts inside a statement, such as a multi-line const
+// declaration.
+func mergeRangesWithinStatements(ranges []codeRange, stmts []ast.Stmt) []codeRange {
+	merged := []codeRange{ranges[0]}
+	for _, r := range ranges[1:] {
+		if insideStatement(r.pos, stmts) {
+			merged[len(merged)-1].end = r.end
+		} else {
+			merged = append(merged, r)
+		}
+	}
+
+	return merged
+}
+
+// funcLitFinder is a type implementing the ast.Visitor interface.
+// This implementation locates the first function literal in a
+// subtree, recording the position of its body.
+type funcLitFinder token.Pos
+
+// Visit implements the ast.Visitor interface for funcLitFinder.
+func (f *funcLitFinder) Visit(node ast.Node) ast.Visitor {
+	if token.Pos(*f) != token.NoPos {
+		return nil
+	}
+	if n, ok := node.(*ast.FuncLit); ok {
+		*f = funcLitFinder(n.Body.Lbrace)
+		return nil
+	}
+
+	return f
+}
+
+// hasFuncLiteral reports whether a node contains a function literal,
+// and the position of the body of the first one.
+func hasFuncLiteral(n ast.Node) (bool, token.Pos) {
+	if n == nil {
+		return false, token.NoPos
+	}
+
+	var literal funcLitFinder
+	walk(&literal, n)
+	return token.Pos(literal) != token.NoPos, token.Pos(literal)
+}
+
+// firstFuncLiteral returns the position of the body of the first
+// function literal in any of a list of nodes, or NoPos if there is
+// none.
+func firstFuncLiteral(nodes ...ast.Node) token.Pos {
+	for _, n := range nodes {
+		if found, pos := hasFuncLiteral(n); found {
+			return pos
+		}
+	}
+
+	return token.NoPos
+}
+
+// statementBoundary finds the position in a statement that ends the
+// current basic block.
+func statementBoundary(s ast.Stmt) token.Pos {
+	var pos token.Pos
+	var lbrace token.Pos
+	switch s := s.(type) {
+	case *ast.BlockStmt:
+		return s.Lbrace
+	case *ast.IfStmt:
+		pos, lbrace = firstFuncLiteral(s.Init, s.Cond), s.Body.Lbrace
+	case *ast.ForStmt:
+		pos, lbrace = firstFuncLiteral(s.Init, s.Cond, s.Post), s.Body.Lbrace
+	case *ast.LabeledStmt:
+		return statementBoundary(s.Stmt)
+	case *ast.RangeStmt:
+		pos, lbrace = firstFuncLiteral(s.X), s.Body.Lbrace
+	case *ast.SwitchStmt:
+		pos, lbrace = firstFuncLiteral(s.Init, s.Tag), s.Body.Lbrace
+	case *ast.SelectStmt:
+		return s.Body.Lbrace
+	case *ast.TypeSwitchStmt:
+		pos, lbrace = firstFuncLiteral(s.Init), s.Body.Lbrace
+	default:
+		pos, lbrace = firstFuncLiteral(s), s.End()
+	}
+
+	if pos != token.NoPos {
+		return pos
+	}
+	return lbrace
+}
+
+// endsBasicSourceBlock reports whether a statement changes the flow
+// of control, or contains a function literal.
+func endsBasicSourceBlock(s ast.Stmt) bool {
+	switch s := s.(type) {
+	case *ast.BlockStmt, *ast.BranchStmt, *ast.ForStmt, *ast.IfStmt, *ast.LabeledStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.SelectStmt, *ast.TypeSwitchStmt:
+		return true
+
+	case *ast.ExprStmt:
+		// Calls to panic change the flow
+		if call, ok := s.X.(*ast.CallExpr); ok {
+			if ident, ok := call.Fun.(*ast.Ident); ok && ident.Name == "panic" && len(call.Args) == 1 {
+				return true
+			}
+		}
+	}
+
+	found, _ := hasFuncLiteral(s)
+	return found
+}
+
+// isControl reports whether a statement is a control statement that
+// cannot be separated from its label.
+func isControl(s ast.Stmt) bool {
+	switch s.(type) {
+	case *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.SelectStmt, *ast.TypeSwitchStmt:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package statements
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/cover"
+
+	"github.com/klmitch/overcover/common"
+)
+
+// goldenBlocks parses a source file and computes its blocks.
+func goldenBlocks(t *testing.T, fname string) []common.Block {
+	content, err := os.ReadFile(fname)
+	require.NoError(t, err)
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, fname, content, parser.ParseComments)
+	require.NoError(t, err)
+
+	return fileBlocks(fset, file, content)
+}
+
+// TestFileBlocksGolden compares the blocks computed for the files in
+// testdata/golden with the blocks in testdata/golden/cover.out, which
+// was produced by running "go test -coverprofile=cover.out" in that
+// directory.
+func TestFileBlocksGolden(t *testing.T) {
+	profiles, err := cover.ParseProfiles(filepath.Join("testdata", "golden", "cover.out"))
+	require.NoError(t, err)
+	require.NotEmpty(t, profiles)
+
+	for _, prof := range profiles {
+		expected := []common.Block{}
+		for _, blk := range prof.Blocks {
+			expected = append(expected, common.Block{
+				StartLine: blk.StartLine,
+				StartCol:  blk.StartCol,
+				EndLine:   blk.EndLine,
+				EndCol:    blk.EndCol,
+				NumStmt:   blk.NumStmt,
+			})
+		}
+
+		result := goldenBlocks(t, filepath.Join("testdata", "golden", path.Base(prof.FileName)))
+
+		assert.Equal(t, expected, result, prof.FileName)
+	}
+}
+
+// newTestVisitor constructs a blockVisitor for some source text.
+func newTestVisitor(src string) (*blockVisitor, *token.File) {
+	fset := token.NewFileSet()
+	file := fset.AddFile("test.go", -1, len(src))
+	file.SetLinesForContent([]byte(src))
+
+	return &blockVisitor{
+		fset:    fset,
+		content: []byte(src),
+		seen:    map[[4]int]bool{},
+	}, file
+}
+
+func TestFindTextComments(t *testing.T) {
+	obj, file := newTestVisitor("} // else\n/* else */ else {}")
+
+	result := obj.findText(file.Pos(1), "else")
+
+	assert.Equal(t, 21, result)
+}
+
+func TestFindTextUnterminated(t *testing.T) {
+	obj, file := newTestVisitor("} /* else {}")
+
+	result := obj.findText(file.Pos(1), "else")
+
+	assert.Equal(t, -1, result)
+}
+
+func TestFindTextMissing(t *testing.T) {
+	obj, file := newTestVisitor("} {}")
+
+	result := obj.findText(file.Pos(1), "else")
+
+	assert.Equal(t, -1, result)
+}
+
+func TestAddBlockDuplicate(t *testing.T) {
+	obj, file := newTestVisitor("{}\n")
+
+	obj.addBlock(file.Pos(1), file.Pos(1), 0)
+	obj.addBlock(file.Pos(1), file.Pos(1), 0)
+
+	assert.Equal(t, []common.Block{
+		{StartLine: 1, StartCol: 2, EndLine: 1, EndCol: 2},
+		{StartLine: 1, StartCol: 2, EndLine: 1, EndCol: 3},
+	}, obj.blocks)
+}
@@ -16,107 +16,56 @@ package statements
 
 import (
 	"go/ast"
+	"os"
 	"path/filepath"
+	"strings"
 
 	"golang.org/x/tools/go/packages"
 
 	"github.com/klmitch/overcover/common"
+	"github.com/klmitch/overcover/ignore"
 )
 
 // Patch points for top-level functions called by functions in this
 // file.
 var (
-	walk                                                                = ast.Walk
-	load func(*packages.Config, ...string) ([]*packages.Package, error) = packages.Load
+	readFile                                                                = os.ReadFile
+	load     func(*packages.Config, ...string) ([]*packages.Package, error) = packages.Load
 )
 
-// funcVisitor is a type implementing the ast.Visitor interface.  This
-// implementation prospects for function declarations and literals,
-// then constructs and returns a stmtVisitor to actually count the
-// statements.
-type funcVisitor struct {
-	fd *common.FileData // The file data
-}
-
-// Visit implements the ast.Visitor interface for funcVisitor.
-func (v *funcVisitor) Visit(n ast.Node) ast.Visitor {
-	switch n.(type) {
-	case *ast.FuncDecl:
-		return &stmtVisitor{fd: v.fd}
-	case *ast.FuncLit:
-		return &stmtVisitor{fd: v.fd}
-	}
-
-	return v
-}
-
-// stmtVisitor is a type implementing the ast.Visitor interface.  This
-// implementation prospects for statements and counts them.  Certain
-// statements containing other statements are handled specially to
-// ensure a proper count, avoiding double-counts.
-type stmtVisitor struct {
-	fd *common.FileData // The file data
-}
-
-// Visit implements the ast.Visitor interface for stmtVisitor.
-func (v *stmtVisitor) Visit(n ast.Node) ast.Visitor {
-	switch t := n.(type) {
-	case *ast.CaseClause: // Handle 'case' in a switch
-		for _, stmt := range t.Body {
-			walk(v, stmt)
-		}
-		return nil // we handled recursion ourselves
-
-	case *ast.CommClause: // Handle 'case' in a select
-		for _, stmt := range t.Body {
-			walk(v, stmt)
-		}
-		return nil // we handled recursion ourselves
-
-	case *ast.ForStmt: // Handle for statement
-		// Count the statement
-		v.fd.Count++
-		walk(v, t.Body)
-		return nil // we handled recursion ourselves
-
-	case *ast.IfStmt: // Handle if statement
-		// Count the statement
-		v.fd.Count++
-		walk(v, t.Body)
-		if t.Else != nil {
-			walk(v, t.Else)
-		}
-		return nil // we handled recursion ourselves
-
-	case *ast.SwitchStmt: // Handle switch statement
-		// Count the statement
-		v.fd.Count++
-		walk(v, t.Body)
-		return nil // we handled recursion ourselves
-
-	case *ast.TypeSwitchStmt: // Handle type switch statement
-		// Count the statement
-		v.fd.Count++
-		walk(v, t.Body)
-		return nil // we handled recursion ourselves
-
-	// Omits BlockStmt and LabeledStmt, as they're not independent
-	case *ast.AssignStmt, *ast.BadStmt, *ast.BranchStmt, *ast.DeclStmt, *ast.DeferStmt, *ast.EmptyStmt, *ast.ExprStmt, *ast.GoStmt, *ast.IncDecStmt, *ast.RangeStmt, *ast.ReturnStmt, *ast.SelectStmt, *ast.SendStmt:
-		// Count the statement
-		v.fd.Count++
-	}
-
-	return v
+// testMain reports whether a package is the main package generated
+// by "go test" to run the tests of another package.
+func testMain(pkg *packages.Package) bool {
+	return pkg.Name == "main" && strings.HasSuffix(pkg.ID, ".test") && pkg.ID == pkg.PkgPath
 }
 
 // Load loads file data for all files in the specified list of
-// packages.  The flags is a list of build flags (nil is acceptable)
-// for selecting the files to load, and patterns is a list of package
-// patterns to load.  A list of FileData instances is returned.
-func Load(flags, patterns []string) (common.DataSet, error) {
+// packages.  The dir is the directory in which to interpret the
+// patterns, which determines the module they are loaded from (an
+// empty string selects the current directory).  The flags is a list
+// of build flags (nil is acceptable) for selecting the files to load,
+// and patterns is a list of package patterns to load.  A list of
+// FileData instances is returned; each records whether the file
+// contains generated code.  Statements are counted exactly as the
+// cover tool counts them: each coverage block the cover tool would
+// emit for the file contributes the number of statements in its basic
+// block.  The blocks are also returned, with zero counts, as if from
+// a profile in which the file was not executed.  Functions, blocks,
+// and statements excluded by "//overcover:ignore" directives are not
+// counted, and files excluded by "//overcover:ignore-file" directives
+// are omitted.  The function declarations and function literals in
+// each file are also located.
+//
+// Files are identified by the import path of their package, as in
+// coverage profiles, rather than by the package ID.  Test files, which
+// are never instrumented, are omitted, along with the test variants of
+// packages and the main packages generated for tests, should they be
+// loaded; each file is reported only once.
+func Load(dir string, flags, patterns []string) (common.DataSet, error) {
 	// Begin by constructing the configuration for packages.Load
 	cfg := &packages.Config{
-		Mode:       packages.NeedTypes | packages.NeedSyntax,
+		Mode:       packages.NeedName | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo,
+		Dir:        dir,
 		BuildFlags: flags,
 	}
 
@@ -128,16 +77,60 @@ func Load(flags, patterns []string) (common.DataSet, error) {
 
 	// Next, set up the statement counts
 	var data []common.FileData
+	seen := map[string]bool{}
 	for _, pkg := range pkgs {
+		if testMain(pkg) {
+			continue
+		}
+
+		api := apiMethods(pkg.Types)
+		globs := new(int)
 		for _, file := range pkg.Syntax {
+			// Skip test files
+			fname := pkg.Fset.PositionFor(file.Package, false).Filename
+			name := filepath.Base(fname)
+			if strings.HasSuffix(name, "_test.go") {
+				continue
+			}
+
+			// Locate the functions; this must be done for every
+			// file, so that the literals outside of functions are
+			// numbered as by the compiler
+			funcs := fileFunctions(pkg, api, file, globs)
+
+			// Skip files already seen
+			if seen[pkg.PkgPath+"/"+name] {
+				continue
+			}
+			seen[pkg.PkgPath+"/"+name] = true
+
+			// Read the source, which the block computation needs
+			content, err := readFile(fname)
+			if err != nil {
+				return nil, err
+			}
+			blocks := fileBlocks(pkg.Fset, file, content)
+
+			// Honor any ignore directives
+			if d := ignore.Find(pkg.Fset, file); d != nil {
+				if d.File {
+					continue
+				}
+				blocks = d.Apply(blocks)
+			}
+
 			// Assemble the file data
 			fd := common.FileData{
-				Package: pkg.ID,
-				Name:    filepath.Base(pkg.Fset.Position(file.Package).Filename),
+				Package:   pkg.PkgPath,
+				Name:      name,
+				Blocks:    blocks,
+				Funcs:     funcs,
+				Generated: ast.IsGenerated(file),
+				Synthetic: true,
+			}
+			for _, blk := range blocks {
+				fd.Count += int64(blk.NumStmt)
 			}
-
-			// Count the statements in the file
-			walk(&funcVisitor{fd: &fd}, file)
 
 			// Append it to our results
 			data = append(data, fd)
@@ -16,7 +16,9 @@ package statements
 
 import (
 	"go/ast"
+	"go/parser"
 	"go/token"
+	"go/types"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -28,550 +30,406 @@ import (
 	"github.com/klmitch/overcover/common"
 )
 
-func TestFuncVisitorVisitFuncDecl(t *testing.T) {
-	obj := &funcVisitor{
-		fd: &common.FileData{},
-	}
-	node := &ast.FuncDecl{}
-
-	result := obj.Visit(node)
+func TestTestMainTrue(t *testing.T) {
+	result := testMain(&packages.Package{
+		ID:      "example.com/mod/pkg.test",
+		Name:    "main",
+		PkgPath: "example.com/mod/pkg.test",
+	})
 
-	assert.Equal(t, &stmtVisitor{
-		fd: obj.fd,
-	}, result)
-	assert.Same(t, result.(*stmtVisitor).fd, obj.fd)
+	assert.True(t, result)
 }
 
-func TestFuncVisitorVisitFuncLit(t *testing.T) {
-	obj := &funcVisitor{
-		fd: &common.FileData{},
-	}
-	node := &ast.FuncLit{}
-
-	result := obj.Visit(node)
+func TestTestMainFalse(t *testing.T) {
+	result := testMain(&packages.Package{
+		ID:      "example.com/mod/cmd/tool",
+		Name:    "main",
+		PkgPath: "example.com/mod/cmd/tool",
+	})
 
-	assert.Equal(t, &stmtVisitor{
-		fd: obj.fd,
-	}, result)
-	assert.Same(t, result.(*stmtVisitor).fd, obj.fd)
+	assert.False(t, result)
 }
 
-func TestFuncVisitorVisitOther(t *testing.T) {
-	obj := &funcVisitor{
-		fd: &common.FileData{},
-	}
-	node := &ast.EmptyStmt{}
-
-	result := obj.Visit(node)
-
-	assert.Same(t, obj, result)
-}
+func TestLoadBase(t *testing.T) {
+	fset := token.NewFileSet()
+	sources := map[string]string{
+		"some/path/p1f1.go": `package p1
 
-func TestStmtVisitorVisitCaseClause(t *testing.T) {
-	obj := &stmtVisitor{
-		fd: &common.FileData{},
-	}
-	stmts := []ast.Stmt{
-		&ast.EmptyStmt{},
-		&ast.EmptyStmt{},
-		&ast.EmptyStmt{},
-	}
-	node := &ast.CaseClause{
-		Body: stmts,
+func F(x int) int {
+	x++
+	if x > 0 {
+		return x
 	}
-	walkCalled := 0
-	defer patcher.SetVar(&walk, func(v ast.Visitor, n ast.Node) {
-		assert.Same(t, obj, v)
-		assert.Same(t, stmts[walkCalled], n)
-		walkCalled++
-	}).Install().Restore()
-
-	result := obj.Visit(node)
-
-	assert.Nil(t, result)
-	assert.Equal(t, int64(0), obj.fd.Count)
-	assert.Equal(t, 3, walkCalled)
+	return -x
 }
+`,
+		"some/path/p1f2.go": `// Code generated by test. DO NOT EDIT.
 
-func TestStmtVisitorVisitCommClause(t *testing.T) {
-	obj := &stmtVisitor{
-		fd: &common.FileData{},
-	}
-	stmts := []ast.Stmt{
-		&ast.EmptyStmt{},
-		&ast.EmptyStmt{},
-		&ast.EmptyStmt{},
-	}
-	node := &ast.CommClause{
-		Body: stmts,
-	}
-	walkCalled := 0
-	defer patcher.SetVar(&walk, func(v ast.Visitor, n ast.Node) {
-		assert.Same(t, obj, v)
-		assert.Same(t, stmts[walkCalled], n)
-		walkCalled++
-	}).Install().Restore()
+package p1
 
-	result := obj.Visit(node)
+func G() {}
+`,
+		"some/path/p2f1.go": `package p2
 
-	assert.Nil(t, result)
-	assert.Equal(t, int64(0), obj.fd.Count)
-	assert.Equal(t, 3, walkCalled)
+func H() {
+	println("h")
 }
-
-func TestStmtVisitorVisitForStmt(t *testing.T) {
-	obj := &stmtVisitor{
-		fd: &common.FileData{},
+`,
+	}
+	parse := func(fname string) *ast.File {
+		file, err := parser.ParseFile(fset, fname, sources[fname], parser.ParseComments)
+		require.NoError(t, err)
+		return file
+	}
+	check := func(path string, files ...*ast.File) *packages.Package {
+		info := &types.Info{Defs: map[*ast.Ident]types.Object{}}
+		pkg, err := (&types.Config{}).Check(path, fset, files, info)
+		require.NoError(t, err)
+		return &packages.Package{
+			ID:        path,
+			PkgPath:   path,
+			Fset:      fset,
+			Syntax:    files,
+			Types:     pkg,
+			TypesInfo: info,
+		}
 	}
-	body := &ast.BlockStmt{}
-	node := &ast.ForStmt{
-		Body: body,
+	pkgs := []*packages.Package{
+		check("p1", parse("some/path/p1f1.go"), parse("some/path/p1f2.go")),
+		check("p2", parse("some/path/p2f1.go")),
 	}
-	walkCalled := false
-	defer patcher.SetVar(&walk, func(v ast.Visitor, n ast.Node) {
-		assert.Same(t, obj, v)
-		assert.Same(t, body, n)
-		walkCalled = true
-	}).Install().Restore()
+	loadCalled := false
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&load, func(cfg *packages.Config, patterns ...string) ([]*packages.Package, error) {
+			assert.Equal(t, &packages.Config{
+				Mode:       packages.NeedName | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo,
+				Dir:        "/src/mod",
+				BuildFlags: []string{},
+			}, cfg)
+			assert.Equal(t, []string{"./..."}, patterns)
+			loadCalled = true
+			return pkgs, nil
+		}),
+		patcher.SetVar(&readFile, func(fname string) ([]byte, error) {
+			src, ok := sources[fname]
+			require.True(t, ok, fname)
+			return []byte(src), nil
+		}),
+	).Install().Restore()
 
-	result := obj.Visit(node)
+	result, err := Load("/src/mod", []string{}, []string{"./..."})
 
-	assert.Nil(t, result)
-	assert.Equal(t, int64(1), obj.fd.Count)
-	assert.True(t, walkCalled)
+	assert.NoError(t, err)
+	assert.Equal(t, common.DataSet{
+		{
+			Package: "p1",
+			Name:    "p1f1.go",
+			Count:   4,
+			Blocks: []common.Block{
+				{StartLine: 4, StartCol: 2, EndLine: 5, EndCol: 11, NumStmt: 2},
+				{StartLine: 6, StartCol: 3, EndLine: 7, EndCol: 1, NumStmt: 1},
+				{StartLine: 8, StartCol: 2, EndLine: 8, EndCol: 11, NumStmt: 1},
+			},
+			Funcs: []common.Extent{
+				{Name: "F", Line: 3, StartLine: 3, StartCol: 19, EndLine: 9, EndCol: 1, Exported: true},
+			},
+			Synthetic: true,
+		},
+		{
+			Package: "p1",
+			Name:    "p1f2.go",
+			Blocks: []common.Block{
+				{StartLine: 5, StartCol: 11, EndLine: 5, EndCol: 11},
+			},
+			Funcs: []common.Extent{
+				{Name: "G", Line: 5, StartLine: 5, StartCol: 10, EndLine: 5, EndCol: 11, Exported: true},
+			},
+			Generated: true,
+			Synthetic: true,
+		},
+		{
+			Package: "p2",
+			Name:    "p2f1.go",
+			Count:   1,
+			Blocks: []common.Block{
+				{StartLine: 4, StartCol: 2, EndLine: 5, EndCol: 1, NumStmt: 1},
+			},
+			Funcs: []common.Extent{
+				{Name: "H", Line: 3, StartLine: 3, StartCol: 10, EndLine: 5, EndCol: 1, Exported: true},
+			},
+			Synthetic: true,
+		},
+	}, result)
+	assert.True(t, loadCalled)
 }
 
-func TestStmtVisitorVisitIfStmtBase(t *testing.T) {
-	obj := &stmtVisitor{
-		fd: &common.FileData{},
-	}
-	body := &ast.BlockStmt{}
-	node := &ast.IfStmt{
-		Body: body,
-	}
-	walkCalled := 0
-	defer patcher.SetVar(&walk, func(v ast.Visitor, n ast.Node) {
-		assert.Same(t, obj, v)
-		switch walkCalled {
-		case 0:
-			assert.Same(t, body, n)
-		default:
-			t.Error("Unexpected call to walk")
-			t.Fail()
-		}
-		walkCalled++
-	}).Install().Restore()
+func TestLoadTestVariants(t *testing.T) {
+	fset := token.NewFileSet()
+	sources := map[string]string{
+		"/src/mod/pkg/file.go": `package pkg
 
-	result := obj.Visit(node)
-
-	assert.Nil(t, result)
-	assert.Equal(t, int64(1), obj.fd.Count)
-	assert.Equal(t, 1, walkCalled)
+func F() {
+	println("f")
 }
+`,
+		"/src/mod/pkg/file_test.go": `package pkg
 
-func TestStmtVisitorVisitIfStmtElse(t *testing.T) {
-	obj := &stmtVisitor{
-		fd: &common.FileData{},
-	}
-	body := &ast.BlockStmt{}
-	elseStmt := &ast.EmptyStmt{}
-	node := &ast.IfStmt{
-		Body: body,
-		Else: elseStmt,
-	}
-	walkCalled := 0
-	defer patcher.SetVar(&walk, func(v ast.Visitor, n ast.Node) {
-		assert.Same(t, obj, v)
-		switch walkCalled {
-		case 0:
-			assert.Same(t, body, n)
-		case 1:
-			assert.Same(t, elseStmt, n)
-		default:
-			t.Error("Unexpected call to walk")
-			t.Fail()
-		}
-		walkCalled++
-	}).Install().Restore()
-
-	result := obj.Visit(node)
+func helper() {
+	println("helper")
+}
+`,
+		"/src/mod/pkg/ext_test.go": `package pkg_test
 
-	assert.Nil(t, result)
-	assert.Equal(t, int64(1), obj.fd.Count)
-	assert.Equal(t, 2, walkCalled)
+func other() {
+	println("other")
 }
+`,
+		"/cache/testmain": `package main
 
-func TestStmtVisitorVisitSwitchStmt(t *testing.T) {
-	obj := &stmtVisitor{
-		fd: &common.FileData{},
+func main() {
+	println("main")
+}
+`,
 	}
-	body := &ast.BlockStmt{}
-	node := &ast.SwitchStmt{
-		Body: body,
+	parse := func(fname string) *ast.File {
+		file, err := parser.ParseFile(fset, fname, sources[fname], parser.ParseComments)
+		require.NoError(t, err)
+		return file
+	}
+	file := parse("/src/mod/pkg/file.go")
+	pkgs := []*packages.Package{
+		{
+			ID:      "example.com/mod/pkg",
+			Name:    "pkg",
+			PkgPath: "example.com/mod/pkg",
+			Fset:    fset,
+			Syntax:  []*ast.File{file},
+		},
+		{
+			ID:      "example.com/mod/pkg [example.com/mod/pkg.test]",
+			Name:    "pkg",
+			PkgPath: "example.com/mod/pkg",
+			Fset:    fset,
+			Syntax:  []*ast.File{file, parse("/src/mod/pkg/file_test.go")},
+		},
+		{
+			ID:      "example.com/mod/pkg_test [example.com/mod/pkg.test]",
+			Name:    "pkg_test",
+			PkgPath: "example.com/mod/pkg_test",
+			Fset:    fset,
+			Syntax:  []*ast.File{parse("/src/mod/pkg/ext_test.go")},
+		},
+		{
+			ID:      "example.com/mod/pkg.test",
+			Name:    "main",
+			PkgPath: "example.com/mod/pkg.test",
+			Fset:    fset,
+			Syntax:  []*ast.File{parse("/cache/testmain")},
+		},
 	}
-	walkCalled := false
-	defer patcher.SetVar(&walk, func(v ast.Visitor, n ast.Node) {
-		assert.Same(t, obj, v)
-		assert.Same(t, body, n)
-		walkCalled = true
-	}).Install().Restore()
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&load, func(cfg *packages.Config, patterns ...string) ([]*packages.Package, error) {
+			return pkgs, nil
+		}),
+		patcher.SetVar(&readFile, func(fname string) ([]byte, error) {
+			return []byte(sources[fname]), nil
+		}),
+	).Install().Restore()
 
-	result := obj.Visit(node)
+	result, err := Load("", []string{}, []string{"./..."})
 
-	assert.Nil(t, result)
-	assert.Equal(t, int64(1), obj.fd.Count)
-	assert.True(t, walkCalled)
+	assert.NoError(t, err)
+	assert.Equal(t, common.DataSet{
+		{
+			Package: "example.com/mod/pkg",
+			Name:    "file.go",
+			Count:   1,
+			Blocks: []common.Block{
+				{StartLine: 4, StartCol: 2, EndLine: 5, EndCol: 1, NumStmt: 1},
+			},
+			Funcs: []common.Extent{
+				{Name: "F", Line: 3, StartLine: 3, StartCol: 10, EndLine: 5, EndCol: 1},
+			},
+			Synthetic: true,
+		},
+	}, result)
 }
 
-func TestStmtVisitorVisitTypeSwitchStmt(t *testing.T) {
-	obj := &stmtVisitor{
-		fd: &common.FileData{},
-	}
-	body := &ast.BlockStmt{}
-	node := &ast.TypeSwitchStmt{
-		Body: body,
-	}
-	walkCalled := false
-	defer patcher.SetVar(&walk, func(v ast.Visitor, n ast.Node) {
-		assert.Same(t, obj, v)
-		assert.Same(t, body, n)
-		walkCalled = true
-	}).Install().Restore()
+func TestLoadReadError(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "some/path/f1.go", "package p\n", parser.ParseComments)
+	require.NoError(t, err)
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&load, func(cfg *packages.Config, patterns ...string) ([]*packages.Package, error) {
+			return []*packages.Package{
+				{
+					ID:      "p",
+					PkgPath: "p",
+					Fset:    fset,
+					Syntax:  []*ast.File{file},
+				},
+			}, nil
+		}),
+		patcher.SetVar(&readFile, func(fname string) ([]byte, error) {
+			assert.Equal(t, "some/path/f1.go", fname)
+			return nil, assert.AnError
+		}),
+	).Install().Restore()
 
-	result := obj.Visit(node)
+	result, err := Load("", []string{}, []string{"./..."})
 
+	assert.Same(t, assert.AnError, err)
 	assert.Nil(t, result)
-	assert.Equal(t, int64(1), obj.fd.Count)
-	assert.True(t, walkCalled)
 }
 
-func TestStmtVisitorVisitAssignStmt(t *testing.T) {
-	obj := &stmtVisitor{
-		fd: &common.FileData{},
-	}
-	node := &ast.AssignStmt{}
-	walkCalled := false
-	defer patcher.SetVar(&walk, func(v ast.Visitor, _ ast.Node) {
-		assert.Same(t, obj, v)
-		walkCalled = true
-	}).Install().Restore()
-
-	result := obj.Visit(node)
-
-	assert.Same(t, obj, result)
-	assert.Equal(t, int64(1), obj.fd.Count)
-	assert.False(t, walkCalled)
-}
+func TestLoadDirectives(t *testing.T) {
+	fset := token.NewFileSet()
+	sources := map[string]string{
+		"some/path/f1.go": `package p
 
-func TestStmtVisitorVisitBadStmt(t *testing.T) {
-	obj := &stmtVisitor{
-		fd: &common.FileData{},
+func Check(x int) bool {
+	z := x + 1
+	if z < 0 { //overcover:ignore defensive
+		return false
 	}
-	node := &ast.BadStmt{}
-	walkCalled := false
-	defer patcher.SetVar(&walk, func(v ast.Visitor, _ ast.Node) {
-		assert.Same(t, obj, v)
-		walkCalled = true
-	}).Install().Restore()
-
-	result := obj.Visit(node)
-
-	assert.Same(t, obj, result)
-	assert.Equal(t, int64(1), obj.fd.Count)
-	assert.False(t, walkCalled)
+	return true
 }
 
-func TestStmtVisitorVisitBranchStmt(t *testing.T) {
-	obj := &stmtVisitor{
-		fd: &common.FileData{},
-	}
-	node := &ast.BranchStmt{}
-	walkCalled := false
-	defer patcher.SetVar(&walk, func(v ast.Visitor, _ ast.Node) {
-		assert.Same(t, obj, v)
-		walkCalled = true
-	}).Install().Restore()
-
-	result := obj.Visit(node)
-
-	assert.Same(t, obj, result)
-	assert.Equal(t, int64(1), obj.fd.Count)
-	assert.False(t, walkCalled)
+//overcover:ignore
+func Unused() {
+	panic("unused")
 }
+`,
+		"some/path/f2.go": `//overcover:ignore-file
 
-func TestStmtVisitorVisitDeclStmt(t *testing.T) {
-	obj := &stmtVisitor{
-		fd: &common.FileData{},
-	}
-	node := &ast.DeclStmt{}
-	walkCalled := false
-	defer patcher.SetVar(&walk, func(v ast.Visitor, _ ast.Node) {
-		assert.Same(t, obj, v)
-		walkCalled = true
-	}).Install().Restore()
-
-	result := obj.Visit(node)
-
-	assert.Same(t, obj, result)
-	assert.Equal(t, int64(1), obj.fd.Count)
-	assert.False(t, walkCalled)
-}
+package p
 
-func TestStmtVisitorVisitDeferStmt(t *testing.T) {
-	obj := &stmtVisitor{
-		fd: &common.FileData{},
-	}
-	node := &ast.DeferStmt{}
-	walkCalled := false
-	defer patcher.SetVar(&walk, func(v ast.Visitor, _ ast.Node) {
-		assert.Same(t, obj, v)
-		walkCalled = true
-	}).Install().Restore()
-
-	result := obj.Visit(node)
-
-	assert.Same(t, obj, result)
-	assert.Equal(t, int64(1), obj.fd.Count)
-	assert.False(t, walkCalled)
+func Other() int {
+	return 0
 }
-
-func TestStmtVisitorVisitEmptyStmt(t *testing.T) {
-	obj := &stmtVisitor{
-		fd: &common.FileData{},
+`,
 	}
-	node := &ast.EmptyStmt{}
-	walkCalled := false
-	defer patcher.SetVar(&walk, func(v ast.Visitor, _ ast.Node) {
-		assert.Same(t, obj, v)
-		walkCalled = true
-	}).Install().Restore()
-
-	result := obj.Visit(node)
-
-	assert.Same(t, obj, result)
-	assert.Equal(t, int64(1), obj.fd.Count)
-	assert.False(t, walkCalled)
-}
+	f1, err := parser.ParseFile(fset, "some/path/f1.go", sources["some/path/f1.go"], parser.ParseComments)
+	require.NoError(t, err)
+	f2, err := parser.ParseFile(fset, "some/path/f2.go", sources["some/path/f2.go"], parser.ParseComments)
+	require.NoError(t, err)
+	defer patcher.NewPatchMaster(
+		patcher.SetVar(&load, func(cfg *packages.Config, patterns ...string) ([]*packages.Package, error) {
+			return []*packages.Package{
+				{
+					ID:      "p",
+					PkgPath: "p",
+					Fset:    fset,
+					Syntax:  []*ast.File{f1, f2},
+				},
+			}, nil
+		}),
+		patcher.SetVar(&readFile, func(fname string) ([]byte, error) {
+			return []byte(sources[fname]), nil
+		}),
+	).Install().Restore()
 
-func TestStmtVisitorVisitExprStmt(t *testing.T) {
-	obj := &stmtVisitor{
-		fd: &common.FileData{},
-	}
-	node := &ast.ExprStmt{}
-	walkCalled := false
-	defer patcher.SetVar(&walk, func(v ast.Visitor, _ ast.Node) {
-		assert.Same(t, obj, v)
-		walkCalled = true
-	}).Install().Restore()
-
-	result := obj.Visit(node)
-
-	assert.Same(t, obj, result)
-	assert.Equal(t, int64(1), obj.fd.Count)
-	assert.False(t, walkCalled)
-}
+	result, err := Load("", []string{}, []string{"./..."})
 
-func TestStmtVisitorVisitGoStmt(t *testing.T) {
-	obj := &stmtVisitor{
-		fd: &common.FileData{},
-	}
-	node := &ast.GoStmt{}
-	walkCalled := false
-	defer patcher.SetVar(&walk, func(v ast.Visitor, _ ast.Node) {
-		assert.Same(t, obj, v)
-		walkCalled = true
-	}).Install().Restore()
-
-	result := obj.Visit(node)
-
-	assert.Same(t, obj, result)
-	assert.Equal(t, int64(1), obj.fd.Count)
-	assert.False(t, walkCalled)
+	assert.NoError(t, err)
+	assert.Equal(t, common.DataSet{
+		{
+			Package: "p",
+			Name:    "f1.go",
+			Count:   2,
+			Blocks: []common.Block{
+				{StartLine: 4, StartCol: 2, EndLine: 5, EndCol: 11, NumStmt: 1},
+				{StartLine: 8, StartCol: 2, EndLine: 8, EndCol: 13, NumStmt: 1},
+			},
+			Funcs: []common.Extent{
+				{Name: "Check", Line: 3, StartLine: 3, StartCol: 24, EndLine: 9, EndCol: 1},
+				{Name: "Unused", Line: 12, StartLine: 12, StartCol: 15, EndLine: 14, EndCol: 1},
+			},
+			Synthetic: true,
+		},
+	}, result)
 }
 
-func TestStmtVisitorVisitIncDecStmt(t *testing.T) {
-	obj := &stmtVisitor{
-		fd: &common.FileData{},
-	}
-	node := &ast.IncDecStmt{}
-	walkCalled := false
-	defer patcher.SetVar(&walk, func(v ast.Visitor, _ ast.Node) {
-		assert.Same(t, obj, v)
-		walkCalled = true
-	}).Install().Restore()
-
-	result := obj.Visit(node)
-
-	assert.Same(t, obj, result)
-	assert.Equal(t, int64(1), obj.fd.Count)
-	assert.False(t, walkCalled)
-}
+func TestLoadGlobs(t *testing.T) {
+	fset := token.NewFileSet()
+	sources := map[string]string{
+		"some/path/f1.go": `package p
 
-func TestStmtVisitorVisitRangeStmt(t *testing.T) {
-	obj := &stmtVisitor{
-		fd: &common.FileData{},
-	}
-	node := &ast.RangeStmt{}
-	walkCalled := false
-	defer patcher.SetVar(&walk, func(v ast.Visitor, _ ast.Node) {
-		assert.Same(t, obj, v)
-		walkCalled = true
-	}).Install().Restore()
-
-	result := obj.Visit(node)
-
-	assert.Same(t, obj, result)
-	assert.Equal(t, int64(1), obj.fd.Count)
-	assert.False(t, walkCalled)
+var a = func() int {
+	return 1
 }
+`,
+		"some/path/f2.go": `//overcover:ignore-file
 
-func TestStmtVisitorVisitReturnStmt(t *testing.T) {
-	obj := &stmtVisitor{
-		fd: &common.FileData{},
-	}
-	node := &ast.ReturnStmt{}
-	walkCalled := false
-	defer patcher.SetVar(&walk, func(v ast.Visitor, _ ast.Node) {
-		assert.Same(t, obj, v)
-		walkCalled = true
-	}).Install().Restore()
-
-	result := obj.Visit(node)
-
-	assert.Same(t, obj, result)
-	assert.Equal(t, int64(1), obj.fd.Count)
-	assert.False(t, walkCalled)
-}
+package p
 
-func TestStmtVisitorVisitSelectStmt(t *testing.T) {
-	obj := &stmtVisitor{
-		fd: &common.FileData{},
-	}
-	node := &ast.SelectStmt{}
-	walkCalled := false
-	defer patcher.SetVar(&walk, func(v ast.Visitor, _ ast.Node) {
-		assert.Same(t, obj, v)
-		walkCalled = true
-	}).Install().Restore()
-
-	result := obj.Visit(node)
-
-	assert.Same(t, obj, result)
-	assert.Equal(t, int64(1), obj.fd.Count)
-	assert.False(t, walkCalled)
+var b = func() int {
+	return 2
 }
+`,
+		"some/path/f3.go": `package p
 
-func TestStmtVisitorVisitSendStmt(t *testing.T) {
-	obj := &stmtVisitor{
-		fd: &common.FileData{},
-	}
-	node := &ast.SendStmt{}
-	walkCalled := false
-	defer patcher.SetVar(&walk, func(v ast.Visitor, _ ast.Node) {
-		assert.Same(t, obj, v)
-		walkCalled = true
-	}).Install().Restore()
-
-	result := obj.Visit(node)
-
-	assert.Same(t, obj, result)
-	assert.Equal(t, int64(1), obj.fd.Count)
-	assert.False(t, walkCalled)
+var c = func() int {
+	return 3
 }
-
-func TestLoadBase(t *testing.T) {
-	fset := &token.FileSet{}
-	p1f1 := fset.AddFile("some/path/p1f1", -1, 4)
-	p1f2 := fset.AddFile("some/path/p1f2", -1, 4)
-	p2f1 := fset.AddFile("some/path/p2f1", -1, 4)
-	nodes := []*ast.File{
-		{
-			Package: token.Pos(p1f1.Base() + 1),
-		},
-		{
-			Package: token.Pos(p1f2.Base() + 1),
-		},
-		{
-			Package: token.Pos(p2f1.Base() + 1),
-		},
-	}
-	pkgs := []*packages.Package{
-		{
-			ID:     "p1",
-			Fset:   fset,
-			Syntax: []*ast.File{nodes[0], nodes[1]},
-		},
-		{
-			ID:     "p2",
-			Fset:   fset,
-			Syntax: []*ast.File{nodes[2]},
-		},
+`,
 	}
-	ds := common.DataSet{
-		common.FileData{
-			Package: "p1",
-			Name:    "p1f1",
-		},
-		common.FileData{
-			Package: "p1",
-			Name:    "p1f2",
-		},
-		common.FileData{
-			Package: "p2",
-			Name:    "p2f1",
-		},
+	parse := func(fname string) *ast.File {
+		file, err := parser.ParseFile(fset, fname, sources[fname], parser.ParseComments)
+		require.NoError(t, err)
+		return file
 	}
-	loadCalled := false
-	walkCalled := 0
 	defer patcher.NewPatchMaster(
 		patcher.SetVar(&load, func(cfg *packages.Config, patterns ...string) ([]*packages.Package, error) {
-			assert.Equal(t, &packages.Config{
-				Mode:       packages.NeedTypes | packages.NeedSyntax,
-				BuildFlags: []string{},
-			}, cfg)
-			assert.Equal(t, []string{"./..."}, patterns)
-			loadCalled = true
-			return pkgs, nil
+			return []*packages.Package{
+				{
+					ID:      "p",
+					PkgPath: "p",
+					Fset:    fset,
+					Syntax:  []*ast.File{parse("some/path/f1.go"), parse("some/path/f2.go"), parse("some/path/f3.go")},
+				},
+			}, nil
 		}),
-		patcher.SetVar(&walk, func(v ast.Visitor, n ast.Node) {
-			fv, ok := v.(*funcVisitor)
-			require.True(t, ok)
-			assert.Equal(t, &ds[walkCalled], fv.fd)
-			assert.Same(t, nodes[walkCalled], n)
-			walkCalled++
+		patcher.SetVar(&readFile, func(fname string) ([]byte, error) {
+			return []byte(sources[fname]), nil
 		}),
 	).Install().Restore()
 
-	result, err := Load([]string{}, []string{"./..."})
+	result, err := Load("", []string{}, []string{"./..."})
 
 	assert.NoError(t, err)
-	assert.Equal(t, ds, result)
-	assert.True(t, loadCalled)
-	assert.Equal(t, 3, walkCalled)
+	require.Len(t, result, 2)
+	assert.Equal(t, []common.Extent{
+		{Name: "glob..func1", Line: 3, StartLine: 3, StartCol: 20, EndLine: 5, EndCol: 1},
+	}, result[0].Funcs)
+	assert.Equal(t, []common.Extent{
+		{Name: "glob..func3", Line: 3, StartLine: 3, StartCol: 20, EndLine: 5, EndCol: 1},
+	}, result[1].Funcs)
 }
 
 func TestLoadError(t *testing.T) {
 	loadCalled := false
-	walkCalled := 0
+	readFileCalled := 0
 	defer patcher.NewPatchMaster(
 		patcher.SetVar(&load, func(cfg *packages.Config, patterns ...string) ([]*packages.Package, error) {
 			assert.Equal(t, &packages.Config{
-				Mode:       packages.NeedTypes | packages.NeedSyntax,
+				Mode:       packages.NeedName | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo,
 				BuildFlags: []string{},
 			}, cfg)
 			assert.Equal(t, []string{"./..."}, patterns)
 			loadCalled = true
 			return nil, assert.AnError
 		}),
-		patcher.SetVar(&walk, func(_ ast.Visitor, _ ast.Node) {
-			walkCalled++
+		patcher.SetVar(&readFile, func(_ string) ([]byte, error) {
+			readFileCalled++
+			return nil, nil
 		}),
 	).Install().Restore()
 
-	result, err := Load([]string{}, []string{"./..."})
+	result, err := Load("", []string{}, []string{"./..."})
 
 	assert.Same(t, assert.AnError, err)
 	assert.Nil(t, result)
 	assert.True(t, loadCalled)
-	assert.Equal(t, 0, walkCalled)
+	assert.Equal(t, 0, readFileCalled)
 }
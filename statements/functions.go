@@ -0,0 +1,181 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package statements
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/klmitch/overcover/common"
+)
+
+// receiverName returns the name of the type of a method receiver,
+// without any type parameters; pointer receivers are parenthesized,
+// as in "(*T)".
+func receiverName(expr ast.Expr) string {
+	switch x := expr.(type) {
+	case *ast.StarExpr:
+		return "(*" + receiverName(x.X) + ")"
+	case *ast.ParenExpr:
+		return receiverName(x.X)
+	case *ast.IndexExpr:
+		return receiverName(x.X)
+	case *ast.IndexListExpr:
+		return receiverName(x.X)
+	}
+
+	return types.ExprString(expr)
+}
+
+// apiMethods returns the methods that are part of a package's API:
+// those in the method set of a type, or of a pointer to a type, named
+// by an exported type name in the package scope.  This includes the
+// methods of unexported types promoted through embedding in exported
+// types, and the methods of unexported types with exported aliases.
+func apiMethods(pkg *types.Package) map[*types.Func]bool {
+	result := map[*types.Func]bool{}
+	if pkg == nil {
+		return result
+	}
+
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		obj, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || !obj.Exported() {
+			continue
+		}
+		for _, typ := range []types.Type{obj.Type(), types.NewPointer(obj.Type())} {
+			mset := types.NewMethodSet(typ)
+			for i := 0; i < mset.Len(); i++ {
+				if fn, ok := mset.At(i).Obj().(*types.Func); ok {
+					result[fn.Origin()] = true
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// funcExported reports whether a declared function is part of the
+// package's API: a function with an exported name, or a method with
+// an exported name among the API methods.  The function is looked up
+// in the type information; functions without type information are not
+// part of the API.
+func funcExported(info *types.Info, api map[*types.Func]bool, decl *ast.FuncDecl) bool {
+	if info == nil {
+		return false
+	}
+	fn, ok := info.Defs[decl.Name].(*types.Func)
+	if !ok || !fn.Exported() {
+		return false
+	}
+	if decl.Recv == nil {
+		return true
+	}
+
+	return api[fn]
+}
+
+// funcName returns the name of a declared function.  Methods are
+// qualified with the type of their receiver, as in "(*T).Method".
+func funcName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return decl.Name.Name
+	}
+
+	return receiverName(decl.Recv.List[0].Type) + "." + decl.Name.Name
+}
+
+// funcFinder is a type implementing the ast.Visitor interface.  It
+// locates the function declarations and function literals in a file,
+// naming the literals after the function containing them.
+type funcFinder struct {
+	fset   *token.FileSet       // The file set
+	info   *types.Info          // The type information for the package
+	api    map[*types.Func]bool // The API methods of the package
+	funcs  *[]common.Extent     // The functions found, in order
+	name   string               // Name of the containing function
+	nested bool                 // Whether the containing function is a literal
+	lits   *int                 // Number of literals in the containing function
+}
+
+// Visit implements the ast.Visitor interface for funcFinder.
+func (f funcFinder) Visit(node ast.Node) ast.Visitor {
+	var name string
+	var body *ast.BlockStmt
+	nested, exported := false, false
+	switch n := node.(type) {
+	case *ast.FuncDecl:
+		if n.Body == nil {
+			return nil
+		}
+		name, body, exported = funcName(n), n.Body, funcExported(f.info, f.api, n)
+
+	case *ast.FuncLit:
+		*f.lits++
+		switch {
+		case f.nested:
+			name = fmt.Sprintf("%s.%d", f.name, *f.lits)
+		case f.name != "":
+			name = fmt.Sprintf("%s.func%d", f.name, *f.lits)
+		default:
+			name = fmt.Sprintf("glob..func%d", *f.lits)
+		}
+		body, nested = n.Body, true
+
+	default:
+		return f
+	}
+
+	start := f.fset.PositionFor(body.Lbrace, false)
+	end := f.fset.PositionFor(body.Rbrace, false)
+	*f.funcs = append(*f.funcs, common.Extent{
+		Name:      name,
+		Line:      f.fset.PositionFor(node.Pos(), false).Line,
+		StartLine: start.Line,
+		StartCol:  start.Column,
+		EndLine:   end.Line,
+		EndCol:    end.Column,
+		Exported:  exported,
+	})
+	return funcFinder{
+		fset:   f.fset,
+		info:   f.info,
+		api:    f.api,
+		funcs:  f.funcs,
+		name:   name,
+		nested: nested,
+		lits:   new(int),
+	}
+}
+
+// fileFunctions locates the function declarations and function
+// literals in a file of a package.  Whether each function is part of
+// the package's API is determined from the type information of the
+// package and its API methods.  Function literals outside of any
+// function are numbered within the package, as by the compiler, so
+// globs counts those literals across the files of the package.  The
+// result is never nil, so that a file whose source was read can be
+// distinguished from one whose source was not.
+func fileFunctions(pkg *packages.Package, api map[*types.Func]bool, file *ast.File, globs *int) []common.Extent {
+	funcs := []common.Extent{}
+	ast.Walk(funcFinder{fset: pkg.Fset, info: pkg.TypesInfo, api: api, funcs: &funcs, lits: globs}, file)
+	return funcs
+}
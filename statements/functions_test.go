@@ -0,0 +1,245 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package statements
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/klmitch/overcover/common"
+)
+
+const testFunctionSource = `package mod
+
+type T[K any] struct{ k K }
+
+func (t *T[K]) Get() K {
+	return t.k
+}
+
+func (t T[K]) Each(fn func(K)) {
+	fn(t.k)
+}
+
+func Outer(x int) func() int {
+	y := x * 2
+	return func() int {
+		f := func() int { return y }
+		return f() + 1
+	}
+}
+
+var global = func() int {
+	return 1
+}
+
+func Empty() {}
+
+func external()
+`
+
+func parseExpr(t *testing.T, src string) ast.Expr {
+	expr, err := parser.ParseExpr(src)
+	require.NoError(t, err)
+	return expr
+}
+
+func TestReceiverName(t *testing.T) {
+	assert.Equal(t, "T", receiverName(parseExpr(t, "T")))
+	assert.Equal(t, "(*T)", receiverName(parseExpr(t, "*T")))
+	assert.Equal(t, "(*T)", receiverName(parseExpr(t, "(*T)")))
+	assert.Equal(t, "(*T)", receiverName(parseExpr(t, "*T[K]")))
+	assert.Equal(t, "T", receiverName(parseExpr(t, "T[K, V]")))
+	assert.Equal(t, "pkg.T", receiverName(parseExpr(t, "pkg.T")))
+}
+
+const testAPISource = `package mod
+
+type T struct{ impl }
+
+func (T) Exported() {}
+
+func (*T) unexported() {}
+
+type impl struct{ *deep }
+
+func (impl) Promoted() {}
+
+type deep struct{}
+
+func (*deep) Deep() {}
+
+type hidden struct{}
+
+func (hidden) Hidden() {}
+
+type aliased struct{}
+
+func (aliased) Aliased() {}
+
+type Alias = aliased
+
+type G[K any] struct{ gimpl[K] }
+
+type gimpl[K any] struct{ k K }
+
+func (g gimpl[K]) Generic() K { return g.k }
+
+func F() {}
+
+func f() {}
+`
+
+// checkSource parses and type-checks a package with a single file,
+// returning the package as loaded by packages.Load.
+func checkSource(t *testing.T, fname, src string) *packages.Package {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, fname, src, parser.ParseComments)
+	require.NoError(t, err)
+	info := &types.Info{Defs: map[*ast.Ident]types.Object{}}
+	pkg, err := (&types.Config{}).Check("example.com/mod", fset, []*ast.File{file}, info)
+	require.NoError(t, err)
+
+	return &packages.Package{
+		ID:        "example.com/mod",
+		PkgPath:   "example.com/mod",
+		Fset:      fset,
+		Syntax:    []*ast.File{file},
+		Types:     pkg,
+		TypesInfo: info,
+	}
+}
+
+func TestAPIMethods(t *testing.T) {
+	pkg := checkSource(t, "api.go", testAPISource)
+
+	result := apiMethods(pkg.Types)
+
+	names := []string{}
+	for fn := range result {
+		names = append(names, fn.Name())
+	}
+	assert.ElementsMatch(t, []string{"Exported", "unexported", "Promoted", "Deep", "Aliased", "Generic"}, names)
+}
+
+func TestAPIMethodsNoPackage(t *testing.T) {
+	result := apiMethods(nil)
+
+	assert.Equal(t, map[*types.Func]bool{}, result)
+}
+
+func TestFuncExported(t *testing.T) {
+	pkg := checkSource(t, "api.go", testAPISource)
+	api := apiMethods(pkg.Types)
+	exported := map[string]bool{}
+	for _, decl := range pkg.Syntax[0].Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			exported[fn.Name.Name] = funcExported(pkg.TypesInfo, api, fn)
+		}
+	}
+
+	assert.Equal(t, map[string]bool{
+		"Exported":   true,
+		"unexported": false,
+		"Promoted":   true,
+		"Deep":       true,
+		"Hidden":     false,
+		"Aliased":    true,
+		"Generic":    true,
+		"F":          true,
+		"f":          false,
+	}, exported)
+}
+
+func TestFuncExportedNoInfo(t *testing.T) {
+	result := funcExported(nil, nil, &ast.FuncDecl{Name: ast.NewIdent("F")})
+
+	assert.False(t, result)
+}
+
+func TestFuncExportedUndefined(t *testing.T) {
+	result := funcExported(&types.Info{}, nil, &ast.FuncDecl{Name: ast.NewIdent("F")})
+
+	assert.False(t, result)
+}
+
+func TestFuncNameFunction(t *testing.T) {
+	result := funcName(&ast.FuncDecl{Name: ast.NewIdent("F")})
+
+	assert.Equal(t, "F", result)
+}
+
+func TestFuncNameMethod(t *testing.T) {
+	result := funcName(&ast.FuncDecl{
+		Recv: &ast.FieldList{
+			List: []*ast.Field{
+				{Type: &ast.StarExpr{X: ast.NewIdent("T")}},
+			},
+		},
+		Name: ast.NewIdent("M"),
+	})
+
+	assert.Equal(t, "(*T).M", result)
+}
+
+func TestFileFunctionsBase(t *testing.T) {
+	pkg := checkSource(t, "funcs.go", testFunctionSource)
+
+	result := fileFunctions(pkg, apiMethods(pkg.Types), pkg.Syntax[0], new(int))
+
+	assert.Equal(t, []common.Extent{
+		{Name: "(*T).Get", Line: 5, StartLine: 5, StartCol: 24, EndLine: 7, EndCol: 1, Exported: true},
+		{Name: "T.Each", Line: 9, StartLine: 9, StartCol: 32, EndLine: 11, EndCol: 1, Exported: true},
+		{Name: "Outer", Line: 13, StartLine: 13, StartCol: 30, EndLine: 19, EndCol: 1, Exported: true},
+		{Name: "Outer.func1", Line: 15, StartLine: 15, StartCol: 20, EndLine: 18, EndCol: 2},
+		{Name: "Outer.func1.1", Line: 16, StartLine: 16, StartCol: 19, EndLine: 16, EndCol: 30},
+		{Name: "glob..func1", Line: 21, StartLine: 21, StartCol: 25, EndLine: 23, EndCol: 1},
+		{Name: "Empty", Line: 25, StartLine: 25, StartCol: 14, EndLine: 25, EndCol: 15, Exported: true},
+	}, result)
+}
+
+func TestFileFunctionsGlobs(t *testing.T) {
+	pkg := checkSource(t, "globs.go", `package mod
+
+var a, b = func() {}, func() {
+	_ = func() {}
+}
+`)
+	globs := 2
+
+	result := fileFunctions(pkg, nil, pkg.Syntax[0], &globs)
+
+	assert.Equal(t, []common.Extent{
+		{Name: "glob..func3", Line: 3, StartLine: 3, StartCol: 19, EndLine: 3, EndCol: 20},
+		{Name: "glob..func4", Line: 3, StartLine: 3, StartCol: 30, EndLine: 5, EndCol: 1},
+		{Name: "glob..func4.1", Line: 4, StartLine: 4, StartCol: 13, EndLine: 4, EndCol: 14},
+	}, result)
+	assert.Equal(t, 4, globs)
+}
+
+func TestFileFunctionsEmpty(t *testing.T) {
+	pkg := checkSource(t, "empty.go", "package mod\n")
+
+	result := fileFunctions(pkg, nil, pkg.Syntax[0], new(int))
+
+	assert.Equal(t, []common.Extent{}, result)
+}
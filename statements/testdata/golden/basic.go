@@ -0,0 +1,67 @@
+package golden
+
+import "fmt"
+
+// Classify exercises if/else chains, comments, and blank lines.
+func Classify(x int) string {
+	result := ""
+
+	// A comment between statements splits the block
+	if x < 0 {
+		result = "negative"
+	} else if x == 0 {
+		result = "zero"
+	} else /* trailing */ {
+		result = "positive"
+	}
+
+	if y := x * 2; y > 10 {
+		result += "!"
+	} /* else if, */ else {
+		result += "."
+	}
+	return result
+}
+
+// Declare has a multi-line declaration inside a function.
+func Declare() string {
+	const (
+		a = "a"
+
+		b = "b"
+	)
+	var s = `line one
+
+line three`
+	return a + b + s
+}
+
+// Nested contains bare blocks.
+func Nested(x int) int {
+	{
+		x++
+	}
+	{
+	}
+	return x
+}
+
+// Empty has an empty body.
+func Empty() {}
+
+// Print has one statement.
+func Print(v any) { fmt.Println(v) }
+
+func _() {
+	fmt.Println("never")
+}
+
+type point struct{ x, y int }
+
+// Sum is a method.
+func (p *point) Sum() int {
+	if p == nil {
+		return 0
+	}
+	return p.x + p.y
+}
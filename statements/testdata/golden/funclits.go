@@ -0,0 +1,51 @@
+package golden
+
+import (
+	"fmt"
+	"sort"
+)
+
+var handler = func(s string) string {
+	return s + "!"
+}
+
+// FuncLits exercises function literals.
+func FuncLits(items []int) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	sort.Slice(items, func(i, j int) bool { return items[i] < items[j] })
+	go func() {}()
+
+	if f := func() bool { return len(items) > 0 }; f() {
+		items = append(items, 1)
+	}
+	for i := func() int { return 0 }(); i < len(items); i++ {
+		items[i]++
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, v := range items {
+			_ = handler(fmt.Sprint(v))
+		}
+	}()
+	<-done
+	x := func(v int) int {
+		return v * 2
+	}(3)
+	_ = x
+	return nil
+}
+
+// Panics exercises panic calls.
+func Panics(x int) int {
+	if x < 0 {
+		panic("negative")
+	}
+	x++
+	panic(fmt.Sprint(x))
+}
@@ -0,0 +1,45 @@
+package golden
+
+// Number is a type constraint.
+type Number interface {
+	~int | ~int64 | ~float64
+}
+
+// Stack is a generic type.
+type Stack[T any] struct {
+	items []T
+}
+
+// Push pushes an item.
+func (s *Stack[T]) Push(v T) {
+	s.items = append(s.items, v)
+}
+
+// Pop pops an item.
+func (s *Stack[T]) Pop() (T, bool) {
+	var zero T
+	if len(s.items) == 0 {
+		return zero, false
+	}
+	v := s.items[len(s.items)-1]
+	s.items = s.items[:len(s.items)-1]
+	return v, true
+}
+
+// Total sums numbers.
+func Total[N Number](nums ...N) N {
+	var total N
+	for _, n := range nums {
+		total += n
+	}
+	return total
+}
+
+// Map maps a slice.
+func Map[T, U any](in []T, f func(T) U) []U {
+	out := make([]U, 0, len(in))
+	for _, v := range in {
+		out = append(out, f(v))
+	}
+	return out
+}
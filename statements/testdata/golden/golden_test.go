@@ -0,0 +1,8 @@
+package golden
+
+import "testing"
+
+func TestGolden(t *testing.T) {
+	Classify(3)
+	Labels(4)
+}
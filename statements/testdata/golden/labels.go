@@ -0,0 +1,49 @@
+package golden
+
+// Labels exercises labeled statements and goto.
+func Labels(n int) int {
+	total := 0
+outer:
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if j > i {
+				continue outer
+			}
+			if i*j > 20 {
+				break outer
+			}
+			total++
+		}
+	}
+
+	i := 0
+loop:
+	i++
+	total += i
+	if i < n {
+		goto loop
+	}
+	return total
+}
+
+// LabelFirst starts with a label.
+func LabelFirst(n int) int {
+top:
+	n--
+	if n > 0 {
+		goto top
+	}
+	return n
+}
+
+// LabeledSwitch labels a switch.
+func LabeledSwitch(n int) int {
+sw:
+	switch {
+	case n > 0:
+		break sw
+	default:
+		n = -n
+	}
+	return n
+}
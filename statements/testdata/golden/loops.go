@@ -0,0 +1,27 @@
+package golden
+
+// Ranges exercises range bodies.
+func Ranges(m map[string]int, s []int) int {
+	total := 0
+	for _, v := range s {
+		total += v
+	}
+	for k, v := range m {
+		if k == "" {
+			continue
+		}
+		total += v
+	}
+	for range s {
+	}
+	for i := range 3 {
+		total += i
+	}
+	for {
+		total++
+		if total > 100 {
+			break
+		}
+	}
+	return total
+}
@@ -0,0 +1,34 @@
+package golden
+
+import "time"
+
+// Selects exercises select bodies.
+func Selects(c chan int, d chan string) int {
+	select {
+	case v := <-c:
+		return v
+	case s, ok := <-d:
+		if !ok {
+			return -1
+		}
+		return len(s)
+	case c <- 1:
+	case <-time.After(time.Millisecond):
+		return 0
+	default:
+	}
+
+	for {
+		select {
+		case <-c:
+			continue
+		default:
+			return 1
+		}
+	}
+}
+
+// Block blocks forever.
+func Block() {
+	select {}
+}
@@ -0,0 +1,37 @@
+package golden
+
+import "fmt"
+
+// Switches exercises switch and type switch statements.
+func Switches(x any) string {
+	switch v := x.(type) {
+	case int:
+		if v > 0 {
+			return "positive"
+		}
+		fallthrough_ := true
+		_ = fallthrough_
+	case string, []byte:
+		return fmt.Sprint(v)
+	case nil:
+	}
+
+	switch n := 3; n {
+	case 1, 2:
+		return "small"
+	case 3:
+		fallthrough
+	case 4:
+		return "medium"
+	}
+
+	switch {
+	}
+	switch x := 1; x {
+	}
+	switch x.(type) {
+	}
+	switch y := x; y.(type) {
+	}
+	return "other"
+}